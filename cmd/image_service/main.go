@@ -20,17 +20,25 @@ func main() {
 	cfg := config.MustLoad()
 
 	log := setupLogger(cfg.Env)
+	slog.SetDefault(log)
 
-	application := app.NewApp(log, cfg.GRPC.Port, cfg.Database)
+	application := app.NewApp(log, cfg)
+
+	application.Health.Start()
 
 	go application.GRPCSrv.MustRun()
+	go application.HTTPSrv.MustRun()
 
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGTERM, syscall.SIGINT)
 
 	<-stop
 
-	application.GRPCSrv.Stop()
+	if err := application.Shutdown(); err != nil {
+		log.Error("application stopped with errors", slog.String("error", err.Error()))
+		return
+	}
+
 	log.Info("application stopped")
 }
 
@@ -49,6 +57,12 @@ func setupLogger(env string) *slog.Logger {
 		log = slog.New(
 			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}),
 		)
+	default:
+		// Unknown environments get the production logger rather than nil.
+		log = slog.New(
+			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}),
+		)
+		log.Warn("unknown env, using the production logger", slog.String("env", env))
 	}
 
 	return log
@@ -1,92 +1,236 @@
 package main
 
 import (
-	"database/sql"
+	"context"
 	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"os"
+	"slices"
+	"strconv"
 
 	"github.com/aidosgal/image-processing-service/internal/config"
-	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
-	_ "github.com/lib/pq"
+	"github.com/aidosgal/image-processing-service/internal/lib/migrator"
+	"github.com/aidosgal/image-processing-service/internal/repository/psql"
+	"github.com/aidosgal/image-processing-service/migrations"
 )
 
+const usage = `usage: migrate [flags] [command]
+
+commands:
+  up [N]       apply all pending migrations, or only the next N (default command)
+  down [N]     revert the last N migrations (default 1)
+  goto V       migrate up or down to version V
+  force V      record version V as applied and clear the dirty flag
+  status       print the applied version and every migration
+  version      print the applied version
+
+flags:
+`
+
+var commands = []string{"up", "down", "goto", "force", "status", "version"}
+
 func main() {
 	var migrationPath, migrationTable string
-	flag.StringVar(&migrationPath, "migrations-path", "", "path to migrations")
-	flag.StringVar(&migrationTable, "migration-table", "migrations", "name of migration table")
+	var dryRun bool
+	flag.StringVar(&migrationPath, "migrations-path", "", "path to migrations, the embedded ones are used when empty")
+	flag.StringVar(&migrationTable, "migration-table", "", "name of migration table, database.migrations_table when empty")
+	flag.BoolVar(&dryRun, "dry-run", false, "print the migrations a command would run without running them")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
 	cfg := config.MustLoad()
 	flag.Parse()
 
-	if migrationPath == "" {
-		panic("migration path not defined")
+	if migrationTable == "" {
+		migrationTable = cfg.Database.MigrationsTable
 	}
 
-	err := ensureDatabaseExists(cfg)
+	args := flag.Args()
+	command := "up"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+	if !slices.Contains(commands, command) {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	postgresURL, err := psql.DSN(cfg.Database)
 	if err != nil {
-		log.Fatalf("failed to ensure database exists: %v", err)
+		log.Fatalf("failed to build database url: %v", err)
 	}
 
-	postgresURL := fmt.Sprintf(
-		"postgres://%s:%s@%s:%d/%s?sslmode=%s",
-		cfg.Database.User,
-		cfg.Database.Password,
-		cfg.Database.Host,
-		cfg.Database.Port,
-		cfg.Database.Name,
-		cfg.Database.SSLMode,
-	)
+	if !dryRun && command != "status" && command != "version" {
+		created, err := migrator.EnsureDatabase(context.Background(), postgresURL)
+		if err != nil {
+			log.Fatalf("failed to ensure database exists: %v", err)
+		}
+		if created {
+			fmt.Println("database created")
+		}
+	}
 
-	m, err := migrate.New("file://"+migrationPath, postgresURL)
+	var m *migrator.Migrator
+	if migrationPath != "" {
+		m, err = migrator.NewFromDir(migrationPath, postgresURL, migrationTable)
+	} else {
+		m, err = migrator.NewFromFS(migrations.FS, postgresURL, migrationTable)
+	}
 	if err != nil {
-		panic(err)
+		log.Fatalf("failed to load migrations: %v", err)
 	}
+	defer m.Close()
 
-	if err := m.Up(); err != nil {
-		if errors.Is(err, migrate.ErrNoChange) {
+	if err := run(m, command, args, dryRun); err != nil {
+		if errors.Is(err, migrator.ErrNoChange) {
 			fmt.Println("no migration to apply")
 			return
 		}
-		panic(err)
+		m.Close()
+		log.Fatal(err)
+	}
+}
+
+func run(m *migrator.Migrator, command string, args []string, dryRun bool) error {
+	switch command {
+	case "up":
+		n, err := optionalCount(args, 0)
+		if err != nil {
+			return err
+		}
+		if dryRun {
+			return printPlan(m.PlanUp(n))
+		}
+		if err := m.Up(n); err != nil {
+			return err
+		}
+
+	case "down":
+		n, err := optionalCount(args, 1)
+		if err != nil {
+			return err
+		}
+		if dryRun {
+			return printPlan(m.PlanDown(n))
+		}
+		if err := m.Down(n); err != nil {
+			return err
+		}
+
+	case "goto":
+		version, err := requiredArg(args, "goto V")
+		if err != nil {
+			return err
+		}
+		v, err := strconv.ParseUint(version, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version %q", version)
+		}
+		if dryRun {
+			return printPlan(m.PlanGoto(uint(v)))
+		}
+		if err := m.Goto(uint(v)); err != nil {
+			return err
+		}
+
+	case "force":
+		version, err := requiredArg(args, "force V")
+		if err != nil {
+			return err
+		}
+		v, err := strconv.Atoi(version)
+		if err != nil || v < -1 {
+			return fmt.Errorf("invalid version %q", version)
+		}
+		if dryRun {
+			fmt.Printf("would force version %d\n", v)
+			return nil
+		}
+		if err := m.Force(v); err != nil {
+			return err
+		}
+
+	case "status":
+		return printStatus(m, true)
+
+	case "version":
+		return printStatus(m, false)
+
+	default:
+		return fmt.Errorf("unknown command %q", command)
 	}
 
 	fmt.Println("migration applied successfully")
+
+	return printStatus(m, false)
+}
+
+func optionalCount(args []string, def int) (int, error) {
+	if len(args) == 0 {
+		return def, nil
+	}
+
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid number of migrations %q", args[0])
+	}
+
+	return n, nil
 }
 
-func ensureDatabaseExists(cfg *config.Config) error {
-	adminPostgresURL := fmt.Sprintf(
-		"postgres://%s:%s@%s:%d/postgres?sslmode=%s",
-		cfg.Database.User,
-		cfg.Database.Password,
-		cfg.Database.Host,
-		cfg.Database.Port,
-		cfg.Database.SSLMode,
-	)
-
-	db, err := sql.Open("postgres", adminPostgresURL)
+func requiredArg(args []string, usage string) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("usage: migrate %s", usage)
+	}
+
+	return args[0], nil
+}
+
+func printPlan(steps []migrator.Step, err error) error {
 	if err != nil {
-		return fmt.Errorf("failed to connect to PostgreSQL server: %w", err)
+		return err
 	}
-	defer db.Close()
+	if len(steps) == 0 {
+		fmt.Println("no migration to apply")
+		return nil
+	}
+
+	fmt.Println("would run:")
+	for _, step := range steps {
+		fmt.Printf("  %s\n", step)
+	}
+
+	return nil
+}
 
-	var exists bool
-	query := fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM pg_database WHERE datname = '%s')", cfg.Database.Name)
-	err = db.QueryRow(query).Scan(&exists)
+func printStatus(m *migrator.Migrator, all bool) error {
+	status, err := m.Status()
 	if err != nil {
-		return fmt.Errorf("failed to check if database exists: %w", err)
+		return err
 	}
 
-	if !exists {
-		_, err = db.Exec(fmt.Sprintf("CREATE DATABASE %s", cfg.Database.Name))
-		if err != nil {
-			return fmt.Errorf("failed to create database: %w", err)
+	switch {
+	case !status.Applied:
+		fmt.Println("version: none")
+	case status.Dirty:
+		fmt.Printf("version: %d (dirty)\n", status.Version)
+	default:
+		fmt.Printf("version: %d\n", status.Version)
+	}
+
+	if !all {
+		return nil
+	}
+
+	for _, migration := range status.Migrations {
+		state := "pending"
+		if status.Applied && migration.Version <= status.Version {
+			state = "applied"
 		}
-		fmt.Printf("database %s created successfully\n", cfg.Database.Name)
-	} else {
-		fmt.Printf("database %s already exists\n", cfg.Database.Name)
+		fmt.Printf("  %-8s %s\n", state, migration)
 	}
 
 	return nil
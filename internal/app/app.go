@@ -1,29 +1,210 @@
 package app
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
+	"net"
 
 	grpcapp "github.com/aidosgal/image-processing-service/internal/app/grpc"
+	httpapp "github.com/aidosgal/image-processing-service/internal/app/http"
 	"github.com/aidosgal/image-processing-service/internal/config"
-	"github.com/aidosgal/image-processing-service/internal/repository/psql"
+	"github.com/aidosgal/image-processing-service/internal/domain/model"
+	"github.com/aidosgal/image-processing-service/internal/lib/auth"
+	"github.com/aidosgal/image-processing-service/internal/lib/healthcheck"
+	"github.com/aidosgal/image-processing-service/internal/lib/lifecycle"
+	"github.com/aidosgal/image-processing-service/internal/lib/metrics"
+	"github.com/aidosgal/image-processing-service/internal/lib/pipeline"
+	"github.com/aidosgal/image-processing-service/internal/lib/ratelimit"
+	"github.com/aidosgal/image-processing-service/internal/lib/tlsconfig"
+	"github.com/aidosgal/image-processing-service/internal/lib/tracing"
+	"github.com/aidosgal/image-processing-service/internal/lib/variantcache"
+	"github.com/aidosgal/image-processing-service/internal/lib/watermark"
 	service "github.com/aidosgal/image-processing-service/internal/service/image"
+	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
 )
 
 type App struct {
 	GRPCSrv *grpcapp.App
+	HTTPSrv *httpapp.App
+	// Health runs the readiness checks behind the gRPC health service and /readyz.
+	Health *healthcheck.Checker
+
+	lifecycle *lifecycle.Manager
+}
+
+// Option replaces a part of the app that is otherwise built from the config.
+type Option func(*options)
+
+type options struct {
+	repository Repository
+	listener   net.Listener
+	registerer prometheus.Registerer
+}
+
+// WithRepository uses repo instead of opening the configured database. The
+// app closes it on shutdown.
+func WithRepository(repo Repository) Option {
+	return func(o *options) { o.repository = repo }
 }
 
-func NewApp(log *slog.Logger, grpcPort int, cfg config.DatabaseConfig) *App {
-	reposiry, err := psql.NewRepository(cfg)
+// WithGRPCListener serves gRPC on l instead of the configured port.
+func WithGRPCListener(l net.Listener) Option {
+	return func(o *options) { o.listener = l }
+}
+
+// WithRegisterer registers the app's collectors with reg instead of the
+// default registry, so several apps can run in one process.
+func WithRegisterer(reg prometheus.Registerer) Option {
+	return func(o *options) { o.registerer = reg }
+}
+
+func NewApp(log *slog.Logger, cfg *config.Config, opts ...Option) *App {
+	o := options{registerer: prometheus.DefaultRegisterer}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
 	if err != nil {
 		panic(err)
 	}
 
-	service := service.NewImageService(log, reposiry)
+	repo := o.repository
+	if repo == nil {
+		repo, err = newRepository(context.Background(), log, cfg.Database)
+		if err != nil {
+			panic(err)
+		}
+	}
 
-	grpcApp := grpcapp.NewApp(log, service, grpcPort)
+	authenticator, err := auth.New(cfg.Auth)
+	if err != nil {
+		panic(err)
+	}
+	if authenticator == nil {
+		log.Warn("authentication is disabled, tenant is taken from request metadata")
+	}
+
+	decodes := ratelimit.NewConcurrencyLimiter(cfg.Processing.MaxConcurrentDecodes, cfg.Processing.DecodeWaitTimeout)
+	metrics.RegisterDecodeQueue(o.registerer, decodes.InUse, decodes.Waiting)
+	metrics.RegisterStorageUsage(o.registerer, repo.GetTotalUsage)
+
+	cache, err := variantcache.New(variantcache.Config{
+		MemoryBytes: cfg.Cache.MemoryBytes,
+		DiskDir:     cfg.Cache.DiskDir,
+		DiskBytes:   cfg.Cache.DiskBytes,
+		// Image ids start over with every run of the memory repository.
+		Ephemeral: cfg.Database.Driver == "memory",
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	watermarks, err := watermark.Load(cfg.Watermark.Profiles)
+	if err != nil {
+		panic(err)
+	}
+	for _, k := range cfg.Auth.APIKeys {
+		if _, ok := watermarks[k.Watermark]; k.Watermark != "" && !ok {
+			panic(fmt.Sprintf("api key %q: unknown watermark profile %q", k.Name, k.Watermark))
+		}
+	}
+
+	presets, err := pipeline.LoadPresets(cfg.Transform.Presets)
+	if err != nil {
+		panic(err)
+	}
+	if name := cfg.Transform.ThumbnailPreset; name != "" && presets[name] == nil {
+		panic(fmt.Sprintf("thumbnail preset: unknown preset %q", name))
+	}
+
+	service := service.NewImageService(log, repo, service.Config{
+		StorageRoot: cfg.Storage.Root,
+		DefaultQuota: model.Quota{
+			MaxTotalBytes:         cfg.Quota.MaxTotalBytes,
+			MaxImages:             cfg.Quota.MaxImages,
+			MaxMonthlyUploadBytes: cfg.Quota.MaxMonthlyUploadBytes,
+		},
+		Decodes:   decodes,
+		MaxPixels: cfg.Processing.MaxPixels,
+		Timeouts: service.Timeouts{
+			Decode:   cfg.Processing.Timeouts.Decode,
+			Variants: cfg.Processing.Timeouts.Variants,
+		},
+		Similarity: service.Similarity{
+			MaxDistance:       cfg.Similarity.MaxDistance,
+			DuplicateDistance: cfg.Similarity.DuplicateDistance,
+			MaxResults:        cfg.Similarity.MaxResults,
+		},
+		Cache:      cache,
+		Watermarks: watermarks,
+		Transforms: service.Transforms{
+			MaxOperations: cfg.Transform.MaxOperations,
+			Presets:       presets,
+			Thumbnail:     presets[cfg.Transform.ThumbnailPreset],
+		},
+	})
+
+	var creds credentials.TransportCredentials
+	if cfg.GRPC.TLS.Enabled {
+		reloader, err := tlsconfig.NewReloader(log, cfg.GRPC.TLS)
+		if err != nil {
+			panic(err)
+		}
+		creds = credentials.NewTLS(reloader.Config())
+	} else {
+		log.Warn("tls is disabled, gRPC traffic is not encrypted")
+	}
+
+	healthServer := health.NewServer()
+	checker := healthcheck.NewChecker(log, healthServer,
+		[]string{imagev1.ImageService_ServiceDesc.ServiceName},
+		cfg.Health.Interval, cfg.Health.Timeout,
+	)
+	checker.Add("database", repo.Ping)
+	checker.Add("storage", service.CheckStorage)
+
+	// Reflection exposes the full API schema, so it is only served outside production.
+	reflection := cfg.Env == "local" || cfg.Env == "dev"
+
+	grpcApp := grpcapp.NewApp(log, service, cfg.GRPC, authenticator, cfg.Auth.PublicMethods, creds, healthServer, reflection)
+	if o.listener != nil {
+		grpcApp.UseListener(o.listener)
+	}
+
+	httpApp := httpapp.NewApp(log, cfg.HTTP.Port)
+	httpApp.Handle("GET /healthz", healthcheck.LivenessHandler())
+	httpApp.Handle("GET /readyz", checker.ReadinessHandler())
+
+	// Shutdown runs in dependency order: stop advertising readiness, let
+	// uploads and calls finish, then release what they were using.
+	manager := lifecycle.NewManager(log, cfg.Shutdown.DrainTimeout, cfg.Shutdown.CloseTimeout)
+	manager.OnDrain("health", func(context.Context) error {
+		checker.Stop()
+		return nil
+	})
+	manager.OnDrain("uploads", service.Drain)
+	manager.OnDrain("grpc", grpcApp.Shutdown)
+	manager.OnDrain("http", httpApp.Stop)
+	manager.OnClose("database", func(context.Context) error {
+		return repo.Close()
+	})
+	manager.OnClose("tracing", shutdownTracing)
 
 	return &App{
-		GRPCSrv: grpcApp,
+		GRPCSrv:   grpcApp,
+		HTTPSrv:   httpApp,
+		Health:    checker,
+		lifecycle: manager,
 	}
 }
+
+// Shutdown stops the servers, drains in-flight work and closes resources
+// within the configured deadlines.
+func (a *App) Shutdown() error {
+	return a.lifecycle.Shutdown()
+}
@@ -1,29 +1,110 @@
 package grpcapp
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"net"
+	"slices"
 
+	"github.com/aidosgal/image-processing-service/internal/config"
 	"github.com/aidosgal/image-processing-service/internal/delivery/image"
+	"github.com/aidosgal/image-processing-service/internal/delivery/interceptor"
+	"github.com/aidosgal/image-processing-service/internal/lib/auth"
+	"github.com/aidosgal/image-processing-service/internal/lib/ratelimit"
+	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	grpcreflection "google.golang.org/grpc/reflection"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionpbalpha "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
 )
 
 type App struct {
 	log        *slog.Logger
 	gRPCServer *grpc.Server
 	port       int
+	listener   net.Listener
 }
 
-func NewApp(log *slog.Logger, service image.ImageService, port int) *App {
-	gRPCServer := grpc.NewServer()
+// NewApp creates the gRPC server. When authenticator is nil the tenant is
+// taken from the request metadata as is, which is only meant for local use.
+// Without creds the server accepts plaintext connections. The health service
+// is always registered and public; server reflection only when reflection is
+// set.
+func NewApp(
+	log *slog.Logger,
+	service image.ImageService,
+	cfg config.GRPCConfig,
+	authenticator auth.Authenticator,
+	publicMethods []string,
+	creds credentials.TransportCredentials,
+	healthServer *health.Server,
+	reflection bool,
+) *App {
+	publicMethods = append(slices.Clone(publicMethods),
+		healthpb.Health_Check_FullMethodName,
+		healthpb.Health_Watch_FullMethodName,
+	)
+	if reflection {
+		publicMethods = append(publicMethods,
+			reflectionpb.ServerReflection_ServerReflectionInfo_FullMethodName,
+			reflectionpbalpha.ServerReflection_ServerReflectionInfo_FullMethodName,
+		)
+	}
 
-	image.Register(gRPCServer, service)
+	unary := []grpc.UnaryServerInterceptor{interceptor.LoggingUnary(log), interceptor.MetricsUnary()}
+	stream := []grpc.StreamServerInterceptor{interceptor.LoggingStream(log), interceptor.MetricsStream()}
+
+	if authenticator != nil {
+		unary = append(unary, interceptor.AuthUnary(log, authenticator, publicMethods))
+		stream = append(stream, interceptor.AuthStream(log, authenticator, publicMethods))
+	} else {
+		unary = append(unary, interceptor.TenantUnary(publicMethods))
+		stream = append(stream, interceptor.TenantStream(publicMethods))
+	}
+
+	guarded := []string{imagev1.ImageService_ServiceDesc.ServiceName}
+	unary = append(unary, interceptor.AuthorizeUnary(image.MethodRoles, guarded))
+	stream = append(stream, interceptor.AuthorizeStream(image.MethodRoles, guarded))
+
+	if cfg.RateLimit.Enabled {
+		limiter := ratelimit.NewKeyedLimiter(cfg.RateLimit.IdleTTL)
+		defaultLimit := ratelimit.Limit{Rate: cfg.RateLimit.Rate, Burst: cfg.RateLimit.Burst}
+		methodLimits := make(map[string]ratelimit.Limit, len(cfg.RateLimit.Methods))
+		for method, l := range cfg.RateLimit.Methods {
+			methodLimits[method] = ratelimit.Limit{Rate: l.Rate, Burst: l.Burst}
+		}
+
+		unary = append(unary, interceptor.RateLimitUnary(limiter, defaultLimit, methodLimits))
+		stream = append(stream, interceptor.RateLimitStream(limiter, defaultLimit, methodLimits))
+	}
+
+	opts := []grpc.ServerOption{
+		// Traces every call and continues the W3C trace context of the caller.
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	}
+	if creds != nil {
+		opts = append(opts, grpc.Creds(creds))
+	}
+
+	gRPCServer := grpc.NewServer(opts...)
+
+	image.Register(gRPCServer, log, service)
+	healthpb.RegisterHealthServer(gRPCServer, healthServer)
+	if reflection {
+		grpcreflection.Register(gRPCServer)
+	}
 
 	return &App{
 		log:        log,
 		gRPCServer: gRPCServer,
-		port:       port,
+		port:       cfg.Port,
 	}
 }
 
@@ -41,20 +122,58 @@ func (a *App) Run() error {
 		slog.Int("port", a.port),
 	)
 
-	l, err := net.Listen("tcp", fmt.Sprintf(":%d", a.port))
-	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+	l := a.listener
+	if l == nil {
+		var err error
+		l, err = net.Listen("tcp", fmt.Sprintf(":%d", a.port))
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
 	}
 
 	log.Info("starting gRPC server", slog.String("addr", l.Addr().String()))
 
-	if err := a.gRPCServer.Serve(l); err != nil {
+	if err := a.Serve(l); err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
 	return nil
 }
 
+// UseListener makes Run serve on l instead of listening on the configured
+// port. It must be called before Run.
+func (a *App) UseListener(l net.Listener) {
+	a.listener = l
+}
+
+// Serve accepts connections on l until the server is stopped.
+func (a *App) Serve(l net.Listener) error {
+	return a.gRPCServer.Serve(l)
+}
+
+// Shutdown stops accepting connections and waits for in-flight calls. When
+// ctx is done first the remaining calls are cancelled.
+func (a *App) Shutdown(ctx context.Context) error {
+	const op = "grpcapp.Shutdown"
+
+	a.log.With(slog.String("op", op)).Info("stopping gRPC server", slog.Int("port", a.port))
+
+	done := make(chan struct{})
+	go func() {
+		a.gRPCServer.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		a.gRPCServer.Stop()
+		<-done
+		return fmt.Errorf("%s: cancelled in-flight calls: %w", op, ctx.Err())
+	}
+}
+
 func (a *App) Stop() {
 	const op = "grpcapp.Stop"
 
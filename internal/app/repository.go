@@ -0,0 +1,82 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/aidosgal/image-processing-service/internal/config"
+	"github.com/aidosgal/image-processing-service/internal/lib/migrator"
+	"github.com/aidosgal/image-processing-service/internal/repository/memory"
+	"github.com/aidosgal/image-processing-service/internal/repository/psql"
+	"github.com/aidosgal/image-processing-service/internal/repository/sqlite"
+	service "github.com/aidosgal/image-processing-service/internal/service/image"
+	"github.com/aidosgal/image-processing-service/migrations"
+)
+
+// Repository is what the app needs from the configured store, on top of
+// what the service uses.
+type Repository interface {
+	service.Repository
+	Ping(ctx context.Context) error
+	Close() error
+	GetTotalUsage(ctx context.Context) (int64, int64, error)
+}
+
+func newRepository(ctx context.Context, log *slog.Logger, cfg config.DatabaseConfig) (Repository, error) {
+	const op = "app.newRepository"
+
+	switch cfg.Driver {
+	case "", "postgres":
+		repo, err := psql.NewRepository(ctx, log, cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		if cfg.MigrateOnStart {
+			if err := migrateOnStart(log, cfg); err != nil {
+				repo.Close()
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+		}
+
+		return repo, nil
+	case "sqlite":
+		log.Info("using sqlite repository", slog.String("path", cfg.SQLitePath))
+		return sqlite.NewRepository(ctx, cfg)
+	case "memory":
+		log.Warn("using in-memory repository, all data is lost on restart")
+		return memory.NewRepository(), nil
+	default:
+		return nil, fmt.Errorf("%s: unknown database driver %q", op, cfg.Driver)
+	}
+}
+
+// migrateOnStart applies the embedded migrations. It runs once the database
+// answers, and concurrent replicas wait for each other on the migration lock.
+func migrateOnStart(log *slog.Logger, cfg config.DatabaseConfig) error {
+	dsn, err := psql.DSN(cfg)
+	if err != nil {
+		return err
+	}
+
+	m, err := migrator.NewFromFS(migrations.FS, dsn, cfg.MigrationsTable)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	err = m.Up(0)
+	if err != nil && !errors.Is(err, migrator.ErrNoChange) {
+		return err
+	}
+
+	status, err := m.Status()
+	if err != nil {
+		return err
+	}
+	log.Info("database schema is up to date", slog.Uint64("version", uint64(status.Version)))
+
+	return nil
+}
@@ -9,24 +9,250 @@ import (
 )
 
 type Config struct {
-	Env      string         `yaml:"env" env-default:"local"`
-	DBName   string         `yaml:"db_name" env-default:"image_service"`
-	GRPC     GRPCConfig     `yaml:"grpc"`
-	Database DatabaseConfig `yaml:"database"`
+	Env        string           `yaml:"env" env-default:"local"`
+	DBName     string           `yaml:"db_name" env-default:"image_service"`
+	GRPC       GRPCConfig       `yaml:"grpc"`
+	HTTP       HTTPConfig       `yaml:"http"`
+	Database   DatabaseConfig   `yaml:"database"`
+	Auth       AuthConfig       `yaml:"auth"`
+	Quota      QuotaConfig      `yaml:"quota"`
+	Processing ProcessingConfig `yaml:"processing"`
+	Tracing    TracingConfig    `yaml:"tracing"`
+	Health     HealthConfig     `yaml:"health"`
+	Shutdown   ShutdownConfig   `yaml:"shutdown"`
+	Cache      CacheConfig      `yaml:"cache"`
+	Storage    StorageConfig    `yaml:"storage"`
+	Similarity SimilarityConfig `yaml:"similarity"`
+	Watermark  WatermarkConfig  `yaml:"watermark"`
+	Transform  TransformConfig  `yaml:"transform"`
 }
 
 type GRPCConfig struct {
-	Port    int           `yaml:"port"`
-	Timeout time.Duration `yaml:"timeout"`
+	Port      int             `yaml:"port"`
+	Timeout   time.Duration   `yaml:"timeout"`
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+	TLS       TLSConfig       `yaml:"tls"`
+}
+
+type TLSConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// ClientCAFile enables mutual TLS: clients must present a certificate signed by it.
+	ClientCAFile string `yaml:"client_ca_file"`
+	// MinVersion is "1.2" or "1.3".
+	MinVersion     string        `yaml:"min_version" env-default:"1.2"`
+	ReloadInterval time.Duration `yaml:"reload_interval" env-default:"30s"`
+}
+
+// RateLimitConfig configures token buckets per principal and method.
+// Rate is in requests per second.
+type RateLimitConfig struct {
+	Enabled bool                   `yaml:"enabled"`
+	Rate    float64                `yaml:"rate" env-default:"20"`
+	Burst   int                    `yaml:"burst" env-default:"40"`
+	Methods map[string]LimitConfig `yaml:"methods"`
+	IdleTTL time.Duration          `yaml:"idle_ttl" env-default:"10m"`
+}
+
+type LimitConfig struct {
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst"`
+}
+
+type ProcessingConfig struct {
+	// MaxConcurrentDecodes caps image decodes across all requests, 0 disables the cap.
+	MaxConcurrentDecodes int           `yaml:"max_concurrent_decodes" env-default:"4"`
+	DecodeWaitTimeout    time.Duration `yaml:"decode_wait_timeout" env-default:"5s"`
+	// MaxPixels rejects images whose header declares more pixels, before
+	// decoding them. 0 disables the limit.
+	MaxPixels int64              `yaml:"max_pixels" env-default:"50000000"`
+	Timeouts  ProcessingTimeouts `yaml:"timeouts"`
+}
+
+// ProcessingTimeouts bounds each processing operation on top of the RPC
+// deadline, 0 leaves an operation bounded by the deadline only.
+type ProcessingTimeouts struct {
+	Decode   time.Duration `yaml:"decode" env-default:"5s"`
+	Variants time.Duration `yaml:"variants" env-default:"10s"`
+}
+
+// StorageConfig locates stored files. Originals go to Root/images and
+// thumbnails to Root/thumbnails.
+type StorageConfig struct {
+	Root string `yaml:"root" env:"STORAGE_ROOT" env-default:"./uploads"`
+}
+
+// SimilarityConfig configures near-duplicate search. Distances are in bits
+// of the 64-bit perceptual hashes, 0 to 64.
+type SimilarityConfig struct {
+	// MaxDistance applies to FindSimilarImages requests that set none.
+	MaxDistance int `yaml:"max_distance" env-default:"10"`
+	// DuplicateDistance is the pHash distance up to which an upload is
+	// flagged as a near-duplicate.
+	DuplicateDistance int `yaml:"duplicate_distance" env-default:"4"`
+	MaxResults        int `yaml:"max_results" env-default:"50"`
+}
+
+// WatermarkConfig defines the watermark profiles by name. A profile is
+// applied when a GetImage request or the caller's API key names it.
+type WatermarkConfig struct {
+	Profiles map[string]WatermarkProfile `yaml:"profiles"`
+}
+
+// WatermarkProfile overlays either a logo or a line of text. Zero values
+// take the defaults noted below.
+type WatermarkProfile struct {
+	// Image is the path of the logo, usually a PNG with transparency.
+	Image string `yaml:"image"`
+	Text  string `yaml:"text"`
+	// Color is the text color as "#rrggbb", white by default.
+	Color string `yaml:"color"`
+	// Anchor is one of top_left, top, top_right, left, center, right,
+	// bottom_left, bottom or bottom_right (the default). Tiled profiles
+	// ignore it.
+	Anchor string `yaml:"anchor"`
+	// Margin is the distance from the edges, or between tiles, as a
+	// fraction of the shorter image side. 0.02 by default.
+	Margin float64 `yaml:"margin"`
+	// Opacity is 0.5 by default.
+	Opacity float64 `yaml:"opacity"`
+	// Scale is the watermark width as a fraction of the image width, 0.2
+	// by default.
+	Scale float64 `yaml:"scale"`
+	Tile  bool    `yaml:"tile"`
+	// OnUpload stores a watermarked variant of every upload, so retrieval
+	// does not have to render it.
+	OnUpload bool `yaml:"on_upload"`
+}
+
+// TransformConfig configures the TransformImage RPC. Presets are named
+// operation lists a request can use instead of listing operations itself.
+type TransformConfig struct {
+	// MaxOperations caps the operations of one request, 0 disables the cap.
+	MaxOperations int                          `yaml:"max_operations" env-default:"10"`
+	Presets       map[string][]OperationConfig `yaml:"presets"`
+	// ThumbnailPreset names the preset that renders the thumbnail of
+	// uploads. Empty keeps the 200px wide resize.
+	ThumbnailPreset string `yaml:"thumbnail_preset"`
+}
+
+// OperationConfig is one step of a preset, such as {name: blur, params: {sigma: 2}}.
+type OperationConfig struct {
+	Name   string            `yaml:"name"`
+	Params map[string]string `yaml:"params"`
+}
+
+// CacheConfig bounds the derived-variant cache. An empty DiskDir keeps the
+// cache in memory only.
+type CacheConfig struct {
+	MemoryBytes int64  `yaml:"memory_bytes" env-default:"67108864"`
+	DiskDir     string `yaml:"disk_dir" env-default:"./uploads/cache"`
+	DiskBytes   int64  `yaml:"disk_bytes" env-default:"1073741824"`
+}
+
+// ShutdownConfig bounds graceful shutdown. In-flight calls and uploads get
+// DrainTimeout to finish before they are cancelled; closing the database and
+// flushing telemetry then get CloseTimeout.
+type ShutdownConfig struct {
+	DrainTimeout time.Duration `yaml:"drain_timeout" env-default:"25s"`
+	CloseTimeout time.Duration `yaml:"close_timeout" env-default:"5s"`
+}
+
+// HealthConfig configures the background readiness checks of the database
+// and storage.
+type HealthConfig struct {
+	Interval time.Duration `yaml:"interval" env-default:"10s"`
+	Timeout  time.Duration `yaml:"timeout" env-default:"2s"`
+}
+
+// HTTPConfig configures the operational HTTP server serving /metrics and probes.
+type HTTPConfig struct {
+	Port int `yaml:"port" env-default:"9090"`
 }
 
 type DatabaseConfig struct {
+	// Driver selects the repository: "postgres", "sqlite" or "memory".
+	Driver string `yaml:"driver" env:"DATABASE_DRIVER" env-default:"postgres"`
+	// SQLitePath is the database file used by the sqlite driver.
+	SQLitePath string `yaml:"sqlite_path" env-default:"./data/image_service.db"`
+
+	// URL is a full connection string that takes precedence over the
+	// individual fields below.
+	URL      string `yaml:"url" env:"DATABASE_URL"`
 	User     string `yaml:"user"`
 	Password string `yaml:"password"`
 	Host     string `yaml:"host"`
 	Name     string `yaml:"name"`
-	Port     int    `yaml:"port"`
+	Port     int    `yaml:"port" env-default:"5432"`
 	SSLMode  string `yaml:"sslmode"`
+
+	MaxOpenConns    int           `yaml:"max_open_conns" env-default:"20"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env-default:"10"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env-default:"30m"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env-default:"5m"`
+
+	// ConnectAttempts and ConnectBackoff control the startup ping; the
+	// backoff doubles after every failed attempt.
+	ConnectAttempts int           `yaml:"connect_attempts" env-default:"5"`
+	ConnectBackoff  time.Duration `yaml:"connect_backoff" env-default:"1s"`
+	// QueryTimeout bounds every repository call, 0 disables it.
+	QueryTimeout time.Duration `yaml:"query_timeout" env-default:"5s"`
+
+	// MigrateOnStart applies the embedded migrations when the service
+	// starts. The database itself must already exist.
+	MigrateOnStart  bool   `yaml:"migrate_on_start" env:"DATABASE_MIGRATE_ON_START"`
+	MigrationsTable string `yaml:"migrations_table" env-default:"schema_migrations"`
+}
+
+type AuthConfig struct {
+	// Mode is one of "none", "api_key" or "jwt".
+	Mode string `yaml:"mode" env:"AUTH_MODE" env-default:"none"`
+	// PublicMethods are full gRPC method names that skip authentication.
+	PublicMethods []string       `yaml:"public_methods"`
+	APIKeys       []APIKeyConfig `yaml:"api_keys"`
+	JWT           JWTConfig      `yaml:"jwt"`
+}
+
+type APIKeyConfig struct {
+	Name string `yaml:"name"`
+	// Hash is the hex-encoded SHA-256 digest of the key.
+	Hash   string   `yaml:"hash"`
+	Tenant string   `yaml:"tenant"`
+	Roles  []string `yaml:"roles"`
+	// Watermark names a profile applied to every image the key retrieves.
+	Watermark string `yaml:"watermark"`
+}
+
+type JWTConfig struct {
+	HMACSecret string `yaml:"hmac_secret" env:"AUTH_JWT_HMAC_SECRET"`
+	JWKSFile   string `yaml:"jwks_file"`
+	// PublicKeys are PEM-encoded RSA public keys.
+	PublicKeys  []string      `yaml:"public_keys"`
+	Issuer      string        `yaml:"issuer"`
+	Audience    string        `yaml:"audience"`
+	Leeway      time.Duration `yaml:"leeway" env-default:"30s"`
+	TenantClaim string        `yaml:"tenant_claim" env-default:"tenant"`
+	RolesClaim  string        `yaml:"roles_claim" env-default:"roles"`
+}
+
+// QuotaConfig holds the default per-tenant limits. Zero means unlimited.
+type QuotaConfig struct {
+	MaxTotalBytes         int64 `yaml:"max_total_bytes"`
+	MaxImages             int64 `yaml:"max_images"`
+	MaxMonthlyUploadBytes int64 `yaml:"max_monthly_upload_bytes"`
+}
+
+type TracingConfig struct {
+	Enabled     bool   `yaml:"enabled"`
+	ServiceName string `yaml:"service_name" env-default:"image_service"`
+	// Exporter is "otlp", "stdout" or "file".
+	Exporter string `yaml:"exporter" env-default:"otlp"`
+	// Endpoint is the OTLP gRPC collector address.
+	Endpoint    string  `yaml:"endpoint" env:"TRACING_ENDPOINT" env-default:"localhost:4317"`
+	Insecure    bool    `yaml:"insecure"`
+	FilePath    string  `yaml:"file_path" env-default:"./traces.jsonl"`
+	SampleRatio float64 `yaml:"sample_ratio" env-default:"1"`
 }
 
 func MustLoad() *Config {
@@ -0,0 +1,26 @@
+package image
+
+import (
+	"github.com/aidosgal/image-processing-service/internal/lib/permission"
+	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
+)
+
+// MethodRoles is the minimum role required to call each RPC.
+var MethodRoles = map[string]permission.Role{
+	imagev1.ImageService_GetImage_FullMethodName:          permission.RoleViewer,
+	imagev1.ImageService_ListImages_FullMethodName:        permission.RoleViewer,
+	imagev1.ImageService_GetUsage_FullMethodName:          permission.RoleViewer,
+	imagev1.ImageService_FindSimilarImages_FullMethodName: permission.RoleViewer,
+	imagev1.ImageService_UploadImage_FullMethodName:       permission.RoleEditor,
+	imagev1.ImageService_TransformImage_FullMethodName:    permission.RoleEditor,
+	imagev1.ImageService_CreateAlbum_FullMethodName:       permission.RoleEditor,
+	imagev1.ImageService_AddImageToAlbum_FullMethodName:   permission.RoleEditor,
+	imagev1.ImageService_ShareImage_FullMethodName:        permission.RoleEditor,
+	imagev1.ImageService_ShareAlbum_FullMethodName:        permission.RoleEditor,
+	imagev1.ImageService_RevokeShare_FullMethodName:       permission.RoleEditor,
+	imagev1.ImageService_SetFocalPoint_FullMethodName:     permission.RoleEditor,
+	imagev1.ImageService_SetCropHints_FullMethodName:      permission.RoleEditor,
+	imagev1.ImageService_DeleteImage_FullMethodName:       permission.RoleAdmin,
+	imagev1.ImageService_PurgeImages_FullMethodName:       permission.RoleAdmin,
+	imagev1.ImageService_SetQuota_FullMethodName:          permission.RoleOperator,
+}
@@ -0,0 +1,25 @@
+package image
+
+import (
+	"testing"
+
+	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMethodRoles_CoverService(t *testing.T) {
+	desc := imagev1.ImageService_ServiceDesc
+
+	var methods []string
+	for _, m := range desc.Methods {
+		methods = append(methods, "/"+desc.ServiceName+"/"+m.MethodName)
+	}
+	for _, s := range desc.Streams {
+		methods = append(methods, "/"+desc.ServiceName+"/"+s.StreamName)
+	}
+
+	for _, method := range methods {
+		assert.Contains(t, MethodRoles, method, "no access policy for %s", method)
+	}
+	assert.Len(t, MethodRoles, len(methods), "a rule names a method the service doesn't have")
+}
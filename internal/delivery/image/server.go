@@ -2,27 +2,57 @@ package image
 
 import (
 	"context"
+	"errors"
+	"log/slog"
 
+	"github.com/aidosgal/image-processing-service/internal/domain/model"
+	"github.com/aidosgal/image-processing-service/internal/lib/logger"
+	"github.com/aidosgal/image-processing-service/internal/lib/palette"
+	"github.com/aidosgal/image-processing-service/internal/lib/permission"
+	"github.com/aidosgal/image-processing-service/internal/lib/ratelimit"
+	"github.com/aidosgal/image-processing-service/internal/lib/smartcrop"
+	"github.com/aidosgal/image-processing-service/internal/lib/tenant"
+	"github.com/aidosgal/image-processing-service/internal/lib/variantcache"
+	service "github.com/aidosgal/image-processing-service/internal/service/image"
 	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
 type ImageService interface {
 	UploadImage(ctx context.Context, image []byte, fileName string) (imageId int64, err error)
-	ListImages(ctx context.Context) (images []*imagev1.ImageMetadata, err error)
-	GetImage(ctx context.Context, image_id int64) (image []byte, metadata *imagev1.ImageMetadata, err error)
+	ListImages(ctx context.Context, filter service.ListFilter) (images []*imagev1.ImageMetadata, err error)
+	GetImage(ctx context.Context, image_id int64, watermark string) (image []byte, metadata *imagev1.ImageMetadata, err error)
 	DeleteImage(ctx context.Context, image_id int64) (is_deleted bool, err error)
+	PurgeImages(ctx context.Context) (deleted int64, err error)
+	CreateAlbum(ctx context.Context, name string) (album_id int64, err error)
+	AddImageToAlbum(ctx context.Context, album_id int64, image_id int64) error
+	ShareImage(ctx context.Context, image_id int64, grantee permission.Grantee, level permission.Level) error
+	ShareAlbum(ctx context.Context, album_id int64, grantee permission.Grantee, level permission.Level) error
+	RevokeImageShare(ctx context.Context, image_id int64, grantee permission.Grantee) error
+	RevokeAlbumShare(ctx context.Context, album_id int64, grantee permission.Grantee) error
+	GetUsage(ctx context.Context, tenant_id string) (usage model.Usage, quota model.Quota, err error)
+	SetQuota(ctx context.Context, tenant_id string, quota model.Quota) error
+	FindSimilarImages(ctx context.Context, query service.SimilarQuery) (matches []model.SimilarImage, err error)
+	NearDuplicates(ctx context.Context, image_id int64) (matches []model.SimilarImage)
+	TransformImage(ctx context.Context, req service.TransformRequest) (transformed *service.Transformed, err error)
+	SetFocalPoint(ctx context.Context, image_id int64, point smartcrop.Point) (focal *imagev1.FocalPoint, revision int64, err error)
+	SetCropHints(ctx context.Context, image_id int64, crops map[smartcrop.Aspect]smartcrop.Rect) (hints []*imagev1.CropHint, revision int64, err error)
 }
 
+// defaultColorDistance applies to color filters that set no distance.
+const defaultColorDistance = 20
+
 type serverAPI struct {
 	imagev1.UnimplementedImageServiceServer
+	log     *slog.Logger
 	service ImageService
 }
 
-func Register(gRPC *grpc.Server, service ImageService) {
-	imagev1.RegisterImageServiceServer(gRPC, &serverAPI{service: service})
+func Register(gRPC *grpc.Server, log *slog.Logger, service ImageService) {
+	imagev1.RegisterImageServiceServer(gRPC, &serverAPI{log: log, service: service})
 }
 
 func (s *serverAPI) UploadImage(ctx context.Context, req *imagev1.UploadImageRequest) (*imagev1.UploadImageResponse, error) {
@@ -36,16 +66,47 @@ func (s *serverAPI) UploadImage(ctx context.Context, req *imagev1.UploadImageReq
 
 	image_id, err := s.service.UploadImage(ctx, req.GetImage(), req.GetFilename())
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		var busy *ratelimit.BusyError
+		if errors.As(err, &busy) {
+			grpc.SetTrailer(ctx, metadata.Pairs(ratelimit.RetryAfterKey, ratelimit.RetryAfterSeconds(busy.RetryAfter)))
+		}
+		return nil, s.statusFromError(ctx, err, "failed to upload image")
 	}
 
-	return &imagev1.UploadImageResponse{
+	resp := &imagev1.UploadImageResponse{
 		ImageId: image_id,
-	}, nil
+	}
+	if req.GetFlagNearDuplicates() {
+		resp.NearDuplicates = similarImages(s.service.NearDuplicates(ctx, image_id))
+	}
+
+	return resp, nil
 }
 
 func (s *serverAPI) ListImages(ctx context.Context, req *imagev1.ListImagesRequest) (*imagev1.ListImagesResponse, error) {
-	images, err := s.service.ListImages(ctx)
+	filter := service.ListFilter{GrayscaleOnly: req.GetGrayscaleOnly()}
+
+	if f := req.GetColor(); f != nil {
+		c, err := palette.ParseHex(f.GetColor())
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "color filter: "+err.Error())
+		}
+		if f.GetMaxDistance() < 0 {
+			return nil, status.Error(codes.InvalidArgument, "color filter: max distance must not be negative")
+		}
+		if f.GetMinFraction() < 0 || f.GetMinFraction() > 1 {
+			return nil, status.Error(codes.InvalidArgument, "color filter: min fraction must be between 0 and 1")
+		}
+
+		filter.Color = &c
+		filter.ColorDistance = f.GetMaxDistance()
+		if filter.ColorDistance == 0 {
+			filter.ColorDistance = defaultColorDistance
+		}
+		filter.ColorMinFraction = f.GetMinFraction()
+	}
+
+	images, err := s.service.ListImages(ctx, filter)
 	if err != nil {
 		return nil, status.Error(codes.Internal, "internal error")
 	}
@@ -60,9 +121,9 @@ func (s *serverAPI) GetImage(ctx context.Context, req *imagev1.GetImageRequest)
 		return nil, status.Error(codes.InvalidArgument, "image id is required")
 	}
 
-	image, metadata, err := s.service.GetImage(ctx, req.GetImageId())
+	image, metadata, err := s.service.GetImage(ctx, req.GetImageId(), req.GetWatermark())
 	if err != nil {
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, s.statusFromError(ctx, err, "failed to get image")
 	}
 
 	return &imagev1.GetImageResponse{
@@ -71,6 +132,81 @@ func (s *serverAPI) GetImage(ctx context.Context, req *imagev1.GetImageRequest)
 	}, nil
 }
 
+func (s *serverAPI) TransformImage(ctx context.Context, req *imagev1.TransformImageRequest) (*imagev1.TransformImageResponse, error) {
+	if req.GetImageId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "image id is required")
+	}
+
+	ops := make([]variantcache.Op, 0, len(req.GetOperations()))
+	for _, op := range req.GetOperations() {
+		ops = append(ops, variantcache.Op{Name: op.GetName(), Params: op.GetParams()})
+	}
+
+	transformed, err := s.service.TransformImage(ctx, service.TransformRequest{
+		ImageID:   req.GetImageId(),
+		Preset:    req.GetPreset(),
+		Ops:       ops,
+		Format:    req.GetFormat(),
+		Watermark: req.GetWatermark(),
+	})
+	if err != nil {
+		return nil, s.statusFromError(ctx, err, "internal error")
+	}
+
+	return &imagev1.TransformImageResponse{
+		Image:    transformed.Image,
+		MimeType: transformed.MimeType,
+		Width:    int32(transformed.Width),
+		Height:   int32(transformed.Height),
+	}, nil
+}
+
+func (s *serverAPI) SetFocalPoint(ctx context.Context, req *imagev1.SetFocalPointRequest) (*imagev1.SetFocalPointResponse, error) {
+	if req.GetImageId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "image id is required")
+	}
+	point := smartcrop.Point{X: req.GetX(), Y: req.GetY()}
+	if !point.Valid() {
+		return nil, status.Error(codes.InvalidArgument, "x and y must be between 0 and 1")
+	}
+
+	focal, revision, err := s.service.SetFocalPoint(ctx, req.GetImageId(), point)
+	if err != nil {
+		return nil, s.statusFromError(ctx, err, "failed to set focal point")
+	}
+
+	return &imagev1.SetFocalPointResponse{FocalPoint: focal, Revision: revision}, nil
+}
+
+func (s *serverAPI) SetCropHints(ctx context.Context, req *imagev1.SetCropHintsRequest) (*imagev1.SetCropHintsResponse, error) {
+	if req.GetImageId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "image id is required")
+	}
+
+	crops := make(map[smartcrop.Aspect]smartcrop.Rect, len(req.GetCropHints()))
+	for _, hint := range req.GetCropHints() {
+		aspect, err := smartcrop.ParseAspect(hint.GetAspectRatio())
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "crop hint %q: %s", hint.GetAspectRatio(), err)
+		}
+		if _, ok := crops[aspect]; ok {
+			return nil, status.Errorf(codes.InvalidArgument, "more than one crop hint for %s", aspect)
+		}
+		r := smartcrop.Rect{X: hint.GetX(), Y: hint.GetY(), W: hint.GetWidth(), H: hint.GetHeight()}
+		if !r.Valid() {
+			return nil, status.Errorf(codes.InvalidArgument, "crop hint %s must have an area and lie within the image", aspect)
+		}
+		crops[aspect] = r
+	}
+
+	hints, revision, err := s.service.SetCropHints(ctx, req.GetImageId(), crops)
+	if err != nil {
+		return nil, s.statusFromError(ctx, err, "failed to set crop hints")
+	}
+
+	return &imagev1.SetCropHintsResponse{CropHints: hints, Revision: revision}, nil
+}
+
 func (s *serverAPI) DeleteImage(ctx context.Context, req *imagev1.DeleteImageRequest) (*imagev1.DeleteImageResponse, error) {
 	if req.GetImageId() == 0 {
 		return nil, status.Error(codes.InvalidArgument, "image id is required")
@@ -78,10 +214,298 @@ func (s *serverAPI) DeleteImage(ctx context.Context, req *imagev1.DeleteImageReq
 
 	is_deleted, err := s.service.DeleteImage(ctx, req.GetImageId())
 	if err != nil {
-		return nil, status.Error(codes.Internal, "internal error")
+		return nil, s.statusFromError(ctx, err, "internal error")
 	}
 
 	return &imagev1.DeleteImageResponse{
 		Success: is_deleted,
 	}, nil
 }
+
+func (s *serverAPI) PurgeImages(ctx context.Context, req *imagev1.PurgeImagesRequest) (*imagev1.PurgeImagesResponse, error) {
+	deleted, err := s.service.PurgeImages(ctx)
+	if err != nil {
+		return nil, s.statusFromError(ctx, err, "internal error")
+	}
+
+	return &imagev1.PurgeImagesResponse{
+		Deleted: deleted,
+	}, nil
+}
+
+func (s *serverAPI) CreateAlbum(ctx context.Context, req *imagev1.CreateAlbumRequest) (*imagev1.CreateAlbumResponse, error) {
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "album name is required")
+	}
+
+	album_id, err := s.service.CreateAlbum(ctx, req.GetName())
+	if err != nil {
+		return nil, s.statusFromError(ctx, err, "internal error")
+	}
+
+	return &imagev1.CreateAlbumResponse{
+		AlbumId: album_id,
+	}, nil
+}
+
+func (s *serverAPI) AddImageToAlbum(ctx context.Context, req *imagev1.AddImageToAlbumRequest) (*imagev1.AddImageToAlbumResponse, error) {
+	if req.GetAlbumId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "album id is required")
+	}
+
+	if req.GetImageId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "image id is required")
+	}
+
+	if err := s.service.AddImageToAlbum(ctx, req.GetAlbumId(), req.GetImageId()); err != nil {
+		return nil, s.statusFromError(ctx, err, "internal error")
+	}
+
+	return &imagev1.AddImageToAlbumResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *serverAPI) ShareImage(ctx context.Context, req *imagev1.ShareImageRequest) (*imagev1.ShareResponse, error) {
+	if req.GetImageId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "image id is required")
+	}
+
+	grantee, err := validateGrantee(req.GetGranteeTenantId(), req.GetGrantee())
+	if err != nil {
+		return nil, err
+	}
+	level, err := validateLevel(req.GetPermission())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.service.ShareImage(ctx, req.GetImageId(), grantee, level); err != nil {
+		return nil, s.statusFromError(ctx, err, "internal error")
+	}
+
+	return &imagev1.ShareResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *serverAPI) ShareAlbum(ctx context.Context, req *imagev1.ShareAlbumRequest) (*imagev1.ShareResponse, error) {
+	if req.GetAlbumId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "album id is required")
+	}
+
+	grantee, err := validateGrantee(req.GetGranteeTenantId(), req.GetGrantee())
+	if err != nil {
+		return nil, err
+	}
+	level, err := validateLevel(req.GetPermission())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.service.ShareAlbum(ctx, req.GetAlbumId(), grantee, level); err != nil {
+		return nil, s.statusFromError(ctx, err, "internal error")
+	}
+
+	return &imagev1.ShareResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *serverAPI) RevokeShare(ctx context.Context, req *imagev1.RevokeShareRequest) (*imagev1.RevokeShareResponse, error) {
+	grantee, err := validateGrantee(req.GetGranteeTenantId(), req.GetGrantee())
+	if err != nil {
+		return nil, err
+	}
+
+	switch target := req.GetTarget().(type) {
+	case *imagev1.RevokeShareRequest_ImageId:
+		err = s.service.RevokeImageShare(ctx, target.ImageId, grantee)
+	case *imagev1.RevokeShareRequest_AlbumId:
+		err = s.service.RevokeAlbumShare(ctx, target.AlbumId, grantee)
+	default:
+		return nil, status.Error(codes.InvalidArgument, "image id or album id is required")
+	}
+	if err != nil {
+		return nil, s.statusFromError(ctx, err, "internal error")
+	}
+
+	return &imagev1.RevokeShareResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *serverAPI) GetUsage(ctx context.Context, req *imagev1.GetUsageRequest) (*imagev1.GetUsageResponse, error) {
+	usage, quota, err := s.service.GetUsage(ctx, req.GetTenantId())
+	if err != nil {
+		return nil, s.statusFromError(ctx, err, "internal error")
+	}
+
+	return &imagev1.GetUsageResponse{
+		Usage: &imagev1.Usage{
+			TenantId:           usage.OwnerID,
+			TotalBytes:         usage.TotalBytes,
+			ImageCount:         usage.ImageCount,
+			MonthlyUploadBytes: usage.MonthlyUploadBytes,
+			Month:              usage.Month.Format("2006-01"),
+		},
+		Quota: &imagev1.Quota{
+			MaxTotalBytes:         quota.MaxTotalBytes,
+			MaxImages:             quota.MaxImages,
+			MaxMonthlyUploadBytes: quota.MaxMonthlyUploadBytes,
+		},
+	}, nil
+}
+
+func (s *serverAPI) SetQuota(ctx context.Context, req *imagev1.SetQuotaRequest) (*imagev1.SetQuotaResponse, error) {
+	if err := tenant.Validate(req.GetTenantId()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "valid tenant id is required")
+	}
+
+	q := req.GetQuota()
+	if q == nil {
+		return nil, status.Error(codes.InvalidArgument, "quota is required")
+	}
+
+	if q.GetMaxTotalBytes() < 0 || q.GetMaxImages() < 0 || q.GetMaxMonthlyUploadBytes() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "quota limits must not be negative")
+	}
+
+	err := s.service.SetQuota(ctx, req.GetTenantId(), model.Quota{
+		MaxTotalBytes:         q.GetMaxTotalBytes(),
+		MaxImages:             q.GetMaxImages(),
+		MaxMonthlyUploadBytes: q.GetMaxMonthlyUploadBytes(),
+	})
+	if err != nil {
+		return nil, s.statusFromError(ctx, err, "internal error")
+	}
+
+	return &imagev1.SetQuotaResponse{
+		Success: true,
+	}, nil
+}
+
+func (s *serverAPI) FindSimilarImages(ctx context.Context, req *imagev1.FindSimilarImagesRequest) (*imagev1.FindSimilarImagesResponse, error) {
+	query := service.SimilarQuery{MaxDistance: -1}
+
+	switch source := req.GetSource().(type) {
+	case *imagev1.FindSimilarImagesRequest_ImageId:
+		if source.ImageId == 0 {
+			return nil, status.Error(codes.InvalidArgument, "image id is required")
+		}
+		query.ImageID = source.ImageId
+	case *imagev1.FindSimilarImagesRequest_Image:
+		if len(source.Image) == 0 {
+			return nil, status.Error(codes.InvalidArgument, "image is required")
+		}
+		query.Image = source.Image
+	default:
+		return nil, status.Error(codes.InvalidArgument, "image id or image is required")
+	}
+
+	if req.MaxDistance != nil {
+		if d := req.GetMaxDistance(); d < 0 || d > 64 {
+			return nil, status.Error(codes.InvalidArgument, "max distance must be between 0 and 64")
+		}
+		query.MaxDistance = int(req.GetMaxDistance())
+	}
+
+	if req.GetLimit() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "limit must not be negative")
+	}
+	query.Limit = int(req.GetLimit())
+
+	switch req.GetAlgorithm() {
+	case imagev1.HashAlgorithm_HASH_ALGORITHM_UNSPECIFIED, imagev1.HashAlgorithm_HASH_ALGORITHM_PHASH:
+		query.Algorithm = model.HashPHash
+	case imagev1.HashAlgorithm_HASH_ALGORITHM_AHASH:
+		query.Algorithm = model.HashAHash
+	case imagev1.HashAlgorithm_HASH_ALGORITHM_DHASH:
+		query.Algorithm = model.HashDHash
+	default:
+		return nil, status.Error(codes.InvalidArgument, "unknown hash algorithm")
+	}
+
+	matches, err := s.service.FindSimilarImages(ctx, query)
+	if err != nil {
+		return nil, s.statusFromError(ctx, err, "internal error")
+	}
+
+	return &imagev1.FindSimilarImagesResponse{
+		Matches: similarImages(matches),
+	}, nil
+}
+
+func similarImages(matches []model.SimilarImage) []*imagev1.SimilarImage {
+	out := make([]*imagev1.SimilarImage, 0, len(matches))
+	for _, m := range matches {
+		out = append(out, &imagev1.SimilarImage{
+			Image:    m.Image,
+			Distance: int32(m.Distance),
+		})
+	}
+
+	return out
+}
+
+// validateGrantee checks the grantee of a share. An empty tenant is left
+// for the service to default to the owner's.
+func validateGrantee(tenantID, subject string) (permission.Grantee, error) {
+	if subject == "" {
+		return permission.Grantee{}, status.Error(codes.InvalidArgument, "grantee is required")
+	}
+	if tenantID != "" && tenant.Validate(tenantID) != nil {
+		return permission.Grantee{}, status.Error(codes.InvalidArgument, "invalid grantee tenant id")
+	}
+
+	return permission.Grantee{Tenant: tenantID, Subject: subject}, nil
+}
+
+func validateLevel(p imagev1.Permission) (permission.Level, error) {
+	switch p {
+	case imagev1.Permission_PERMISSION_READ:
+		return permission.LevelRead, nil
+	case imagev1.Permission_PERMISSION_WRITE:
+		return permission.LevelWrite, nil
+	}
+
+	return permission.LevelNone, status.Error(codes.InvalidArgument, "permission must be read or write")
+}
+
+// statusFromError maps service errors to gRPC status codes. Unknown errors
+// become Internal with the given message; their details are only logged,
+// since they may name files, queries or other internals.
+func (s *serverAPI) statusFromError(ctx context.Context, err error, internalMsg string) error {
+	switch {
+	case errors.Is(err, service.ErrImageNotFound):
+		return status.Error(codes.NotFound, "image not found")
+	case errors.Is(err, service.ErrAlbumNotFound):
+		return status.Error(codes.NotFound, "album not found")
+	case errors.Is(err, service.ErrShareNotFound):
+		return status.Error(codes.NotFound, "share not found")
+	case errors.Is(err, service.ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, service.ErrNotHashed):
+		return status.Error(codes.FailedPrecondition, "image has no perceptual hash, it was stored before hashing was added")
+	case errors.Is(err, service.ErrInvalidImage), errors.Is(err, service.ErrUnknownWatermark),
+		errors.Is(err, service.ErrInvalidTransform), errors.Is(err, service.ErrUnknownPreset),
+		errors.Is(err, service.ErrImageTooLarge):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, service.ErrBusy):
+		return status.Error(codes.ResourceExhausted, "server is busy processing images, retry later")
+	case errors.Is(err, service.ErrQuotaExceeded):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, "image processing timed out")
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, "request cancelled")
+	case errors.Is(err, service.ErrShuttingDown):
+		return status.Error(codes.Unavailable, "server is shutting down, retry later")
+	case errors.Is(err, tenant.ErrMissingTenant):
+		return status.Error(codes.Unauthenticated, "tenant id required")
+	}
+
+	logger.FromContext(ctx, s.log).Error("Request failed", "message", internalMsg, "error", err)
+
+	return status.Error(codes.Internal, internalMsg)
+}
@@ -0,0 +1,57 @@
+package image
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	service "github.com/aidosgal/image-processing-service/internal/service/image"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestStatusFromError(t *testing.T) {
+	var logs bytes.Buffer
+	s := &serverAPI{log: slog.New(slog.NewTextHandler(&logs, nil))}
+
+	tests := []struct {
+		name     string
+		err      error
+		wantCode codes.Code
+		wantMsg  string
+	}{
+		{
+			name:     "not found",
+			err:      fmt.Errorf("failed to get image: %w", service.ErrImageNotFound),
+			wantCode: codes.NotFound,
+			wantMsg:  "image not found",
+		},
+		{
+			name:     "invalid image keeps its reason",
+			err:      fmt.Errorf("%w: unsupported format", service.ErrInvalidImage),
+			wantCode: codes.InvalidArgument,
+			wantMsg:  fmt.Sprintf("%v: unsupported format", service.ErrInvalidImage),
+		},
+		{
+			name:     "internal error hides its details",
+			err:      errors.New("open /var/lib/images/tenant-a/3f2a.png: permission denied"),
+			wantCode: codes.Internal,
+			wantMsg:  "failed to get image",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st, _ := status.FromError(s.statusFromError(context.Background(), tt.err, "failed to get image"))
+			assert.Equal(t, tt.wantCode, st.Code())
+			assert.Equal(t, tt.wantMsg, st.Message())
+		})
+	}
+
+	assert.Contains(t, logs.String(), "/var/lib/images/tenant-a/3f2a.png", "internal details are logged")
+	assert.NotContains(t, logs.String(), "image not found", "mapped errors are not logged")
+}
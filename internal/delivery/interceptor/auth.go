@@ -0,0 +1,111 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/aidosgal/image-processing-service/internal/lib/auth"
+	"github.com/aidosgal/image-processing-service/internal/lib/logger"
+	"github.com/aidosgal/image-processing-service/internal/lib/tenant"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	apiKeyMetadataKey        = "x-api-key"
+	authorizationMetadataKey = "authorization"
+)
+
+type authInterceptor struct {
+	log           *slog.Logger
+	authenticator auth.Authenticator
+	publicMethods []string
+}
+
+// AuthUnary authenticates every call except the public methods and stores
+// the principal and its tenant in the context.
+func AuthUnary(log *slog.Logger, authenticator auth.Authenticator, publicMethods []string) grpc.UnaryServerInterceptor {
+	a := &authInterceptor{log: log, authenticator: authenticator, publicMethods: publicMethods}
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := a.authenticate(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// AuthStream is the streaming counterpart of AuthUnary.
+func AuthStream(log *slog.Logger, authenticator auth.Authenticator, publicMethods []string) grpc.StreamServerInterceptor {
+	a := &authInterceptor{log: log, authenticator: authenticator, publicMethods: publicMethods}
+
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.authenticate(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, wrapStream(ss, ctx))
+	}
+}
+
+func (a *authInterceptor) authenticate(ctx context.Context, method string) (context.Context, error) {
+	const op = "interceptor.authenticate"
+
+	log := logger.FromContext(ctx, a.log).With(slog.String("op", op))
+
+	credential := credentialFromMetadata(ctx)
+
+	if slices.Contains(a.publicMethods, method) && credential == "" {
+		return ctx, nil
+	}
+
+	principal, err := a.authenticator.Authenticate(ctx, credential)
+	if err != nil {
+		if errors.Is(err, auth.ErrMissingCredentials) {
+			return nil, status.Error(codes.Unauthenticated, "credentials required")
+		}
+		log.Warn("authentication failed", slog.String("error", err.Error()))
+		return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+	}
+
+	if err := tenant.Validate(principal.Tenant); err != nil {
+		log.Warn("principal has no valid tenant", slog.String("principal", principal.Subject))
+		return nil, status.Error(codes.PermissionDenied, "principal is not bound to a tenant")
+	}
+
+	log.Debug("request authenticated",
+		slog.String("principal", principal.Subject),
+		slog.String("auth_method", principal.Method),
+	)
+
+	ctx = withPrincipal(ctx, principal)
+	return tenant.WithID(ctx, principal.Tenant), nil
+}
+
+func credentialFromMetadata(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	if values := md.Get(apiKeyMetadataKey); len(values) > 0 && values[0] != "" {
+		return values[0]
+	}
+
+	if values := md.Get(authorizationMetadataKey); len(values) > 0 {
+		scheme, token, found := strings.Cut(values[0], " ")
+		if found && strings.EqualFold(scheme, "bearer") {
+			return strings.TrimSpace(token)
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,134 @@
+package interceptor
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/aidosgal/image-processing-service/internal/config"
+	"github.com/aidosgal/image-processing-service/internal/lib/auth"
+	"github.com/aidosgal/image-processing-service/internal/lib/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	publicMethod  = "/grpc.health.v1.Health/Check"
+	privateMethod = "/image.ImageService/GetImage"
+)
+
+// streamWithContext is a server stream that only carries a context.
+type streamWithContext struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *streamWithContext) Context() context.Context { return s.ctx }
+
+func testAuthenticator(t *testing.T) auth.Authenticator {
+	t.Helper()
+
+	a, err := auth.NewAPIKeyAuthenticator([]config.APIKeyConfig{
+		{Name: "editor", Hash: auth.HashAPIKey("editor-key"), Tenant: "tenant-a", Roles: []string{"editor"}},
+		{Name: "orphan", Hash: auth.HashAPIKey("orphan-key"), Roles: []string{"editor"}},
+	})
+	require.NoError(t, err)
+
+	return a
+}
+
+func TestAuthUnary(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	intercept := AuthUnary(log, testAuthenticator(t), []string{publicMethod})
+
+	tests := []struct {
+		name          string
+		method        string
+		md            metadata.MD
+		wantCode      codes.Code
+		wantPrincipal string
+	}{
+		{name: "public method without credentials", method: publicMethod, wantCode: codes.OK},
+		{name: "public method with valid key", method: publicMethod, md: metadata.Pairs("x-api-key", "editor-key"), wantCode: codes.OK, wantPrincipal: "editor"},
+		{name: "public method with invalid key", method: publicMethod, md: metadata.Pairs("x-api-key", "wrong-key"), wantCode: codes.Unauthenticated},
+		{name: "private method without credentials", method: privateMethod, wantCode: codes.Unauthenticated},
+		{name: "private method with empty key", method: privateMethod, md: metadata.Pairs("x-api-key", ""), wantCode: codes.Unauthenticated},
+		{name: "private method with invalid key", method: privateMethod, md: metadata.Pairs("x-api-key", "wrong-key"), wantCode: codes.Unauthenticated},
+		{name: "private method with api key", method: privateMethod, md: metadata.Pairs("x-api-key", "editor-key"), wantCode: codes.OK, wantPrincipal: "editor"},
+		{name: "private method with bearer token", method: privateMethod, md: metadata.Pairs("authorization", "Bearer editor-key"), wantCode: codes.OK, wantPrincipal: "editor"},
+		{name: "bearer scheme is case insensitive", method: privateMethod, md: metadata.Pairs("authorization", "bearer editor-key"), wantCode: codes.OK, wantPrincipal: "editor"},
+		{name: "other authorization scheme", method: privateMethod, md: metadata.Pairs("authorization", "Basic editor-key"), wantCode: codes.Unauthenticated},
+		{name: "principal without tenant", method: privateMethod, md: metadata.Pairs("x-api-key", "orphan-key"), wantCode: codes.PermissionDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, tt.md)
+			}
+
+			var called bool
+			var handlerCtx context.Context
+			handler := func(ctx context.Context, req any) (any, error) {
+				called, handlerCtx = true, ctx
+				return "ok", nil
+			}
+
+			resp, err := intercept(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			if tt.wantCode != codes.OK {
+				assert.False(t, called, "handler must not run")
+				assert.Nil(t, resp)
+				return
+			}
+
+			require.True(t, called)
+			principal, ok := auth.PrincipalFromContext(handlerCtx)
+			if tt.wantPrincipal == "" {
+				assert.False(t, ok, "no principal expected")
+				return
+			}
+
+			require.True(t, ok)
+			assert.Equal(t, tt.wantPrincipal, principal.Subject)
+			tenantID, err := tenant.FromContext(handlerCtx)
+			require.NoError(t, err)
+			assert.Equal(t, principal.Tenant, tenantID)
+		})
+	}
+}
+
+func TestAuthStream(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	intercept := AuthStream(log, testAuthenticator(t), []string{publicMethod})
+
+	run := func(method string, md metadata.MD) (context.Context, error) {
+		var handlerCtx context.Context
+		ss := &streamWithContext{ctx: metadata.NewIncomingContext(context.Background(), md)}
+		err := intercept(nil, ss, &grpc.StreamServerInfo{FullMethod: method}, func(_ any, stream grpc.ServerStream) error {
+			handlerCtx = stream.Context()
+			return nil
+		})
+		return handlerCtx, err
+	}
+
+	ctx, err := run(privateMethod, metadata.Pairs("x-api-key", "editor-key"))
+	require.NoError(t, err)
+	tenantID, err := tenant.FromContext(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "tenant-a", tenantID)
+
+	_, err = run(privateMethod, metadata.MD{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx, err = run(publicMethod, metadata.MD{})
+	require.NoError(t, err)
+	_, ok := auth.PrincipalFromContext(ctx)
+	assert.False(t, ok)
+}
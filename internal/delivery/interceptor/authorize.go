@@ -0,0 +1,59 @@
+package interceptor
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aidosgal/image-processing-service/internal/lib/auth"
+	"github.com/aidosgal/image-processing-service/internal/lib/permission"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AuthorizeUnary checks the principal's role against the role required by
+// the called method. Methods of the guarded services without a rule are denied.
+func AuthorizeUnary(rules map[string]permission.Role, guardedServices []string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := authorize(ctx, info.FullMethod, rules, guardedServices); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// AuthorizeStream is the streaming counterpart of AuthorizeUnary.
+func AuthorizeStream(rules map[string]permission.Role, guardedServices []string) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := authorize(ss.Context(), info.FullMethod, rules, guardedServices); err != nil {
+			return err
+		}
+
+		return handler(srv, ss)
+	}
+}
+
+func authorize(ctx context.Context, method string, rules map[string]permission.Role, guardedServices []string) error {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		// Only public methods get this far without a principal.
+		return nil
+	}
+
+	required, ok := rules[method]
+	if !ok {
+		for _, svc := range guardedServices {
+			if strings.HasPrefix(method, "/"+svc+"/") {
+				return status.Errorf(codes.PermissionDenied, "no access policy defined for %s", method)
+			}
+		}
+		return nil
+	}
+
+	if !permission.HasRole(principal.Roles, required) {
+		return status.Errorf(codes.PermissionDenied, "%s requires the %s role", method, required)
+	}
+
+	return nil
+}
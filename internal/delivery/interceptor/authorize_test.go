@@ -0,0 +1,135 @@
+package interceptor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aidosgal/image-processing-service/internal/delivery/image"
+	"github.com/aidosgal/image-processing-service/internal/lib/auth"
+	"github.com/aidosgal/image-processing-service/internal/lib/permission"
+	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var guardedServices = []string{imagev1.ImageService_ServiceDesc.ServiceName}
+
+func roleContext(roles ...string) context.Context {
+	return auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "alice", Tenant: "tenant-a", Roles: roles})
+}
+
+func TestAuthorize(t *testing.T) {
+	rules := map[string]permission.Role{
+		privateMethod: permission.RoleViewer,
+		uploadMethod:  permission.RoleEditor,
+	}
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		method   string
+		wantCode codes.Code
+	}{
+		{name: "no principal", ctx: context.Background(), method: uploadMethod, wantCode: codes.OK},
+		{name: "role meets the rule", ctx: roleContext("editor"), method: uploadMethod, wantCode: codes.OK},
+		{name: "higher role", ctx: roleContext("admin"), method: uploadMethod, wantCode: codes.OK},
+		{name: "role below the rule", ctx: roleContext("viewer"), method: uploadMethod, wantCode: codes.PermissionDenied},
+		{name: "no roles", ctx: roleContext(), method: privateMethod, wantCode: codes.PermissionDenied},
+		{name: "unknown role", ctx: roleContext("superuser"), method: privateMethod, wantCode: codes.PermissionDenied},
+		{name: "guarded method without a rule", ctx: roleContext("operator"), method: "/image.ImageService/Unknown", wantCode: codes.PermissionDenied},
+		{name: "method of an unguarded service", ctx: roleContext(), method: publicMethod, wantCode: codes.OK},
+		{name: "service name prefix only", ctx: roleContext(), method: "/image.ImageServiceV2/GetImage", wantCode: codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := authorize(tt.ctx, tt.method, rules, guardedServices)
+			assert.Equal(t, tt.wantCode, status.Code(err))
+		})
+	}
+}
+
+func TestAuthorize_MethodRoles(t *testing.T) {
+	roles := []permission.Role{permission.RoleViewer, permission.RoleEditor, permission.RoleAdmin, permission.RoleOperator}
+
+	// The lowest role allowed to call each method; every higher role may call it too.
+	tests := []struct {
+		method string
+		lowest permission.Role
+	}{
+		{method: imagev1.ImageService_GetImage_FullMethodName, lowest: permission.RoleViewer},
+		{method: imagev1.ImageService_ListImages_FullMethodName, lowest: permission.RoleViewer},
+		{method: imagev1.ImageService_GetUsage_FullMethodName, lowest: permission.RoleViewer},
+		{method: imagev1.ImageService_FindSimilarImages_FullMethodName, lowest: permission.RoleViewer},
+		{method: imagev1.ImageService_UploadImage_FullMethodName, lowest: permission.RoleEditor},
+		{method: imagev1.ImageService_TransformImage_FullMethodName, lowest: permission.RoleEditor},
+		{method: imagev1.ImageService_CreateAlbum_FullMethodName, lowest: permission.RoleEditor},
+		{method: imagev1.ImageService_AddImageToAlbum_FullMethodName, lowest: permission.RoleEditor},
+		{method: imagev1.ImageService_ShareImage_FullMethodName, lowest: permission.RoleEditor},
+		{method: imagev1.ImageService_ShareAlbum_FullMethodName, lowest: permission.RoleEditor},
+		{method: imagev1.ImageService_RevokeShare_FullMethodName, lowest: permission.RoleEditor},
+		{method: imagev1.ImageService_SetFocalPoint_FullMethodName, lowest: permission.RoleEditor},
+		{method: imagev1.ImageService_SetCropHints_FullMethodName, lowest: permission.RoleEditor},
+		{method: imagev1.ImageService_DeleteImage_FullMethodName, lowest: permission.RoleAdmin},
+		{method: imagev1.ImageService_PurgeImages_FullMethodName, lowest: permission.RoleAdmin},
+		{method: imagev1.ImageService_SetQuota_FullMethodName, lowest: permission.RoleOperator},
+	}
+	require.Len(t, tests, len(image.MethodRoles), "every rule is covered")
+
+	for _, tt := range tests {
+		allowed := false
+		for _, role := range roles {
+			allowed = allowed || role == tt.lowest
+			t.Run(tt.method+"/"+string(role), func(t *testing.T) {
+				err := authorize(roleContext(string(role)), tt.method, image.MethodRoles, guardedServices)
+				if allowed {
+					assert.NoError(t, err)
+				} else {
+					assert.Equal(t, codes.PermissionDenied, status.Code(err))
+				}
+			})
+		}
+	}
+}
+
+func TestAuthorizeUnary(t *testing.T) {
+	intercept := AuthorizeUnary(map[string]permission.Role{uploadMethod: permission.RoleEditor}, guardedServices)
+	info := &grpc.UnaryServerInfo{FullMethod: uploadMethod}
+
+	var calls int
+	handler := func(context.Context, any) (any, error) {
+		calls++
+		return "ok", nil
+	}
+
+	resp, err := intercept(roleContext("viewer"), nil, info, handler)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Nil(t, resp)
+	assert.Equal(t, 0, calls, "a denied call skips the handler")
+
+	resp, err = intercept(roleContext("editor"), nil, info, handler)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp)
+	assert.Equal(t, 1, calls)
+}
+
+func TestAuthorizeStream(t *testing.T) {
+	intercept := AuthorizeStream(map[string]permission.Role{uploadMethod: permission.RoleEditor}, guardedServices)
+	info := &grpc.StreamServerInfo{FullMethod: uploadMethod}
+
+	var calls int
+	handler := func(any, grpc.ServerStream) error {
+		calls++
+		return nil
+	}
+
+	err := intercept(nil, &streamWithContext{ctx: roleContext("viewer")}, info, handler)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Equal(t, 0, calls)
+
+	require.NoError(t, intercept(nil, &streamWithContext{ctx: roleContext("admin")}, info, handler))
+	assert.Equal(t, 1, calls)
+}
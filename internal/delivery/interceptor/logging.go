@@ -0,0 +1,135 @@
+package interceptor
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/aidosgal/image-processing-service/internal/lib/auth"
+	"github.com/aidosgal/image-processing-service/internal/lib/logger"
+	"github.com/aidosgal/image-processing-service/internal/lib/requestid"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// accessEntry collects what later interceptors learn about a request so it
+// can be included in the access log line.
+type accessEntry struct {
+	principal string
+}
+
+type accessEntryKey struct{}
+
+const healthServicePrefix = "/grpc.health.v1.Health/"
+
+// LoggingUnary assigns every call a request id, taken from the x-request-id
+// metadata when the caller sent a valid one, and echoes it in the response
+// header. The request id, method and trace id are attached to a logger that
+// is stored in the context. When the call finishes an access line with its
+// duration and status code is logged. It should run first in the chain.
+func LoggingUnary(log *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+
+		ctx, id, entry := withRequestLogger(ctx, log, info.FullMethod)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(requestid.MetadataKey, id))
+
+		resp, err := handler(ctx, req)
+		logAccess(ctx, log, entry, info.FullMethod, err, start)
+
+		return resp, err
+	}
+}
+
+// LoggingStream is the streaming counterpart of LoggingUnary.
+func LoggingStream(log *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+
+		ctx, id, entry := withRequestLogger(ss.Context(), log, info.FullMethod)
+		_ = ss.SetHeader(metadata.Pairs(requestid.MetadataKey, id))
+
+		err := handler(srv, wrapStream(ss, ctx))
+		logAccess(ctx, log, entry, info.FullMethod, err, start)
+
+		return err
+	}
+}
+
+func withRequestLogger(ctx context.Context, log *slog.Logger, method string) (context.Context, string, *accessEntry) {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestid.MetadataKey); len(values) > 0 && requestid.Valid(values[0]) {
+			id = values[0]
+		}
+	}
+	if id == "" {
+		id = requestid.New()
+	}
+
+	log = log.With(slog.String("request_id", id), slog.String("method", method))
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		log = log.With(slog.String("trace_id", sc.TraceID().String()))
+	}
+
+	entry := &accessEntry{}
+	ctx = requestid.WithID(ctx, id)
+	ctx = logger.WithContext(ctx, log)
+	ctx = context.WithValue(ctx, accessEntryKey{}, entry)
+
+	return ctx, id, entry
+}
+
+// withPrincipal stores the principal in the context and adds it to the
+// request logger and the access log line.
+func withPrincipal(ctx context.Context, p *auth.Principal) context.Context {
+	if entry, ok := ctx.Value(accessEntryKey{}).(*accessEntry); ok {
+		entry.principal = p.Subject
+	}
+
+	ctx = auth.WithPrincipal(ctx, p)
+	if log := logger.FromContext(ctx, nil); log != nil {
+		ctx = logger.WithContext(ctx, log.With(slog.String("principal", p.Subject)))
+	}
+
+	return ctx
+}
+
+func logAccess(ctx context.Context, log *slog.Logger, entry *accessEntry, method string, err error, start time.Time) {
+	code := status.Code(err)
+
+	attrs := []slog.Attr{
+		slog.String("code", code.String()),
+		slog.Duration("duration", time.Since(start)),
+	}
+	if entry.principal != "" {
+		attrs = append(attrs, slog.String("principal", entry.principal))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", status.Convert(err).Message()))
+	}
+
+	logger.FromContext(ctx, log).LogAttrs(ctx, accessLevel(method, code), "request completed", attrs...)
+}
+
+// accessLevel logs server-side failures as errors, timeouts as warnings and
+// everything else, including rejected client requests, as info. Successful
+// health probes are frequent and only logged at debug level.
+func accessLevel(method string, code codes.Code) slog.Level {
+	if code == codes.OK && strings.HasPrefix(method, healthServicePrefix) {
+		return slog.LevelDebug
+	}
+
+	switch code {
+	case codes.Unknown, codes.Internal, codes.DataLoss, codes.Unavailable:
+		return slog.LevelError
+	case codes.DeadlineExceeded:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
@@ -0,0 +1,42 @@
+package interceptor
+
+import (
+	"context"
+	"time"
+
+	"github.com/aidosgal/image-processing-service/internal/lib/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// MetricsUnary counts requests and records their latency per method and
+// status code. It should run first so rejected calls are counted too.
+func MetricsUnary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+
+		resp, err := handler(ctx, req)
+		observe(info.FullMethod, err, start)
+
+		return resp, err
+	}
+}
+
+// MetricsStream is the streaming counterpart of MetricsUnary.
+func MetricsStream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+
+		err := handler(srv, ss)
+		observe(info.FullMethod, err, start)
+
+		return err
+	}
+}
+
+func observe(method string, err error, start time.Time) {
+	code := status.Code(err).String()
+
+	metrics.GRPCRequests.WithLabelValues(method, code).Inc()
+	metrics.GRPCRequestDuration.WithLabelValues(method, code).Observe(time.Since(start).Seconds())
+}
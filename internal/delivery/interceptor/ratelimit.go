@@ -0,0 +1,87 @@
+package interceptor
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/aidosgal/image-processing-service/internal/lib/auth"
+	"github.com/aidosgal/image-processing-service/internal/lib/metrics"
+	"github.com/aidosgal/image-processing-service/internal/lib/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type rateLimiter struct {
+	limiter      *ratelimit.KeyedLimiter
+	defaultLimit ratelimit.Limit
+	methodLimits map[string]ratelimit.Limit
+}
+
+// RateLimitUnary applies a token bucket per principal and method. Rejected
+// calls get ResourceExhausted with a retry-after trailer.
+func RateLimitUnary(limiter *ratelimit.KeyedLimiter, defaultLimit ratelimit.Limit, methodLimits map[string]ratelimit.Limit) grpc.UnaryServerInterceptor {
+	rl := &rateLimiter{limiter: limiter, defaultLimit: defaultLimit, methodLimits: methodLimits}
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if ok, retryAfter := rl.allow(ctx, info.FullMethod); !ok {
+			grpc.SetTrailer(ctx, metadata.Pairs(ratelimit.RetryAfterKey, ratelimit.RetryAfterSeconds(retryAfter)))
+			return nil, rateLimited(retryAfter)
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// RateLimitStream is the streaming counterpart of RateLimitUnary.
+func RateLimitStream(limiter *ratelimit.KeyedLimiter, defaultLimit ratelimit.Limit, methodLimits map[string]ratelimit.Limit) grpc.StreamServerInterceptor {
+	rl := &rateLimiter{limiter: limiter, defaultLimit: defaultLimit, methodLimits: methodLimits}
+
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if ok, retryAfter := rl.allow(ss.Context(), info.FullMethod); !ok {
+			ss.SetTrailer(metadata.Pairs(ratelimit.RetryAfterKey, ratelimit.RetryAfterSeconds(retryAfter)))
+			return rateLimited(retryAfter)
+		}
+
+		return handler(srv, ss)
+	}
+}
+
+func (rl *rateLimiter) allow(ctx context.Context, method string) (bool, time.Duration) {
+	limit, ok := rl.methodLimits[method]
+	if !ok {
+		limit = rl.defaultLimit
+	}
+
+	ok, retryAfter := rl.limiter.Allow(callerKey(ctx)+"|"+method, limit)
+	if !ok {
+		metrics.RateLimitRejections.WithLabelValues(method).Inc()
+	}
+
+	return ok, retryAfter
+}
+
+func rateLimited(retryAfter time.Duration) error {
+	return status.Errorf(codes.ResourceExhausted, "rate limit exceeded, retry after %s", retryAfter.Round(time.Millisecond))
+}
+
+// callerKey identifies the caller by principal, falling back to the peer
+// address for unauthenticated calls.
+func callerKey(ctx context.Context) string {
+	if p, ok := auth.PrincipalFromContext(ctx); ok {
+		return p.Tenant + "/" + p.Subject
+	}
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		host, _, err := net.SplitHostPort(p.Addr.String())
+		if err != nil {
+			host = p.Addr.String()
+		}
+		return "peer/" + host
+	}
+
+	return "unknown"
+}
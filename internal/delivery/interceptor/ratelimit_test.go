@@ -0,0 +1,169 @@
+package interceptor
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/aidosgal/image-processing-service/internal/lib/auth"
+	"github.com/aidosgal/image-processing-service/internal/lib/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const uploadMethod = "/image.ImageService/UploadImage"
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// transportStream records the trailers set through grpc.SetTrailer.
+type transportStream struct {
+	trailer metadata.MD
+}
+
+func (s *transportStream) Method() string               { return "" }
+func (s *transportStream) SetHeader(metadata.MD) error  { return nil }
+func (s *transportStream) SendHeader(metadata.MD) error { return nil }
+func (s *transportStream) SetTrailer(md metadata.MD) error {
+	s.trailer = metadata.Join(s.trailer, md)
+	return nil
+}
+
+// trailerStream is a server stream that records its trailers.
+type trailerStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	trailer metadata.MD
+}
+
+func (s *trailerStream) Context() context.Context  { return s.ctx }
+func (s *trailerStream) SetTrailer(md metadata.MD) { s.trailer = metadata.Join(s.trailer, md) }
+
+func principalContext(tenantID, subject string) context.Context {
+	return auth.WithPrincipal(context.Background(), &auth.Principal{Subject: subject, Tenant: tenantID})
+}
+
+func peerContext(addr string) context.Context {
+	tcp, _ := net.ResolveTCPAddr("tcp", addr)
+	return peer.NewContext(context.Background(), &peer.Peer{Addr: tcp})
+}
+
+func TestRateLimitUnary(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	intercept := RateLimitUnary(
+		ratelimit.NewKeyedLimiter(time.Hour, ratelimit.WithClock(clock.Now)),
+		ratelimit.Limit{Rate: 1, Burst: 2},
+		map[string]ratelimit.Limit{uploadMethod: {Rate: 0.5, Burst: 1}},
+	)
+
+	call := func(ctx context.Context, method string) (*transportStream, error) {
+		stream := &transportStream{}
+		ctx = grpc.NewContextWithServerTransportStream(ctx, stream)
+		_, err := intercept(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, func(context.Context, any) (any, error) {
+			return "ok", nil
+		})
+		return stream, err
+	}
+
+	alice := principalContext("tenant-a", "alice")
+
+	for i := 0; i < 2; i++ {
+		_, err := call(alice, privateMethod)
+		require.NoError(t, err, "burst request %d", i)
+	}
+
+	stream, err := call(alice, privateMethod)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, []string{"1"}, stream.trailer.Get(ratelimit.RetryAfterKey))
+
+	// Methods have separate buckets, and a method can override the default limit.
+	_, err = call(alice, uploadMethod)
+	require.NoError(t, err)
+	stream, err = call(alice, uploadMethod)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, []string{"2"}, stream.trailer.Get(ratelimit.RetryAfterKey))
+
+	// Principals have separate buckets, also across tenants with the same subject.
+	_, err = call(principalContext("tenant-a", "bob"), privateMethod)
+	assert.NoError(t, err)
+	_, err = call(principalContext("tenant-b", "alice"), privateMethod)
+	assert.NoError(t, err)
+
+	clock.Advance(time.Second)
+	stream, err = call(alice, privateMethod)
+	assert.NoError(t, err, "a token refilled")
+	assert.Empty(t, stream.trailer)
+}
+
+func TestRateLimitUnary_RejectedCallSkipsHandler(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	intercept := RateLimitUnary(ratelimit.NewKeyedLimiter(time.Hour, ratelimit.WithClock(clock.Now)), ratelimit.Limit{Rate: 1, Burst: 1}, nil)
+
+	var calls int
+	handler := func(context.Context, any) (any, error) {
+		calls++
+		return "ok", nil
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: privateMethod}
+
+	_, err := intercept(principalContext("tenant-a", "alice"), nil, info, handler)
+	require.NoError(t, err)
+	resp, err := intercept(principalContext("tenant-a", "alice"), nil, info, handler)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Nil(t, resp)
+	assert.Equal(t, 1, calls)
+}
+
+func TestRateLimitUnary_UnauthenticatedByPeer(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	intercept := RateLimitUnary(ratelimit.NewKeyedLimiter(time.Hour, ratelimit.WithClock(clock.Now)), ratelimit.Limit{Rate: 1, Burst: 1}, nil)
+	info := &grpc.UnaryServerInfo{FullMethod: publicMethod}
+	handler := func(context.Context, any) (any, error) { return "ok", nil }
+
+	_, err := intercept(peerContext("10.0.0.1:1234"), nil, info, handler)
+	require.NoError(t, err)
+
+	_, err = intercept(peerContext("10.0.0.1:5678"), nil, info, handler)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "the port is not part of the key")
+
+	_, err = intercept(peerContext("10.0.0.2:1234"), nil, info, handler)
+	assert.NoError(t, err)
+}
+
+func TestRateLimitStream(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	intercept := RateLimitStream(ratelimit.NewKeyedLimiter(time.Hour, ratelimit.WithClock(clock.Now)), ratelimit.Limit{Rate: 0.25, Burst: 1}, nil)
+	info := &grpc.StreamServerInfo{FullMethod: privateMethod}
+
+	var calls int
+	handler := func(any, grpc.ServerStream) error {
+		calls++
+		return nil
+	}
+
+	stream := &trailerStream{ctx: principalContext("tenant-a", "alice")}
+	require.NoError(t, intercept(nil, stream, info, handler))
+	assert.Empty(t, stream.trailer)
+
+	stream = &trailerStream{ctx: principalContext("tenant-a", "alice")}
+	err := intercept(nil, stream, info, handler)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, []string{"4"}, stream.trailer.Get(ratelimit.RetryAfterKey))
+	assert.Equal(t, 1, calls)
+
+	clock.Advance(4 * time.Second)
+	stream = &trailerStream{ctx: principalContext("tenant-a", "alice")}
+	assert.NoError(t, intercept(nil, stream, info, handler))
+	assert.Equal(t, 2, calls)
+}
@@ -0,0 +1,77 @@
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/aidosgal/image-processing-service/internal/lib/auth"
+	"github.com/aidosgal/image-processing-service/internal/lib/permission"
+	"github.com/aidosgal/image-processing-service/internal/lib/tenant"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const anonymousSubject = "anonymous"
+
+// anonymousRole is granted to every caller when authentication is disabled.
+// Deleting, purging and quotas need a configured API key or token.
+const anonymousRole = permission.RoleEditor
+
+// TenantUnary reads the tenant id from the request metadata and stores it
+// in the context. Requests without a valid tenant id are rejected, except
+// for public methods called without one. It is used when authentication is
+// disabled, so the caller acts as an editor of that tenant.
+func TenantUnary(publicMethods []string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := withTenant(ctx, info.FullMethod, publicMethods)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func withTenant(ctx context.Context, method string, publicMethods []string) (context.Context, error) {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(tenant.MetadataKey); len(values) > 0 {
+			id = values[0]
+		}
+	}
+
+	if id == "" && slices.Contains(publicMethods, method) {
+		return ctx, nil
+	}
+
+	if err := tenant.Validate(id); err != nil {
+		if errors.Is(err, tenant.ErrMissingTenant) {
+			return nil, status.Error(codes.Unauthenticated, "tenant id required")
+		}
+		return nil, status.Error(codes.InvalidArgument, "invalid tenant id")
+	}
+
+	ctx = withPrincipal(ctx, &auth.Principal{
+		Subject: anonymousSubject,
+		Tenant:  id,
+		Roles:   []string{string(anonymousRole)},
+		Method:  auth.ModeNone,
+	})
+
+	return tenant.WithID(ctx, id), nil
+}
+
+// TenantStream is the streaming counterpart of TenantUnary.
+func TenantStream(publicMethods []string) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := withTenant(ss.Context(), info.FullMethod, publicMethods)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, wrapStream(ss, ctx))
+	}
+}
@@ -0,0 +1,61 @@
+package interceptor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aidosgal/image-processing-service/internal/lib/auth"
+	"github.com/aidosgal/image-processing-service/internal/lib/permission"
+	"github.com/aidosgal/image-processing-service/internal/lib/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestTenantUnary(t *testing.T) {
+	intercept := TenantUnary([]string{publicMethod})
+
+	tests := []struct {
+		name     string
+		method   string
+		md       metadata.MD
+		wantCode codes.Code
+		wantAnon bool
+	}{
+		{name: "tenant from metadata", method: privateMethod, md: metadata.Pairs(tenant.MetadataKey, "tenant-a"), wantCode: codes.OK, wantAnon: true},
+		{name: "missing tenant", method: privateMethod, wantCode: codes.Unauthenticated},
+		{name: "invalid tenant", method: privateMethod, md: metadata.Pairs(tenant.MetadataKey, "../tenant-a"), wantCode: codes.InvalidArgument},
+		{name: "public method without tenant", method: publicMethod, wantCode: codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, tt.md)
+			}
+
+			var handlerCtx context.Context
+			_, err := intercept(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, func(ctx context.Context, _ any) (any, error) {
+				handlerCtx = ctx
+				return "ok", nil
+			})
+			require.Equal(t, tt.wantCode, status.Code(err))
+			if err != nil {
+				return
+			}
+
+			principal, ok := auth.PrincipalFromContext(handlerCtx)
+			require.Equal(t, tt.wantAnon, ok)
+			if !ok {
+				return
+			}
+			assert.Equal(t, "tenant-a", principal.Tenant)
+			assert.Equal(t, []string{string(permission.RoleEditor)}, principal.Roles)
+			assert.False(t, permission.HasRole(principal.Roles, permission.RoleAdmin), "anonymous callers are not admins")
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"errors"
+
+	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
+)
+
+var ErrNotHashed = errors.New("image has no perceptual hash")
+
+// HashAlgorithm names one of the perceptual hashes stored with an image.
+type HashAlgorithm string
+
+const (
+	HashAHash HashAlgorithm = "ahash"
+	HashDHash HashAlgorithm = "dhash"
+	HashPHash HashAlgorithm = "phash"
+)
+
+// Of returns the hash of this algorithm from h. It reports false when the
+// image was stored without hashes.
+func (a HashAlgorithm) Of(h *imagev1.PerceptualHash) (uint64, bool) {
+	if h == nil {
+		return 0, false
+	}
+
+	switch a {
+	case HashAHash:
+		return h.GetAhash(), true
+	case HashDHash:
+		return h.GetDhash(), true
+	case HashPHash:
+		return h.GetPhash(), true
+	}
+
+	return 0, false
+}
+
+// SimilarityQuery selects the images of a tenant whose Algorithm hash is at
+// most MaxDistance bits away from Hash. ExcludeID leaves out the image the
+// hash was taken from; Limit caps the number of matches.
+type SimilarityQuery struct {
+	Algorithm   HashAlgorithm
+	Hash        uint64
+	MaxDistance int
+	Limit       int
+	ExcludeID   int64
+}
+
+// SimilarImage is a match of a SimilarityQuery and its Hamming distance.
+type SimilarImage struct {
+	Image    *imagev1.ImageMetadata
+	Distance int
+}
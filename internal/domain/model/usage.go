@@ -0,0 +1,46 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// Usage is the storage accounted to a tenant. TotalBytes includes generated
+// variants, MonthlyUploadBytes only counts original uploads of Month.
+type Usage struct {
+	OwnerID            string
+	TotalBytes         int64
+	ImageCount         int64
+	Month              time.Time
+	MonthlyUploadBytes int64
+}
+
+// Quota limits a tenant's usage. A zero limit means unlimited.
+type Quota struct {
+	MaxTotalBytes         int64
+	MaxImages             int64
+	MaxMonthlyUploadBytes int64
+}
+
+// Check reports whether storing an upload of uploadBytes, taking storedBytes
+// together with its variants, keeps usage within the quota.
+func (q Quota) Check(u Usage, uploadBytes int64, storedBytes int64) error {
+	if q.MaxImages > 0 && u.ImageCount+1 > q.MaxImages {
+		return fmt.Errorf("%w: image count limit of %d reached", ErrQuotaExceeded, q.MaxImages)
+	}
+
+	if q.MaxTotalBytes > 0 && u.TotalBytes+storedBytes > q.MaxTotalBytes {
+		return fmt.Errorf("%w: storage limit of %d bytes reached (%d bytes used)",
+			ErrQuotaExceeded, q.MaxTotalBytes, u.TotalBytes)
+	}
+
+	if q.MaxMonthlyUploadBytes > 0 && u.MonthlyUploadBytes+uploadBytes > q.MaxMonthlyUploadBytes {
+		return fmt.Errorf("%w: monthly upload limit of %d bytes reached (%d bytes uploaded this month)",
+			ErrQuotaExceeded, q.MaxMonthlyUploadBytes, u.MonthlyUploadBytes)
+	}
+
+	return nil
+}
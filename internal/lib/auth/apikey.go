@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/aidosgal/image-processing-service/internal/config"
+)
+
+const MethodAPIKey = "api_key"
+
+type apiKey struct {
+	hash      []byte
+	principal Principal
+}
+
+// APIKeyAuthenticator checks keys against the SHA-256 hashes listed in config,
+// so plaintext keys never have to be stored.
+type APIKeyAuthenticator struct {
+	keys []apiKey
+}
+
+func NewAPIKeyAuthenticator(cfg []config.APIKeyConfig) (*APIKeyAuthenticator, error) {
+	const op = "auth.NewAPIKeyAuthenticator"
+
+	keys := make([]apiKey, 0, len(cfg))
+	for _, k := range cfg {
+		hash, err := hex.DecodeString(strings.TrimSpace(k.Hash))
+		if err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("%s: api key %q: hash must be a hex-encoded sha256 digest", op, k.Name)
+		}
+
+		keys = append(keys, apiKey{
+			hash: hash,
+			principal: Principal{
+				Subject:   k.Name,
+				Tenant:    k.Tenant,
+				Roles:     k.Roles,
+				Method:    MethodAPIKey,
+				Watermark: k.Watermark,
+			},
+		})
+	}
+
+	return &APIKeyAuthenticator{keys: keys}, nil
+}
+
+func (a *APIKeyAuthenticator) Authenticate(_ context.Context, credential string) (*Principal, error) {
+	if credential == "" {
+		return nil, ErrMissingCredentials
+	}
+
+	sum := sha256.Sum256([]byte(credential))
+
+	for _, k := range a.keys {
+		if subtle.ConstantTimeCompare(sum[:], k.hash) == 1 {
+			p := k.principal
+			return &p, nil
+		}
+	}
+
+	return nil, ErrInvalidCredentials
+}
+
+// HashAPIKey returns the value to put into config for the given key.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aidosgal/image-processing-service/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyAuthenticator(t *testing.T) {
+	a, err := NewAPIKeyAuthenticator([]config.APIKeyConfig{
+		{Name: "editor", Hash: HashAPIKey("editor-key"), Tenant: "tenant-a", Roles: []string{"editor"}},
+		// Upper case and surrounding spaces are accepted in config.
+		{Name: "viewer", Hash: " " + strings.ToUpper(HashAPIKey("viewer-key")) + " ", Tenant: "tenant-b", Roles: []string{"viewer"}, Watermark: "proof"},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		key     string
+		want    *Principal
+		wantErr error
+	}{
+		{
+			name: "first key",
+			key:  "editor-key",
+			want: &Principal{Subject: "editor", Tenant: "tenant-a", Roles: []string{"editor"}, Method: MethodAPIKey},
+		},
+		{
+			name: "second key",
+			key:  "viewer-key",
+			want: &Principal{Subject: "viewer", Tenant: "tenant-b", Roles: []string{"viewer"}, Method: MethodAPIKey, Watermark: "proof"},
+		},
+		{name: "unknown key", key: "other-key", wantErr: ErrInvalidCredentials},
+		{name: "key differing in case", key: "Editor-key", wantErr: ErrInvalidCredentials},
+		{name: "hash instead of key", key: HashAPIKey("editor-key"), wantErr: ErrInvalidCredentials},
+		{name: "empty", key: "", wantErr: ErrMissingCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := a.Authenticate(context.Background(), tt.key)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, got)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAPIKeyAuthenticator_ReturnsCopies(t *testing.T) {
+	a, err := NewAPIKeyAuthenticator([]config.APIKeyConfig{
+		{Name: "editor", Hash: HashAPIKey("editor-key"), Tenant: "tenant-a"},
+	})
+	require.NoError(t, err)
+
+	p, err := a.Authenticate(context.Background(), "editor-key")
+	require.NoError(t, err)
+	p.Tenant = "tenant-b"
+
+	p, err = a.Authenticate(context.Background(), "editor-key")
+	require.NoError(t, err)
+	assert.Equal(t, "tenant-a", p.Tenant)
+}
+
+func TestNewAPIKeyAuthenticator_InvalidHash(t *testing.T) {
+	tests := []struct {
+		name string
+		hash string
+	}{
+		{name: "empty", hash: ""},
+		{name: "not hex", hash: strings.Repeat("z", 64)},
+		{name: "too short", hash: HashAPIKey("key")[:62]},
+		{name: "plaintext key", hash: "admin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewAPIKeyAuthenticator([]config.APIKeyConfig{{Name: "key", Hash: tt.hash}})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestHashAPIKey(t *testing.T) {
+	assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", HashAPIKey("hello"))
+}
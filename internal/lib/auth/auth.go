@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"fmt"
+
+	"github.com/aidosgal/image-processing-service/internal/config"
+)
+
+const (
+	ModeNone   = "none"
+	ModeAPIKey = "api_key"
+	ModeJWT    = "jwt"
+)
+
+// New builds the authenticator selected by cfg.Mode. It returns nil when
+// authentication is disabled.
+func New(cfg config.AuthConfig) (Authenticator, error) {
+	switch cfg.Mode {
+	case ModeNone, "":
+		return nil, nil
+	case ModeAPIKey:
+		a, err := NewAPIKeyAuthenticator(cfg.APIKeys)
+		if err != nil {
+			return nil, err
+		}
+		return a, nil
+	case ModeJWT:
+		a, err := NewJWTAuthenticator(cfg.JWT)
+		if err != nil {
+			return nil, err
+		}
+		return a, nil
+	}
+
+	return nil, fmt.Errorf("auth.New: unknown auth mode %q", cfg.Mode)
+}
@@ -0,0 +1,206 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/aidosgal/image-processing-service/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const MethodJWT = "jwt"
+
+// JWTAuthenticator validates HS256 and RS256 bearer tokens. RSA keys come
+// from a local JWKS file and/or PEM keys listed in config.
+type JWTAuthenticator struct {
+	secret      []byte
+	keys        map[string]*rsa.PublicKey
+	parser      *jwt.Parser
+	tenantClaim string
+	rolesClaim  string
+}
+
+func NewJWTAuthenticator(cfg config.JWTConfig) (*JWTAuthenticator, error) {
+	const op = "auth.NewJWTAuthenticator"
+
+	a := &JWTAuthenticator{
+		keys:        make(map[string]*rsa.PublicKey),
+		tenantClaim: cfg.TenantClaim,
+		rolesClaim:  cfg.RolesClaim,
+	}
+
+	var methods []string
+
+	if cfg.HMACSecret != "" {
+		a.secret = []byte(cfg.HMACSecret)
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+
+	if cfg.JWKSFile != "" {
+		keys, err := loadJWKS(cfg.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		for kid, key := range keys {
+			a.keys[kid] = key
+		}
+	}
+
+	for i, pem := range cfg.PublicKeys {
+		key, err := jwt.ParseRSAPublicKeyFromPEM([]byte(pem))
+		if err != nil {
+			return nil, fmt.Errorf("%s: public key %d: %w", op, i, err)
+		}
+		a.keys[fmt.Sprintf("config-%d", i)] = key
+	}
+
+	if len(a.keys) > 0 {
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("%s: no hmac secret or rsa keys configured", op)
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(cfg.Leeway),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+	a.parser = jwt.NewParser(opts...)
+
+	return a, nil
+}
+
+func (a *JWTAuthenticator) Authenticate(_ context.Context, credential string) (*Principal, error) {
+	if credential == "" {
+		return nil, ErrMissingCredentials
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := a.parser.ParseWithClaims(credential, claims, a.keyFunc); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCredentials, err)
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil || subject == "" {
+		return nil, fmt.Errorf("%w: subject claim is missing", ErrInvalidCredentials)
+	}
+
+	tenantID, _ := claims[a.tenantClaim].(string)
+
+	return &Principal{
+		Subject: subject,
+		Tenant:  tenantID,
+		Roles:   parseRoles(claims[a.rolesClaim]),
+		Method:  MethodJWT,
+	}, nil
+}
+
+func (a *JWTAuthenticator) keyFunc(token *jwt.Token) (any, error) {
+	switch token.Method.Alg() {
+	case jwt.SigningMethodHS256.Alg():
+		return a.secret, nil
+	case jwt.SigningMethodRS256.Alg():
+		if kid, ok := token.Header["kid"].(string); ok {
+			if key, ok := a.keys[kid]; ok {
+				return key, nil
+			}
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		// Without a key id every configured key is a candidate.
+		keys := make([]jwt.VerificationKey, 0, len(a.keys))
+		for _, key := range a.keys {
+			keys = append(keys, key)
+		}
+		return jwt.VerificationKeySet{Keys: keys}, nil
+	}
+
+	return nil, fmt.Errorf("unexpected signing method %q", token.Method.Alg())
+}
+
+// parseRoles accepts both a JSON array and a space separated string.
+func parseRoles(claim any) []string {
+	switch v := claim.(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		roles := make([]string, 0, len(v))
+		for _, r := range v {
+			if s, ok := r.(string); ok {
+				roles = append(roles, s)
+			}
+		}
+		return roles
+	}
+
+	return nil
+}
+
+type jwks struct {
+	Keys []struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	} `json:"keys"`
+}
+
+func loadJWKS(path string) (map[string]*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jwks file: %w", err)
+	}
+
+	var set jwks
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse jwks file: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for i, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("jwks key %q: invalid modulus: %w", k.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("jwks key %q: invalid exponent: %w", k.Kid, err)
+		}
+
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("jwks key %q: exponent is too large", k.Kid)
+		}
+
+		kid := k.Kid
+		if kid == "" {
+			kid = fmt.Sprintf("jwks-%d", i)
+		}
+		keys[kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("jwks file contains no rsa signing keys")
+	}
+
+	return keys, nil
+}
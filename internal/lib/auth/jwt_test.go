@@ -0,0 +1,277 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aidosgal/image-processing-service/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "test-hmac-secret"
+
+func rsaKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	return key
+}
+
+// writeJWKS stores the public halves of keys as a JWKS file, keyed by kid.
+func writeJWKS(t *testing.T, keys map[string]*rsa.PrivateKey) string {
+	t.Helper()
+
+	var set jwks
+	for kid, key := range keys {
+		set.Keys = append(set.Keys, struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		}{
+			Kty: "RSA",
+			Kid: kid,
+			Use: "sig",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+
+	data, err := json.Marshal(set)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	return path
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, key any, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+
+	return signed
+}
+
+func claims(mutate func(jwt.MapClaims)) jwt.MapClaims {
+	c := jwt.MapClaims{
+		"sub":    "alice",
+		"tenant": "tenant-a",
+		"roles":  []string{"editor", "viewer"},
+		"iss":    "issuer",
+		"aud":    "images",
+		"exp":    time.Now().Add(time.Hour).Unix(),
+	}
+	if mutate != nil {
+		mutate(c)
+	}
+
+	return c
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	first, second, unknown := rsaKey(t), rsaKey(t), rsaKey(t)
+
+	a, err := NewJWTAuthenticator(config.JWTConfig{
+		HMACSecret:  testSecret,
+		JWKSFile:    writeJWKS(t, map[string]*rsa.PrivateKey{"first": first, "second": second}),
+		Issuer:      "issuer",
+		Audience:    "images",
+		TenantClaim: "tenant",
+		RolesClaim:  "roles",
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		token   string
+		want    *Principal
+		wantErr error
+	}{
+		{
+			name:  "hs256",
+			token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", claims(nil)),
+			want:  &Principal{Subject: "alice", Tenant: "tenant-a", Roles: []string{"editor", "viewer"}, Method: MethodJWT},
+		},
+		{
+			name: "hs256 with space separated roles",
+			token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", claims(func(c jwt.MapClaims) {
+				c["roles"] = "admin viewer"
+			})),
+			want: &Principal{Subject: "alice", Tenant: "tenant-a", Roles: []string{"admin", "viewer"}, Method: MethodJWT},
+		},
+		{
+			name:  "rs256 looked up by kid",
+			token: sign(t, jwt.SigningMethodRS256, first, "first", claims(nil)),
+			want:  &Principal{Subject: "alice", Tenant: "tenant-a", Roles: []string{"editor", "viewer"}, Method: MethodJWT},
+		},
+		{
+			name:  "rs256 with the second jwks key",
+			token: sign(t, jwt.SigningMethodRS256, second, "second", claims(nil)),
+			want:  &Principal{Subject: "alice", Tenant: "tenant-a", Roles: []string{"editor", "viewer"}, Method: MethodJWT},
+		},
+		{
+			name:  "rs256 without kid tries every key",
+			token: sign(t, jwt.SigningMethodRS256, second, "", claims(nil)),
+			want:  &Principal{Subject: "alice", Tenant: "tenant-a", Roles: []string{"editor", "viewer"}, Method: MethodJWT},
+		},
+		{
+			name:    "rs256 signed by the key of another kid",
+			token:   sign(t, jwt.SigningMethodRS256, second, "first", claims(nil)),
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:    "rs256 with unknown kid",
+			token:   sign(t, jwt.SigningMethodRS256, unknown, "unknown", claims(nil)),
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:    "rs256 by an unknown key without kid",
+			token:   sign(t, jwt.SigningMethodRS256, unknown, "", claims(nil)),
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:    "hs256 with the wrong secret",
+			token:   sign(t, jwt.SigningMethodHS256, []byte("other-secret"), "", claims(nil)),
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:    "wrong alg hs384",
+			token:   sign(t, jwt.SigningMethodHS384, []byte(testSecret), "", claims(nil)),
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:    "wrong alg none",
+			token:   sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "", claims(nil)),
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name: "expired",
+			token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", claims(func(c jwt.MapClaims) {
+				c["exp"] = time.Now().Add(-time.Minute).Unix()
+			})),
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name: "no expiry",
+			token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", claims(func(c jwt.MapClaims) {
+				delete(c, "exp")
+			})),
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name: "wrong issuer",
+			token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", claims(func(c jwt.MapClaims) {
+				c["iss"] = "someone-else"
+			})),
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name: "wrong audience",
+			token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", claims(func(c jwt.MapClaims) {
+				c["aud"] = "other"
+			})),
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name: "missing subject",
+			token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", claims(func(c jwt.MapClaims) {
+				delete(c, "sub")
+			})),
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:    "malformed",
+			token:   "not-a-token",
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:    "empty",
+			token:   "",
+			wantErr: ErrMissingCredentials,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := a.Authenticate(context.Background(), tt.token)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, got)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestJWTAuthenticator_RejectsHS256WithoutSecret(t *testing.T) {
+	key := rsaKey(t)
+
+	a, err := NewJWTAuthenticator(config.JWTConfig{
+		JWKSFile:    writeJWKS(t, map[string]*rsa.PrivateKey{"only": key}),
+		TenantClaim: "tenant",
+		RolesClaim:  "roles",
+	})
+	require.NoError(t, err)
+
+	// Without an HMAC secret an HS256 token, even one signed with an empty
+	// key, must not be accepted.
+	token := sign(t, jwt.SigningMethodHS256, []byte{}, "", claims(nil))
+
+	_, err = a.Authenticate(context.Background(), token)
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	_, err = a.Authenticate(context.Background(), sign(t, jwt.SigningMethodRS256, key, "only", claims(nil)))
+	assert.NoError(t, err)
+}
+
+func TestNewJWTAuthenticator_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	empty := filepath.Join(dir, "empty.json")
+	require.NoError(t, os.WriteFile(empty, []byte(`{"keys":[{"kty":"EC","kid":"ec"}]}`), 0o600))
+
+	invalid := filepath.Join(dir, "invalid.json")
+	require.NoError(t, os.WriteFile(invalid, []byte(`{`), 0o600))
+
+	tests := []struct {
+		name string
+		cfg  config.JWTConfig
+	}{
+		{name: "no keys", cfg: config.JWTConfig{}},
+		{name: "missing jwks file", cfg: config.JWTConfig{JWKSFile: filepath.Join(dir, "missing.json")}},
+		{name: "jwks without rsa keys", cfg: config.JWTConfig{JWKSFile: empty}},
+		{name: "invalid jwks", cfg: config.JWTConfig{JWKSFile: invalid}},
+		{name: "invalid pem", cfg: config.JWTConfig{PublicKeys: []string{"not a pem"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewJWTAuthenticator(tt.cfg)
+			assert.Error(t, err)
+		})
+	}
+}
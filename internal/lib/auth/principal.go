@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"slices"
+)
+
+var (
+	ErrMissingCredentials = errors.New("credentials are missing")
+	ErrInvalidCredentials = errors.New("credentials are invalid")
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Subject string
+	Tenant  string
+	Roles   []string
+	// Method is the authentication scheme that produced the principal.
+	Method string
+	// Watermark names the watermark profile applied to every image the
+	// principal retrieves, empty for none.
+	Watermark string
+}
+
+func (p *Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+// Authenticator turns a raw credential (an API key or a bearer token) into a principal.
+type Authenticator interface {
+	Authenticate(ctx context.Context, credential string) (*Principal, error)
+}
+
+type ctxKey struct{}
+
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, ctxKey{}, p)
+}
+
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(ctxKey{}).(*Principal)
+	return p, ok && p != nil
+}
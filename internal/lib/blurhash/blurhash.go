@@ -0,0 +1,136 @@
+// Package blurhash encodes images as BlurHash strings, a compact
+// representation of a blurred placeholder that clients decode themselves.
+// See https://github.com/woltapp/blurhash for the format.
+package blurhash
+
+import (
+	"errors"
+	"image"
+	"math"
+	"strings"
+
+	"github.com/disintegration/imaging"
+)
+
+// sampleSize is the longest side of the copy the hash is computed from. The
+// hash only keeps a few low frequencies, so more pixels add nothing.
+const sampleSize = 32
+
+// Encode hashes img with xComponents by yComponents cosine components, each
+// between 1 and 9. More components keep more detail and make the hash
+// longer: 4 + 2*x*y characters.
+func Encode(img image.Image, xComponents, yComponents int) (string, error) {
+	if xComponents < 1 || xComponents > 9 || yComponents < 1 || yComponents > 9 {
+		return "", errors.New("components must be between 1 and 9")
+	}
+	if img.Bounds().Empty() {
+		return "", errors.New("image is empty")
+	}
+
+	small := imaging.Fit(img, sampleSize, sampleSize, imaging.Box)
+	w, h := small.Bounds().Dx(), small.Bounds().Dy()
+
+	linear := make([][3]float64, w*h)
+	for p := range linear {
+		for c := 0; c < 3; c++ {
+			linear[p][c] = srgbToLinear(small.Pix[p*4+c])
+		}
+	}
+
+	factors := make([][3]float64, 0, xComponents*yComponents)
+	for j := 0; j < yComponents; j++ {
+		for i := 0; i < xComponents; i++ {
+			factors = append(factors, factor(linear, w, h, i, j))
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(encode83((xComponents-1)+(yComponents-1)*9, 1))
+
+	maxValue := 1.0
+	if len(factors) > 1 {
+		var actualMax float64
+		for _, f := range factors[1:] {
+			actualMax = max(actualMax, math.Abs(f[0]), math.Abs(f[1]), math.Abs(f[2]))
+		}
+		quantised := clamp(int(math.Floor(actualMax*166-0.5)), 0, 82)
+		maxValue = float64(quantised+1) / 166
+		b.WriteString(encode83(quantised, 1))
+	} else {
+		b.WriteString(encode83(0, 1))
+	}
+
+	dc := factors[0]
+	b.WriteString(encode83(linearToSRGB(dc[0])<<16|linearToSRGB(dc[1])<<8|linearToSRGB(dc[2]), 4))
+
+	for _, f := range factors[1:] {
+		quant := func(v float64) int {
+			return clamp(int(math.Floor(signPow(v/maxValue, 0.5)*9+9.5)), 0, 18)
+		}
+		b.WriteString(encode83(quant(f[0])*19*19+quant(f[1])*19+quant(f[2]), 2))
+	}
+
+	return b.String(), nil
+}
+
+// factor is the weight of the (i, j) cosine component in each channel.
+func factor(linear [][3]float64, w, h, i, j int) [3]float64 {
+	normalisation := 2.0
+	if i == 0 && j == 0 {
+		normalisation = 1
+	}
+
+	var f [3]float64
+	for y := 0; y < h; y++ {
+		cy := math.Cos(math.Pi * float64(j) * float64(y) / float64(h))
+		for x := 0; x < w; x++ {
+			basis := normalisation * cy * math.Cos(math.Pi*float64(i)*float64(x)/float64(w))
+			p := linear[y*w+x]
+			f[0] += basis * p[0]
+			f[1] += basis * p[1]
+			f[2] += basis * p[2]
+		}
+	}
+
+	scale := 1 / float64(w*h)
+	return [3]float64{f[0] * scale, f[1] * scale, f[2] * scale}
+}
+
+const characters = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// encode83 writes value as length base 83 digits, most significant first.
+func encode83(value, length int) string {
+	digits := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		digits[i] = characters[value%83]
+		value /= 83
+	}
+
+	return string(digits)
+}
+
+func srgbToLinear(v uint8) float64 {
+	f := float64(v) / 255
+	if f <= 0.04045 {
+		return f / 12.92
+	}
+
+	return math.Pow((f+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(v float64) int {
+	v = math.Max(0, math.Min(1, v))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(v, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(v), exp), v)
+}
+
+func clamp(v, lo, hi int) int {
+	return max(lo, min(v, hi))
+}
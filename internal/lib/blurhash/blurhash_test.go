@@ -0,0 +1,78 @@
+package blurhash
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func solid(c color.NRGBA) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, 40, 30))
+	for y := 0; y < 30; y++ {
+		for x := 0; x < 40; x++ {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+
+	return img
+}
+
+func TestEncode_Solid(t *testing.T) {
+	hash, err := Encode(solid(color.NRGBA{R: 255, A: 255}), 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "00TI:j", hash)
+
+	hash, err = Encode(solid(color.NRGBA{R: 255, A: 255}), 4, 3)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "L"), "4x3 components")
+	assert.Equal(t, "TI:j", hash[2:6], "the DC component is the average color")
+}
+
+func TestEncode_Gradient(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 64, 48))
+	for y := 0; y < 48; y++ {
+		for x := 0; x < 64; x++ {
+			img.SetGray(x, y, color.Gray{Y: uint8(x * 4)})
+		}
+	}
+
+	hash, err := Encode(img, 4, 3)
+	require.NoError(t, err)
+	assert.Len(t, hash, 4+2*4*3)
+	assert.NotEqual(t, "0", hash[1:2], "the gradient has AC detail")
+
+	again, err := Encode(img, 4, 3)
+	require.NoError(t, err)
+	assert.Equal(t, hash, again)
+
+	mirrored, err := Encode(flipH(img), 4, 3)
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, mirrored)
+}
+
+func TestEncode_Invalid(t *testing.T) {
+	_, err := Encode(solid(color.NRGBA{A: 255}), 0, 3)
+	assert.Error(t, err)
+
+	_, err = Encode(solid(color.NRGBA{A: 255}), 4, 10)
+	assert.Error(t, err)
+
+	_, err = Encode(image.NewNRGBA(image.Rectangle{}), 4, 3)
+	assert.Error(t, err)
+}
+
+func flipH(img *image.Gray) image.Image {
+	b := img.Bounds()
+	out := image.NewGray(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			out.SetGray(b.Max.X-1-x, y, img.GrayAt(x, y))
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,200 @@
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Check probes a single dependency and returns nil when it is usable.
+type Check func(ctx context.Context) error
+
+// Checker runs the registered checks in the background and publishes the
+// result to the gRPC health server and the HTTP readiness handler. The
+// service is ready only when every check passes.
+type Checker struct {
+	log      *slog.Logger
+	server   *health.Server
+	services []string
+	interval time.Duration
+	timeout  time.Duration
+
+	checks map[string]Check
+
+	mu      sync.RWMutex
+	checked bool
+	ready   bool
+	results map[string]error
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewChecker creates a checker that updates server for the overall ("")
+// service and every name in services. Until the first round of checks
+// finishes they report NOT_SERVING.
+func NewChecker(log *slog.Logger, server *health.Server, services []string, interval, timeout time.Duration) *Checker {
+	c := &Checker{
+		log:      log,
+		server:   server,
+		services: append([]string{""}, services...),
+		interval: interval,
+		timeout:  timeout,
+		checks:   make(map[string]Check),
+		results:  make(map[string]error),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	c.publish(healthpb.HealthCheckResponse_NOT_SERVING)
+
+	return c
+}
+
+// Add registers a check. It must be called before Start.
+func (c *Checker) Add(name string, check Check) {
+	c.checks[name] = check
+}
+
+// Start runs the checks once and then every interval until Stop.
+func (c *Checker) Start() {
+	go func() {
+		defer close(c.done)
+
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		for {
+			c.runChecks()
+
+			select {
+			case <-c.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop halts the background checks and marks every service NOT_SERVING,
+// so load balancers stop sending traffic before the servers shut down.
+func (c *Checker) Stop() {
+	close(c.stop)
+	<-c.done
+
+	c.mu.Lock()
+	c.ready = false
+	c.mu.Unlock()
+
+	c.server.Shutdown()
+}
+
+func (c *Checker) runChecks() {
+	const op = "healthcheck.runChecks"
+
+	log := c.log.With(slog.String("op", op))
+
+	results := make(map[string]error, len(c.checks))
+	ready := true
+	for name, check := range c.checks {
+		ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+		err := check(ctx)
+		cancel()
+
+		results[name] = err
+		if err != nil {
+			ready = false
+		}
+	}
+
+	c.mu.Lock()
+	changed := !c.checked || c.ready != ready
+	c.checked = true
+	c.ready = ready
+	c.results = results
+	c.mu.Unlock()
+
+	if ready {
+		c.publish(healthpb.HealthCheckResponse_SERVING)
+	} else {
+		c.publish(healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+
+	if changed {
+		if ready {
+			log.Info("service is ready")
+		} else {
+			log.Warn("service is not ready", slog.Any("failed", failedChecks(results)))
+		}
+	}
+}
+
+func (c *Checker) publish(status healthpb.HealthCheckResponse_ServingStatus) {
+	for _, service := range c.services {
+		c.server.SetServingStatus(service, status)
+	}
+}
+
+// Ready reports whether the last round of checks passed, with the error of
+// every failed check by name.
+func (c *Checker) Ready() (bool, map[string]string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.ready, failedChecks(c.results)
+}
+
+func failedChecks(results map[string]error) map[string]string {
+	failed := make(map[string]string)
+	for name, err := range results {
+		if err != nil {
+			failed[name] = err.Error()
+		}
+	}
+
+	return failed
+}
+
+// LivenessHandler answers /healthz. It only shows that the process serves
+// HTTP; dependency failures must not get the pod restarted.
+func LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"status": "ok"})
+	})
+}
+
+// ReadinessHandler answers /readyz with 200 when the checker is ready and
+// 503 with the failed checks otherwise.
+func (c *Checker) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ready, failed := c.Ready()
+		if ready {
+			writeJSON(w, http.StatusOK, map[string]any{"status": "ready"})
+			return
+		}
+
+		names := make([]string, 0, len(failed))
+		for name := range failed {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{
+			"status": "not ready",
+			"failed": names,
+		})
+	})
+}
+
+func writeJSON(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,168 @@
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const imageService = "image.ImageService"
+
+var errPing = errors.New("connection refused")
+
+// fakePing is a repository ping whose result the test switches.
+type fakePing struct {
+	failing atomic.Bool
+}
+
+func (p *fakePing) Ping(context.Context) error {
+	if p.failing.Load() {
+		return errPing
+	}
+	return nil
+}
+
+func newChecker(t *testing.T, checks map[string]Check) (*Checker, *health.Server) {
+	t.Helper()
+
+	server := health.NewServer()
+	c := NewChecker(slog.New(slog.NewTextHandler(io.Discard, nil)), server, []string{imageService}, 10*time.Millisecond, 50*time.Millisecond)
+	for name, check := range checks {
+		c.Add(name, check)
+	}
+
+	return c, server
+}
+
+func servingStatus(t *testing.T, server *health.Server, service string) healthpb.HealthCheckResponse_ServingStatus {
+	t.Helper()
+
+	resp, err := server.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+	require.NoError(t, err)
+
+	return resp.GetStatus()
+}
+
+// waitForStatus waits until both the overall and the image service report want.
+func waitForStatus(t *testing.T, server *health.Server, want healthpb.HealthCheckResponse_ServingStatus) {
+	t.Helper()
+
+	require.Eventually(t, func() bool {
+		return servingStatus(t, server, "") == want && servingStatus(t, server, imageService) == want
+	}, time.Second, 5*time.Millisecond, "status never became %s", want)
+}
+
+func readiness(t *testing.T, c *Checker) (int, map[string]any) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	c.ReadinessHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var body map[string]any
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+
+	return rec.Code, body
+}
+
+func TestChecker_NotServingBeforeFirstCheck(t *testing.T) {
+	_, server := newChecker(t, nil)
+
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, servingStatus(t, server, ""))
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, servingStatus(t, server, imageService))
+}
+
+func TestChecker_PingFailure(t *testing.T) {
+	db := &fakePing{}
+	db.failing.Store(true)
+
+	c, server := newChecker(t, map[string]Check{
+		"database": db.Ping,
+		"storage":  func(context.Context) error { return nil },
+	})
+	c.Start()
+	t.Cleanup(c.Stop)
+
+	waitForStatus(t, server, healthpb.HealthCheckResponse_NOT_SERVING)
+	require.Eventually(t, func() bool {
+		_, failed := c.Ready()
+		return len(failed) > 0
+	}, time.Second, 5*time.Millisecond)
+
+	ready, failed := c.Ready()
+	assert.False(t, ready)
+	assert.Equal(t, map[string]string{"database": errPing.Error()}, failed)
+
+	code, body := readiness(t, c)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, []any{"database"}, body["failed"])
+
+	db.failing.Store(false)
+	waitForStatus(t, server, healthpb.HealthCheckResponse_SERVING)
+
+	code, _ = readiness(t, c)
+	assert.Equal(t, http.StatusOK, code)
+
+	db.failing.Store(true)
+	waitForStatus(t, server, healthpb.HealthCheckResponse_NOT_SERVING)
+}
+
+func TestChecker_CheckTimeout(t *testing.T) {
+	c, server := newChecker(t, map[string]Check{
+		"database": func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	})
+	c.Start()
+	t.Cleanup(c.Stop)
+
+	require.Eventually(t, func() bool {
+		_, failed := c.Ready()
+		return failed["database"] == context.DeadlineExceeded.Error()
+	}, time.Second, 5*time.Millisecond, "a hanging check is cut off by the timeout")
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, servingStatus(t, server, ""))
+}
+
+func TestChecker_StopDrains(t *testing.T) {
+	db := &fakePing{}
+	c, server := newChecker(t, map[string]Check{"database": db.Ping})
+	c.Start()
+
+	waitForStatus(t, server, healthpb.HealthCheckResponse_SERVING)
+
+	c.Stop()
+
+	// Every check still passes, but a draining service takes no traffic.
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, servingStatus(t, server, ""))
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, servingStatus(t, server, imageService))
+
+	ready, _ := c.Ready()
+	assert.False(t, ready)
+
+	code, _ := readiness(t, c)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+
+	// Nothing turns the service back to serving once it drains.
+	server.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, servingStatus(t, server, ""))
+}
+
+func TestLivenessHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	LivenessHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, rec.Body.String())
+}
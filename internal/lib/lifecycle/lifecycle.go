@@ -0,0 +1,92 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Hook is one shutdown step. It should return once its work is finished or
+// ctx is done, whichever comes first.
+type Hook func(ctx context.Context) error
+
+type step struct {
+	name string
+	hook Hook
+}
+
+// Manager shuts the application down in two phases. Drain hooks stop
+// accepting work and wait for in-flight work; they share one deadline, and
+// whatever is left when it passes is expected to be cancelled. Close hooks
+// then release resources (database pool, telemetry exporters) under their
+// own deadline, so they run even when draining used up its time. Hooks of
+// each phase run one after another in the order they were added, which is
+// how callers express dependencies between them.
+type Manager struct {
+	log          *slog.Logger
+	drainTimeout time.Duration
+	closeTimeout time.Duration
+
+	drain []step
+	close []step
+}
+
+func NewManager(log *slog.Logger, drainTimeout, closeTimeout time.Duration) *Manager {
+	return &Manager{
+		log:          log,
+		drainTimeout: drainTimeout,
+		closeTimeout: closeTimeout,
+	}
+}
+
+// OnDrain adds a hook to the drain phase.
+func (m *Manager) OnDrain(name string, hook Hook) {
+	m.drain = append(m.drain, step{name: name, hook: hook})
+}
+
+// OnClose adds a hook to the close phase.
+func (m *Manager) OnClose(name string, hook Hook) {
+	m.close = append(m.close, step{name: name, hook: hook})
+}
+
+// Shutdown runs every drain hook and then every close hook. A failing hook
+// doesn't stop the ones after it; all errors are returned joined.
+func (m *Manager) Shutdown() error {
+	const op = "lifecycle.Shutdown"
+
+	log := m.log.With(slog.String("op", op))
+	start := time.Now()
+
+	log.Info("shutting down", slog.Duration("drain_timeout", m.drainTimeout))
+
+	drainCtx, cancel := context.WithTimeout(context.Background(), m.drainTimeout)
+	defer cancel()
+	errs := m.run(drainCtx, log, m.drain)
+
+	closeCtx, cancel := context.WithTimeout(context.Background(), m.closeTimeout)
+	defer cancel()
+	errs = append(errs, m.run(closeCtx, log, m.close)...)
+
+	log.Info("shutdown finished", slog.Duration("duration", time.Since(start)))
+
+	return errors.Join(errs...)
+}
+
+func (m *Manager) run(ctx context.Context, log *slog.Logger, steps []step) []error {
+	var errs []error
+	for _, s := range steps {
+		start := time.Now()
+
+		if err := s.hook(ctx); err != nil {
+			log.Error("shutdown step failed", slog.String("step", s.name), slog.String("error", err.Error()))
+			errs = append(errs, fmt.Errorf("%s: %w", s.name, err))
+			continue
+		}
+
+		log.Debug("shutdown step finished", slog.String("step", s.name), slog.Duration("duration", time.Since(start)))
+	}
+
+	return errs
+}
@@ -0,0 +1,109 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newManager(drainTimeout, closeTimeout time.Duration) *Manager {
+	return NewManager(slog.New(slog.NewTextHandler(io.Discard, nil)), drainTimeout, closeTimeout)
+}
+
+func TestManager_Order(t *testing.T) {
+	m := newManager(time.Second, time.Second)
+
+	var calls []string
+	record := func(name string) Hook {
+		return func(context.Context) error {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+
+	// Close hooks added before drain hooks still run after them.
+	m.OnClose("database", record("database"))
+	m.OnDrain("health", record("health"))
+	m.OnDrain("grpc", record("grpc"))
+	m.OnClose("tracing", record("tracing"))
+	m.OnDrain("workers", record("workers"))
+
+	require.NoError(t, m.Shutdown())
+	assert.Equal(t, []string{"health", "grpc", "workers", "database", "tracing"}, calls)
+}
+
+func TestManager_PhaseTimeouts(t *testing.T) {
+	const drainTimeout, closeTimeout = 50 * time.Millisecond, 200 * time.Millisecond
+
+	m := newManager(drainTimeout, closeTimeout)
+
+	var drainDeadlines []time.Time
+	var closeDeadline time.Time
+	var closeErr error
+
+	start := time.Now()
+	for _, name := range []string{"grpc", "http"} {
+		m.OnDrain(name, func(ctx context.Context) error {
+			deadline, _ := ctx.Deadline()
+			drainDeadlines = append(drainDeadlines, deadline)
+
+			// Hang until the drain deadline.
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	}
+	m.OnClose("database", func(ctx context.Context) error {
+		closeDeadline, _ = ctx.Deadline()
+		closeErr = ctx.Err()
+		return nil
+	})
+
+	err := m.Shutdown()
+	require.Error(t, err)
+
+	require.Len(t, drainDeadlines, 2)
+	assert.Equal(t, drainDeadlines[0], drainDeadlines[1], "drain hooks share one deadline")
+	assert.WithinDuration(t, start.Add(drainTimeout), drainDeadlines[0], drainTimeout)
+
+	assert.NoError(t, closeErr, "close hooks run with a fresh deadline after draining timed out")
+	assert.True(t, closeDeadline.After(drainDeadlines[0]))
+	assert.WithinDuration(t, drainDeadlines[0].Add(closeTimeout), closeDeadline, drainTimeout)
+}
+
+func TestManager_Errors(t *testing.T) {
+	m := newManager(time.Second, time.Second)
+
+	errGRPC := errors.New("listener already closed")
+	errDatabase := errors.New("pool busy")
+
+	var ran []string
+	hook := func(name string, err error) Hook {
+		return func(context.Context) error {
+			ran = append(ran, name)
+			return err
+		}
+	}
+
+	m.OnDrain("grpc", hook("grpc", errGRPC))
+	m.OnDrain("workers", hook("workers", nil))
+	m.OnClose("database", hook("database", errDatabase))
+	m.OnClose("tracing", hook("tracing", nil))
+
+	err := m.Shutdown()
+
+	assert.Equal(t, []string{"grpc", "workers", "database", "tracing"}, ran, "a failing hook doesn't stop the rest")
+	assert.ErrorIs(t, err, errGRPC)
+	assert.ErrorIs(t, err, errDatabase)
+	assert.Contains(t, err.Error(), "grpc: listener already closed")
+	assert.Contains(t, err.Error(), "database: pool busy")
+}
+
+func TestManager_NoHooks(t *testing.T) {
+	assert.NoError(t, newManager(time.Second, time.Second).Shutdown())
+}
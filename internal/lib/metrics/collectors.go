@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RegisterDecodeQueue exposes the decode limiter state on reg. inUse and
+// waiting are read on every scrape.
+func RegisterDecodeQueue(reg prometheus.Registerer, inUse func() int, waiting func() int) {
+	reg.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "decode_slots_in_use",
+			Help:      "Image decodes currently running.",
+		}, func() float64 { return float64(inUse()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "decode_queue_depth",
+			Help:      "Image decodes waiting for a free slot.",
+		}, func() float64 { return float64(waiting()) }),
+	)
+}
+
+// StorageStatsFunc returns the bytes and image count stored across all tenants.
+type StorageStatsFunc func(ctx context.Context) (bytes int64, images int64, err error)
+
+type storageCollector struct {
+	stats  StorageStatsFunc
+	bytes  *prometheus.Desc
+	images *prometheus.Desc
+}
+
+// RegisterStorageUsage exposes storage usage gauges on reg, queried on every
+// scrape.
+func RegisterStorageUsage(reg prometheus.Registerer, stats StorageStatsFunc) {
+	reg.MustRegister(&storageCollector{
+		stats: stats,
+		bytes: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "storage_used_bytes"),
+			"Bytes stored across all tenants, including variants.", nil, nil,
+		),
+		images: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "stored_images"),
+			"Images stored across all tenants.", nil, nil,
+		),
+	})
+}
+
+func (c *storageCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.bytes
+	ch <- c.images
+}
+
+func (c *storageCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	bytes, images, err := c.stats(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.bytes, err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.bytes, prometheus.GaugeValue, float64(bytes))
+	ch <- prometheus.MustNewConstMetric(c.images, prometheus.GaugeValue, float64(images))
+}
@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "image_service"
+
+var (
+	GRPCRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "grpc_requests_total",
+		Help:      "Handled gRPC requests by method and status code.",
+	}, []string{"method", "code"})
+
+	GRPCRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "grpc_request_duration_seconds",
+		Help:      "gRPC request latency by method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "code"})
+
+	RateLimitRejections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limit_rejections_total",
+		Help:      "Requests rejected by the per-principal rate limiter.",
+	}, []string{"method"})
+
+	DecodeLimitRejections = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "decode_limit_rejections_total",
+		Help:      "Image decodes rejected because the concurrent decode limit was reached.",
+	})
+
+	ProcessingDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "processing_duration_seconds",
+		Help:      "Duration of image processing steps.",
+		Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"operation"})
+
+	VariantCacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "variant_cache_lookups_total",
+		Help:      "Variant cache lookups by result: memory_hit, disk_hit or miss.",
+	}, []string{"result"})
+
+	VariantCacheEvictions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "variant_cache_evictions_total",
+		Help:      "Variants evicted from the cache by tier.",
+	}, []string{"tier"})
+
+	UploadSize = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upload_size_bytes",
+		Help:      "Size of uploaded images.",
+		Buckets:   prometheus.ExponentialBuckets(16*1024, 4, 8),
+	})
+
+	UploadedBytes = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "uploaded_bytes_total",
+		Help:      "Bytes of images accepted for upload.",
+	})
+
+	ServedBytes = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "served_bytes_total",
+		Help:      "Bytes of images returned to clients.",
+	})
+
+	NearDuplicateUploads = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "near_duplicate_uploads_total",
+		Help:      "Uploads flagged as near-duplicates of an image the tenant already stored.",
+	})
+
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Latency of repository methods.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"method"})
+)
+
+// ObserveQuery records the latency of a repository method started at start.
+// Call it as `defer metrics.ObserveQuery(op, time.Now())`.
+func ObserveQuery(method string, start time.Time) {
+	DBQueryDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+// ObserveProcessing records the duration of a processing step started at start.
+func ObserveProcessing(operation string, start time.Time) {
+	ProcessingDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
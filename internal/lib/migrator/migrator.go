@@ -0,0 +1,357 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/lib/pq"
+)
+
+// ErrNoChange is returned when the schema is already at the requested version.
+var ErrNoChange = migrate.ErrNoChange
+
+// Migration is a single schema migration, e.g. version 3 named
+// "create_sharing_tables".
+type Migration struct {
+	Version uint
+	Name    string
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%d_%s", m.Version, m.Name)
+}
+
+// Step is a migration to run, up or down.
+type Step struct {
+	Migration
+	Down bool
+}
+
+func (s Step) String() string {
+	if s.Down {
+		return "down " + s.Migration.String()
+	}
+
+	return "up   " + s.Migration.String()
+}
+
+// Status is the state of the schema. Version is only meaningful when Applied
+// is set; Dirty means the migration to Version failed half way and the
+// schema has to be fixed by hand and then forced to a version.
+type Status struct {
+	Version    uint
+	Applied    bool
+	Dirty      bool
+	Migrations []Migration
+}
+
+// Migrator applies the migrations of a source to a Postgres database.
+type Migrator struct {
+	m          *migrate.Migrate
+	migrations []Migration
+}
+
+// NewFromDir reads migrations from dir.
+func NewFromDir(dir string, dsn string, table string) (*Migrator, error) {
+	return NewFromFS(os.DirFS(dir), dsn, table)
+}
+
+// NewFromFS reads migrations from the root of fsys, such as the embedded
+// migrations.FS. The applied version is recorded in table.
+func NewFromFS(fsys fs.FS, dsn string, table string) (*Migrator, error) {
+	const op = "migrator.New"
+
+	src, err := iofs.New(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to read migrations: %w", op, err)
+	}
+
+	migrations, err := list(src)
+	if err != nil {
+		src.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	dsn, err = withTable(dsn, table)
+	if err != nil {
+		src.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	m, err := migrate.NewWithSourceInstance("iofs", src, dsn)
+	if err != nil {
+		src.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Migrator{m: m, migrations: migrations}, nil
+}
+
+func list(src source.Driver) ([]Migration, error) {
+	var migrations []Migration
+
+	version, err := src.First()
+	for err == nil {
+		r, identifier, readErr := src.ReadUp(version)
+		if readErr != nil {
+			return nil, fmt.Errorf("failed to read migration %d: %w", version, readErr)
+		}
+		r.Close()
+
+		migrations = append(migrations, Migration{Version: version, Name: identifier})
+		version, err = src.Next(version)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	return migrations, nil
+}
+
+// withTable points the postgres driver at the migrations table.
+func withTable(dsn string, table string) (string, error) {
+	if table == "" {
+		return dsn, nil
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", errors.New("invalid database url")
+	}
+
+	q := u.Query()
+	q.Set("x-migrations-table", table)
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
+// Close releases the source and the database connection.
+func (m *Migrator) Close() error {
+	srcErr, dbErr := m.m.Close()
+	return errors.Join(srcErr, dbErr)
+}
+
+// Status reports the applied version and the available migrations.
+func (m *Migrator) Status() (Status, error) {
+	const op = "migrator.Status"
+
+	status := Status{Migrations: m.migrations}
+
+	version, dirty, err := m.m.Version()
+	switch {
+	case errors.Is(err, migrate.ErrNilVersion):
+		return status, nil
+	case err != nil:
+		return status, fmt.Errorf("%s: %w", op, err)
+	}
+
+	status.Version = version
+	status.Applied = true
+	status.Dirty = dirty
+
+	return status, nil
+}
+
+// Up applies the next n migrations, or all pending ones when n is 0.
+func (m *Migrator) Up(n int) error {
+	const op = "migrator.Up"
+
+	var err error
+	if n <= 0 {
+		err = m.m.Up()
+	} else {
+		err = m.m.Steps(n)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Down reverts the last n migrations.
+func (m *Migrator) Down(n int) error {
+	const op = "migrator.Down"
+
+	if n <= 0 {
+		return fmt.Errorf("%s: the number of migrations to revert must be positive", op)
+	}
+
+	if err := m.m.Steps(-n); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Goto migrates up or down to version.
+func (m *Migrator) Goto(version uint) error {
+	const op = "migrator.Goto"
+
+	if err := m.m.Migrate(version); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Force records version as applied and clears the dirty flag without
+// running anything. -1 records that no migration is applied.
+func (m *Migrator) Force(version int) error {
+	const op = "migrator.Force"
+
+	if err := m.m.Force(version); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// PlanUp returns the steps Up(n) would run.
+func (m *Migrator) PlanUp(n int) ([]Step, error) {
+	status, err := m.plannable()
+	if err != nil {
+		return nil, err
+	}
+
+	return planUp(status, n), nil
+}
+
+// PlanDown returns the steps Down(n) would run.
+func (m *Migrator) PlanDown(n int) ([]Step, error) {
+	status, err := m.plannable()
+	if err != nil {
+		return nil, err
+	}
+
+	return planDown(status, n), nil
+}
+
+// PlanGoto returns the steps Goto(version) would run.
+func (m *Migrator) PlanGoto(version uint) ([]Step, error) {
+	status, err := m.plannable()
+	if err != nil {
+		return nil, err
+	}
+
+	return planGoto(status, version)
+}
+
+func (m *Migrator) plannable() (Status, error) {
+	status, err := m.Status()
+	if err != nil {
+		return status, err
+	}
+	if status.Dirty {
+		return status, fmt.Errorf("database is dirty at version %d, fix it and force a version", status.Version)
+	}
+
+	return status, nil
+}
+
+// next is the index of the first migration after the applied version.
+func next(status Status) int {
+	if !status.Applied {
+		return 0
+	}
+
+	for i, m := range status.Migrations {
+		if m.Version > status.Version {
+			return i
+		}
+	}
+
+	return len(status.Migrations)
+}
+
+func planUp(status Status, n int) []Step {
+	pending := status.Migrations[next(status):]
+	if n > 0 && n < len(pending) {
+		pending = pending[:n]
+	}
+
+	steps := make([]Step, 0, len(pending))
+	for _, m := range pending {
+		steps = append(steps, Step{Migration: m})
+	}
+
+	return steps
+}
+
+func planDown(status Status, n int) []Step {
+	var steps []Step
+	for i := next(status) - 1; i >= 0 && len(steps) < n; i-- {
+		steps = append(steps, Step{Migration: status.Migrations[i], Down: true})
+	}
+
+	return steps
+}
+
+func planGoto(status Status, version uint) ([]Step, error) {
+	target := -1
+	for i, m := range status.Migrations {
+		if m.Version == version {
+			target = i
+		}
+	}
+	if target < 0 {
+		return nil, fmt.Errorf("no migration with version %d", version)
+	}
+
+	current := next(status) - 1
+	if target > current {
+		return planUp(status, target-current), nil
+	}
+
+	return planDown(status, current-target), nil
+}
+
+// EnsureDatabase creates the database named in dsn unless it exists,
+// connecting to the "postgres" maintenance database on the same server.
+// It reports whether the database was created.
+func EnsureDatabase(ctx context.Context, dsn string) (bool, error) {
+	const op = "migrator.EnsureDatabase"
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return false, fmt.Errorf("%s: invalid database url", op)
+	}
+	name := strings.TrimPrefix(u.Path, "/")
+	if name == "" {
+		return false, fmt.Errorf("%s: database url has no database name", op)
+	}
+	u.Path = "/postgres"
+
+	db, err := sql.Open("postgres", u.String())
+	if err != nil {
+		return false, fmt.Errorf("%s: failed to connect to PostgreSQL server: %w", op, err)
+	}
+	defer db.Close()
+
+	var exists bool
+	err = db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM pg_database WHERE datname = $1)", name).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("%s: failed to check if database exists: %w", op, err)
+	}
+	if exists {
+		return false, nil
+	}
+
+	// CREATE DATABASE takes no parameters, so the name is quoted instead.
+	if _, err := db.ExecContext(ctx, "CREATE DATABASE "+pq.QuoteIdentifier(name)); err != nil {
+		return false, fmt.Errorf("%s: failed to create database: %w", op, err)
+	}
+
+	return true, nil
+}
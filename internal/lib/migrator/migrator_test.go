@@ -0,0 +1,152 @@
+package migrator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aidosgal/image-processing-service/migrations"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+var testMigrations = []Migration{
+	{Version: 1, Name: "create_images_table"},
+	{Version: 2, Name: "add_images_owner_id"},
+	{Version: 3, Name: "create_sharing_tables"},
+	{Version: 5, Name: "add_images_revision"},
+}
+
+func steps(s []Step) string {
+	names := make([]string, 0, len(s))
+	for _, step := range s {
+		names = append(names, step.String())
+	}
+
+	return strings.Join(names, ", ")
+}
+
+func TestPlanUp(t *testing.T) {
+	tests := []struct {
+		status Status
+		n      int
+		want   string
+	}{
+		{Status{}, 0, "up   1_create_images_table, up   2_add_images_owner_id, up   3_create_sharing_tables, up   5_add_images_revision"},
+		{Status{}, 2, "up   1_create_images_table, up   2_add_images_owner_id"},
+		{Status{Applied: true, Version: 2}, 0, "up   3_create_sharing_tables, up   5_add_images_revision"},
+		{Status{Applied: true, Version: 2}, 1, "up   3_create_sharing_tables"},
+		{Status{Applied: true, Version: 5}, 0, ""},
+	}
+
+	for _, tt := range tests {
+		tt.status.Migrations = testMigrations
+		if got := steps(planUp(tt.status, tt.n)); got != tt.want {
+			t.Errorf("planUp(%+v, %d) = %q, want %q", tt.status, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestPlanDown(t *testing.T) {
+	tests := []struct {
+		status Status
+		n      int
+		want   string
+	}{
+		{Status{}, 1, ""},
+		{Status{Applied: true, Version: 5}, 1, "down 5_add_images_revision"},
+		{Status{Applied: true, Version: 3}, 2, "down 3_create_sharing_tables, down 2_add_images_owner_id"},
+		{Status{Applied: true, Version: 2}, 10, "down 2_add_images_owner_id, down 1_create_images_table"},
+	}
+
+	for _, tt := range tests {
+		tt.status.Migrations = testMigrations
+		if got := steps(planDown(tt.status, tt.n)); got != tt.want {
+			t.Errorf("planDown(%+v, %d) = %q, want %q", tt.status, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestPlanGoto(t *testing.T) {
+	tests := []struct {
+		status  Status
+		version uint
+		want    string
+		wantErr bool
+	}{
+		{status: Status{}, version: 2, want: "up   1_create_images_table, up   2_add_images_owner_id"},
+		{status: Status{Applied: true, Version: 2}, version: 5, want: "up   3_create_sharing_tables, up   5_add_images_revision"},
+		{status: Status{Applied: true, Version: 5}, version: 2, want: "down 5_add_images_revision, down 3_create_sharing_tables"},
+		{status: Status{Applied: true, Version: 3}, version: 3, want: ""},
+		{status: Status{Applied: true, Version: 3}, version: 4, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt.status.Migrations = testMigrations
+		got, err := planGoto(tt.status, tt.version)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("planGoto(%+v, %d) = %q, want error", tt.status, tt.version, steps(got))
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("planGoto(%+v, %d) error = %v", tt.status, tt.version, err)
+		}
+		if steps(got) != tt.want {
+			t.Errorf("planGoto(%+v, %d) = %q, want %q", tt.status, tt.version, steps(got), tt.want)
+		}
+	}
+}
+
+func TestWithTable(t *testing.T) {
+	got, err := withTable("postgres://user:p%40ss@db:5432/images?sslmode=disable", "schema_migrations")
+	if err != nil {
+		t.Fatalf("withTable() error = %v", err)
+	}
+
+	want := "postgres://user:p%40ss@db:5432/images?sslmode=disable&x-migrations-table=schema_migrations"
+	if got != want {
+		t.Errorf("withTable() = %q, want %q", got, want)
+	}
+}
+
+// TestEmbeddedMigrations checks that every embedded migration can be
+// reverted and that versions have no gaps.
+func TestEmbeddedMigrations(t *testing.T) {
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		t.Fatalf("failed to open embedded migrations: %v", err)
+	}
+	defer src.Close()
+
+	list, err := list(src)
+	if err != nil {
+		t.Fatalf("list() error = %v", err)
+	}
+	if len(list) == 0 {
+		t.Fatal("no embedded migrations")
+	}
+
+	for i, m := range list {
+		if m.Version != uint(i+1) {
+			t.Errorf("migration %s, want version %d", m, i+1)
+		}
+
+		r, _, err := src.ReadDown(m.Version)
+		if err != nil {
+			t.Errorf("migration %s has no down migration: %v", m, err)
+			continue
+		}
+		r.Close()
+	}
+
+	// The down migration of the first version used to be invalid SQL.
+	down, err := migrations.FS.ReadFile(fmt.Sprintf("%s.down.sql", list[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slices.Contains(strings.Fields(string(down)), "NOT") {
+		t.Errorf("%s.down.sql = %q, want a valid DROP TABLE", list[0], down)
+	}
+}
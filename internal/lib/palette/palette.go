@@ -0,0 +1,242 @@
+// Package palette summarizes the colors of an image: its dominant colors
+// with the share of pixels each covers, the average color, brightness and
+// colorfulness.
+package palette
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/disintegration/imaging"
+)
+
+// Color is an opaque sRGB color.
+type Color struct {
+	R, G, B uint8
+}
+
+// Hex formats c as "#rrggbb".
+func (c Color) Hex() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// ParseHex parses "#rrggbb" or "rrggbb".
+func ParseHex(s string) (Color, error) {
+	if len(s) == 7 && s[0] == '#' {
+		s = s[1:]
+	}
+	if len(s) != 6 {
+		return Color{}, errors.New("color must be #rrggbb")
+	}
+
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return Color{}, errors.New("color must be #rrggbb")
+	}
+
+	return Color{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v)}, nil
+}
+
+// Distance is the CIE76 color difference between a and b, the Euclidean
+// distance in CIELAB. About 2.3 is just noticeable; unrelated colors are
+// 50 or more apart.
+func Distance(a, b Color) float64 {
+	l1, a1, b1 := a.lab()
+	l2, a2, b2 := b.lab()
+
+	return math.Sqrt((l1-l2)*(l1-l2) + (a1-a2)*(a1-a2) + (b1-b2)*(b1-b2))
+}
+
+// lab converts c to CIELAB under the D65 white point.
+func (c Color) lab() (l, a, b float64) {
+	linear := func(v uint8) float64 {
+		f := float64(v) / 255
+		if f <= 0.04045 {
+			return f / 12.92
+		}
+		return math.Pow((f+0.055)/1.055, 2.4)
+	}
+	r, g, bl := linear(c.R), linear(c.G), linear(c.B)
+
+	x := (0.4124*r + 0.3576*g + 0.1805*bl) / 0.95047
+	y := 0.2126*r + 0.7152*g + 0.0722*bl
+	z := (0.0193*r + 0.1192*g + 0.9505*bl) / 1.08883
+
+	f := func(t float64) float64 {
+		if t > 216.0/24389 {
+			return math.Cbrt(t)
+		}
+		return (24389.0/27*t + 16) / 116
+	}
+	fx, fy, fz := f(x), f(y), f(z)
+
+	return 116*fy - 16, 500 * (fx - fy), 200 * (fy - fz)
+}
+
+// Entry is a dominant color and the fraction of pixels closest to it.
+type Entry struct {
+	Color    Color
+	Fraction float64
+}
+
+// Summary describes the colors of an image.
+type Summary struct {
+	// Palette holds at most k colors, most common first. Fractions add up to 1.
+	Palette []Entry
+	Average Color
+	// Brightness is the mean luma, from 0 for black to 1 for white.
+	Brightness float64
+	// Colorfulness is the Hasler-Süsstrunk metric: 0 for gray images,
+	// about 33 for moderately and above 80 for highly colorful ones.
+	Colorfulness float64
+	// Grayscale is set when almost every pixel is a shade of gray.
+	Grayscale bool
+}
+
+const (
+	// sampleSize is the side of the copy the colors are read from.
+	sampleSize = 64
+	// grayChroma is the largest channel spread of a pixel that counts as gray,
+	// which leaves room for compression noise.
+	grayChroma = 12
+	// grayShare is the share of gray pixels that makes an image grayscale.
+	grayShare = 0.99
+)
+
+// Extract summarizes img with a palette of up to k colors. Pixels that are
+// mostly transparent are ignored; a fully transparent image gets an empty
+// summary.
+func Extract(img image.Image, k int) Summary {
+	small := imaging.Resize(img, sampleSize, sampleSize, imaging.Box)
+
+	pixels := make([]Color, 0, sampleSize*sampleSize)
+	for i := 0; i+3 < len(small.Pix); i += 4 {
+		if small.Pix[i+3] < 128 {
+			continue
+		}
+		pixels = append(pixels, Color{R: small.Pix[i], G: small.Pix[i+1], B: small.Pix[i+2]})
+	}
+	if len(pixels) == 0 {
+		return Summary{}
+	}
+
+	var sumR, sumG, sumB, sumLuma float64
+	var sumRG, sumYB, sumRG2, sumYB2 float64
+	gray := 0
+	for _, p := range pixels {
+		r, g, b := float64(p.R), float64(p.G), float64(p.B)
+		sumR, sumG, sumB = sumR+r, sumG+g, sumB+b
+		sumLuma += 0.299*r + 0.587*g + 0.114*b
+
+		rg, yb := r-g, 0.5*(r+g)-b
+		sumRG, sumYB = sumRG+rg, sumYB+yb
+		sumRG2, sumYB2 = sumRG2+rg*rg, sumYB2+yb*yb
+
+		if int(max(p.R, p.G, p.B))-int(min(p.R, p.G, p.B)) <= grayChroma {
+			gray++
+		}
+	}
+
+	n := float64(len(pixels))
+	meanRG, meanYB := sumRG/n, sumYB/n
+	stdRG := math.Sqrt(max(sumRG2/n-meanRG*meanRG, 0))
+	stdYB := math.Sqrt(max(sumYB2/n-meanYB*meanYB, 0))
+
+	return Summary{
+		Palette: medianCut(pixels, k),
+		Average: Color{
+			R: uint8(math.Round(sumR / n)),
+			G: uint8(math.Round(sumG / n)),
+			B: uint8(math.Round(sumB / n)),
+		},
+		Brightness:   sumLuma / n / 255,
+		Colorfulness: math.Hypot(stdRG, stdYB) + 0.3*math.Hypot(meanRG, meanYB),
+		Grayscale:    float64(gray)/n >= grayShare,
+	}
+}
+
+// medianCut splits the pixels into up to k boxes, each time halving the box
+// with the widest channel range at the median of that channel, and returns
+// the mean color of every box. It is deterministic, so the same image
+// always gets the same palette.
+func medianCut(pixels []Color, k int) []Entry {
+	boxes := [][]Color{pixels}
+	for len(boxes) < k {
+		widest, channel, spread := -1, 0, 0
+		for i, box := range boxes {
+			if len(box) < 2 {
+				continue
+			}
+			if c, s := widestChannel(box); s > spread {
+				widest, channel, spread = i, c, s
+			}
+		}
+		if widest < 0 {
+			break
+		}
+
+		box := boxes[widest]
+		sort.Slice(box, func(a, b int) bool {
+			return channelOf(box[a], channel) < channelOf(box[b], channel)
+		})
+		mid := len(box) / 2
+		boxes[widest] = box[:mid]
+		boxes = append(boxes, box[mid:])
+	}
+
+	entries := make([]Entry, 0, len(boxes))
+	for _, box := range boxes {
+		var r, g, b int
+		for _, p := range box {
+			r, g, b = r+int(p.R), g+int(p.G), b+int(p.B)
+		}
+		n := len(box)
+		entries = append(entries, Entry{
+			Color:    Color{R: uint8((r + n/2) / n), G: uint8((g + n/2) / n), B: uint8((b + n/2) / n)},
+			Fraction: float64(n) / float64(len(pixels)),
+		})
+	}
+
+	sort.SliceStable(entries, func(a, b int) bool {
+		return entries[a].Fraction > entries[b].Fraction
+	})
+
+	return entries
+}
+
+// widestChannel returns the channel (0 red, 1 green, 2 blue) with the
+// largest range in box and that range.
+func widestChannel(box []Color) (int, int) {
+	lo := [3]int{255, 255, 255}
+	hi := [3]int{}
+	for _, p := range box {
+		for c := 0; c < 3; c++ {
+			v := channelOf(p, c)
+			lo[c], hi[c] = min(lo[c], v), max(hi[c], v)
+		}
+	}
+
+	channel := 0
+	for c := 1; c < 3; c++ {
+		if hi[c]-lo[c] > hi[channel]-lo[channel] {
+			channel = c
+		}
+	}
+
+	return channel, hi[channel] - lo[channel]
+}
+
+func channelOf(p Color, channel int) int {
+	switch channel {
+	case 0:
+		return int(p.R)
+	case 1:
+		return int(p.G)
+	}
+
+	return int(p.B)
+}
@@ -0,0 +1,98 @@
+package palette
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stripes fills the image top to bottom with the colors, each covering the
+// given share of the height.
+func stripes(colors []color.NRGBA, shares []float64) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, 128, 128))
+	y := 0
+	for i, c := range colors {
+		end := y + int(shares[i]*128)
+		if i == len(colors)-1 {
+			end = 128
+		}
+		for ; y < end; y++ {
+			for x := 0; x < 128; x++ {
+				img.SetNRGBA(x, y, c)
+			}
+		}
+	}
+
+	return img
+}
+
+func TestParseHex(t *testing.T) {
+	c, err := ParseHex("#1a2B3c")
+	require.NoError(t, err)
+	assert.Equal(t, Color{R: 0x1a, G: 0x2b, B: 0x3c}, c)
+	assert.Equal(t, "#1a2b3c", c.Hex())
+
+	c, err = ParseHex("ffffff")
+	require.NoError(t, err)
+	assert.Equal(t, Color{R: 255, G: 255, B: 255}, c)
+
+	for _, s := range []string{"", "#fff", "#12345g", "1234567"} {
+		_, err := ParseHex(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestDistance(t *testing.T) {
+	red := Color{R: 255}
+	assert.Zero(t, Distance(red, red))
+	assert.InDelta(t, 100, Distance(Color{}, Color{R: 255, G: 255, B: 255}), 0.1, "black to white is the full lightness range")
+	assert.Less(t, Distance(red, Color{R: 250, G: 10, B: 5}), 5.0)
+	assert.Greater(t, Distance(red, Color{B: 255}), 50.0)
+}
+
+func TestExtract_Palette(t *testing.T) {
+	blue := color.NRGBA{R: 20, G: 60, B: 200, A: 255}
+	orange := color.NRGBA{R: 240, G: 140, B: 20, A: 255}
+	white := color.NRGBA{R: 250, G: 250, B: 250, A: 255}
+
+	s := Extract(stripes([]color.NRGBA{blue, orange, white}, []float64{0.5, 0.25, 0.25}), 5)
+
+	require.Len(t, s.Palette, 3, "identical pixels are never split")
+	assert.Equal(t, Color{R: 20, G: 60, B: 200}, s.Palette[0].Color)
+	assert.InDelta(t, 0.5, s.Palette[0].Fraction, 0.02)
+	assert.InDelta(t, 0.25, s.Palette[1].Fraction, 0.02)
+
+	var total float64
+	for _, e := range s.Palette {
+		total += e.Fraction
+	}
+	assert.InDelta(t, 1, total, 1e-9)
+
+	assert.False(t, s.Grayscale)
+	assert.Greater(t, s.Colorfulness, 33.0)
+}
+
+func TestExtract_Grayscale(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 100, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 100; x++ {
+			img.SetGray(x, y, color.Gray{Y: uint8(x * 2)})
+		}
+	}
+
+	s := Extract(img, 4)
+
+	assert.True(t, s.Grayscale)
+	assert.Less(t, s.Colorfulness, 1.0)
+	assert.Len(t, s.Palette, 4)
+	assert.InDelta(t, 0.39, s.Brightness, 0.02)
+}
+
+func TestExtract_Transparent(t *testing.T) {
+	s := Extract(image.NewNRGBA(image.Rect(0, 0, 10, 10)), 5)
+
+	assert.Empty(t, s.Palette)
+}
@@ -0,0 +1,94 @@
+package permission
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrDenied is returned, wrapped with a reason, when a policy check fails.
+var ErrDenied = errors.New("permission denied")
+
+// Role is a tenant-wide role carried by a principal. Every role includes the
+// permissions of the roles below it.
+type Role string
+
+const (
+	RoleViewer Role = "viewer"
+	RoleEditor Role = "editor"
+	RoleAdmin  Role = "admin"
+	// RoleOperator runs the service and may act across tenants.
+	RoleOperator Role = "operator"
+)
+
+func (r Role) rank() int {
+	switch r {
+	case RoleViewer:
+		return 1
+	case RoleEditor:
+		return 2
+	case RoleAdmin:
+		return 3
+	case RoleOperator:
+		return 4
+	}
+
+	return 0
+}
+
+// HasRole reports whether any of the given roles grants the required one.
+func HasRole(roles []string, required Role) bool {
+	for _, r := range roles {
+		if Role(r).rank() >= required.rank() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Level is the access level granted on a single image or album through sharing.
+type Level int
+
+const (
+	LevelNone Level = iota
+	LevelRead
+	LevelWrite
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelRead:
+		return "read"
+	case LevelWrite:
+		return "write"
+	}
+
+	return "none"
+}
+
+func ParseLevel(s string) (Level, error) {
+	switch s {
+	case "read":
+		return LevelRead, nil
+	case "write":
+		return LevelWrite, nil
+	}
+
+	return LevelNone, fmt.Errorf("unknown permission level %q", s)
+}
+
+// Grantee is the principal a share is granted to. Subjects are only unique
+// within a tenant, so a grantee is identified by both.
+type Grantee struct {
+	Tenant  string
+	Subject string
+}
+
+func (g Grantee) String() string {
+	return g.Tenant + "/" + g.Subject
+}
+
+// Denied builds an ErrDenied carrying a human readable reason.
+func Denied(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrDenied, fmt.Sprintf(format, args...))
+}
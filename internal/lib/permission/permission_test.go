@@ -0,0 +1,74 @@
+package permission
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHasRole(t *testing.T) {
+	all := []Role{RoleViewer, RoleEditor, RoleAdmin, RoleOperator}
+
+	tests := []struct {
+		name  string
+		roles []string
+		// granted lists the required roles the principal satisfies.
+		granted []Role
+	}{
+		{name: "no roles", roles: nil},
+		{name: "unknown role", roles: []string{"superuser"}},
+		{name: "role names are case sensitive", roles: []string{"Admin"}},
+		{name: "viewer", roles: []string{"viewer"}, granted: []Role{RoleViewer}},
+		{name: "editor", roles: []string{"editor"}, granted: []Role{RoleViewer, RoleEditor}},
+		{name: "admin", roles: []string{"admin"}, granted: []Role{RoleViewer, RoleEditor, RoleAdmin}},
+		{name: "operator", roles: []string{"operator"}, granted: all},
+		{name: "highest of several roles", roles: []string{"viewer", "unknown", "admin"}, granted: []Role{RoleViewer, RoleEditor, RoleAdmin}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, required := range all {
+				want := false
+				for _, g := range tt.granted {
+					want = want || g == required
+				}
+				assert.Equal(t, want, HasRole(tt.roles, required), "required %s", required)
+			}
+		})
+	}
+}
+
+func TestHasRole_UnknownRequiredRole(t *testing.T) {
+	// An unknown required role ranks lowest, so any known role satisfies it;
+	// policies only use the declared roles.
+	assert.True(t, HasRole([]string{"viewer"}, Role("unknown")))
+	assert.True(t, HasRole([]string{"unknown"}, Role("unknown")))
+}
+
+func TestParseLevel(t *testing.T) {
+	for _, level := range []Level{LevelRead, LevelWrite} {
+		parsed, err := ParseLevel(level.String())
+		require.NoError(t, err)
+		assert.Equal(t, level, parsed)
+	}
+
+	for _, s := range []string{"", "none", "READ", "admin"} {
+		_, err := ParseLevel(s)
+		assert.Error(t, err, "ParseLevel(%q)", s)
+	}
+
+	assert.Equal(t, "none", LevelNone.String())
+	assert.Less(t, LevelRead, LevelWrite, "write includes read")
+}
+
+func TestDenied(t *testing.T) {
+	err := Denied("image %d is shared with %s access", 7, LevelRead)
+
+	assert.ErrorIs(t, err, ErrDenied)
+	assert.Equal(t, "permission denied: image 7 is shared with read access", err.Error())
+}
+
+func TestGrantee_String(t *testing.T) {
+	assert.Equal(t, "tenant-a/bob", Grantee{Tenant: "tenant-a", Subject: "bob"}.String())
+}
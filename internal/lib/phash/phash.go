@@ -0,0 +1,168 @@
+// Package phash computes 64-bit perceptual hashes of images. Visually
+// similar images, such as resized or recompressed copies, get hashes that
+// differ in few bits, so the Hamming distance between two hashes measures
+// how alike the images look.
+package phash
+
+import (
+	"image"
+	"math"
+	"math/bits"
+	"sort"
+
+	"github.com/disintegration/imaging"
+)
+
+// Hashes holds the three hashes of an image. AHash compares pixels with the
+// mean and is the fastest to fool; DHash compares neighbouring pixels and
+// survives brightness changes; PHash compares low DCT frequencies and is
+// the most robust to scaling and compression.
+type Hashes struct {
+	AHash uint64
+	DHash uint64
+	PHash uint64
+}
+
+// Compute hashes img. The image is shrunk once and every hash is computed
+// from the small copy, so the cost is a single pass over the pixels.
+func Compute(img image.Image) Hashes {
+	small := imaging.Resize(img, 64, 64, imaging.Box)
+
+	return Hashes{
+		AHash: AHash(small),
+		DHash: DHash(small),
+		PHash: PHash(small),
+	}
+}
+
+// Distance is the number of bits in which a and b differ, from 0 for the
+// same hash to 64.
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// AHash sets a bit for each pixel of an 8x8 grayscale copy that is brighter
+// than the mean.
+func AHash(img image.Image) uint64 {
+	pixels := luminance(img, 8, 8)
+
+	var sum float64
+	for _, p := range pixels {
+		sum += p
+	}
+	mean := sum / float64(len(pixels))
+
+	var hash uint64
+	for i, p := range pixels {
+		if p > mean {
+			hash |= 1 << uint(i)
+		}
+	}
+
+	return hash
+}
+
+// DHash sets a bit for each pixel of a 9x8 grayscale copy that is brighter
+// than its right neighbour.
+func DHash(img image.Image) uint64 {
+	pixels := luminance(img, 9, 8)
+
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if pixels[y*9+x] > pixels[y*9+x+1] {
+				hash |= 1 << uint(y*8+x)
+			}
+		}
+	}
+
+	return hash
+}
+
+// PHash takes the 2D DCT of a 32x32 grayscale copy and sets a bit for each
+// of the 8x8 lowest frequencies that is above their median.
+func PHash(img image.Image) uint64 {
+	const size, low = 32, 8
+
+	coefficients := dct2D(luminance(img, size, size), size)
+
+	lowest := make([]float64, 0, low*low)
+	for y := 0; y < low; y++ {
+		lowest = append(lowest, coefficients[y*size:y*size+low]...)
+	}
+
+	median := median(lowest)
+
+	var hash uint64
+	for i, c := range lowest {
+		if c > median {
+			hash |= 1 << uint(i)
+		}
+	}
+
+	return hash
+}
+
+// luminance resizes img to w x h and returns the luma of each pixel, row
+// by row. Transparent pixels count as black.
+func luminance(img image.Image, w, h int) []float64 {
+	small := imaging.Resize(img, w, h, imaging.Lanczos)
+
+	pixels := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		row := small.Pix[y*small.Stride:]
+		for x := 0; x < w; x++ {
+			r, g, b, a := row[x*4], row[x*4+1], row[x*4+2], row[x*4+3]
+			luma := 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+			pixels[y*w+x] = luma * float64(a) / 255
+		}
+	}
+
+	return pixels
+}
+
+// dct2D is the separable 2D DCT-II of an n x n block.
+func dct2D(block []float64, n int) []float64 {
+	cos := make([]float64, n*n)
+	for k := 0; k < n; k++ {
+		for i := 0; i < n; i++ {
+			cos[k*n+i] = math.Cos(math.Pi / float64(n) * (float64(i) + 0.5) * float64(k))
+		}
+	}
+
+	rows := make([]float64, n*n)
+	for y := 0; y < n; y++ {
+		for k := 0; k < n; k++ {
+			var sum float64
+			for x := 0; x < n; x++ {
+				sum += block[y*n+x] * cos[k*n+x]
+			}
+			rows[y*n+k] = sum
+		}
+	}
+
+	out := make([]float64, n*n)
+	for x := 0; x < n; x++ {
+		for k := 0; k < n; k++ {
+			var sum float64
+			for y := 0; y < n; y++ {
+				sum += rows[y*n+x] * cos[k*n+y]
+			}
+			out[k*n+x] = sum
+		}
+	}
+
+	return out
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+
+	return sorted[mid]
+}
@@ -0,0 +1,83 @@
+package phash
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scene draws a gradient with a bright disc, a picture with structure at
+// every scale.
+func scene(w, h int, cx, cy float64) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			fx, fy := float64(x)/float64(w), float64(y)/float64(h)
+			c := color.NRGBA{R: uint8(200 * fx), G: uint8(120 * fy), B: 60, A: 255}
+			if (fx-cx)*(fx-cx)+(fy-cy)*(fy-cy) < 0.04 {
+				c = color.NRGBA{R: 250, G: 240, B: 220, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+
+	return img
+}
+
+func recompress(t *testing.T, img image.Image, quality int) image.Image {
+	t.Helper()
+
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}))
+
+	decoded, err := jpeg.Decode(&buf)
+	require.NoError(t, err)
+
+	return decoded
+}
+
+func TestDistance(t *testing.T) {
+	assert.Equal(t, 0, Distance(0xdeadbeef, 0xdeadbeef))
+	assert.Equal(t, 1, Distance(0, 1<<63))
+	assert.Equal(t, 64, Distance(0, ^uint64(0)))
+}
+
+func TestCompute_Deterministic(t *testing.T) {
+	img := scene(320, 240, 0.3, 0.4)
+
+	assert.Equal(t, Compute(img), Compute(img))
+}
+
+func TestCompute_NearCopies(t *testing.T) {
+	original := Compute(scene(640, 480, 0.3, 0.4))
+
+	copies := map[string]image.Image{
+		"resized":      imaging.Resize(scene(640, 480, 0.3, 0.4), 160, 0, imaging.Lanczos),
+		"recompressed": recompress(t, scene(640, 480, 0.3, 0.4), 30),
+		"brightened":   imaging.AdjustBrightness(scene(640, 480, 0.3, 0.4), 10),
+	}
+
+	for name, img := range copies {
+		t.Run(name, func(t *testing.T) {
+			got := Compute(img)
+			assert.LessOrEqual(t, Distance(original.AHash, got.AHash), 6, "ahash")
+			assert.LessOrEqual(t, Distance(original.DHash, got.DHash), 6, "dhash")
+			assert.LessOrEqual(t, Distance(original.PHash, got.PHash), 6, "phash")
+		})
+	}
+}
+
+func TestCompute_DifferentImages(t *testing.T) {
+	a := Compute(scene(640, 480, 0.3, 0.4))
+	b := Compute(imaging.Rotate90(scene(640, 480, 0.8, 0.7)))
+
+	assert.Greater(t, Distance(a.AHash, b.AHash), 10, "ahash")
+	assert.Greater(t, Distance(a.DHash, b.DHash), 10, "dhash")
+	assert.Greater(t, Distance(a.PHash, b.PHash), 10, "phash")
+}
@@ -0,0 +1,324 @@
+// Package pipeline applies a validated list of image operations, such as
+// blur or brightness, in order.
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aidosgal/image-processing-service/internal/config"
+	"github.com/aidosgal/image-processing-service/internal/lib/smartcrop"
+	"github.com/aidosgal/image-processing-service/internal/lib/variantcache"
+	"github.com/disintegration/imaging"
+)
+
+// ErrInvalid is matched by every validation error of Parse.
+var ErrInvalid = errors.New("invalid operation")
+
+// param bounds a numeric parameter. Integer parameters reject fractions.
+type param struct {
+	min, max float64
+	integer  bool
+	optional bool
+}
+
+// spec describes an operation: its parameters and how to apply it.
+type spec struct {
+	params map[string]param
+	// check validates the parameters together, after each passed its bounds.
+	check func(p map[string]float64) error
+	apply func(img image.Image, p map[string]float64) image.Image
+	// crop, set instead of apply, also takes the crop hints and returns
+	// those of the result.
+	crop func(img image.Image, p map[string]float64, hints smartcrop.Hints) (image.Image, smartcrop.Hints)
+	// size, set for operations that change the dimensions, returns those
+	// of the result, so oversized results are rejected before allocating.
+	size func(src image.Rectangle, p map[string]float64) (width, height int)
+}
+
+const (
+	// maxSize bounds the width and height parameters and each side of the
+	// image a resize or crop produces.
+	maxSize = 8192
+	// maxPixels bounds the area of the image a resize or crop produces.
+	maxPixels = 40_000_000
+)
+
+var specs = map[string]spec{
+	"blur": {
+		params: map[string]param{"sigma": {min: 0.1, max: 50}},
+		apply: func(img image.Image, p map[string]float64) image.Image {
+			return imaging.Blur(img, p["sigma"])
+		},
+	},
+	"sharpen": {
+		params: map[string]param{"sigma": {min: 0.1, max: 50}},
+		apply: func(img image.Image, p map[string]float64) image.Image {
+			return imaging.Sharpen(img, p["sigma"])
+		},
+	},
+	"brightness": {
+		params: map[string]param{"percent": {min: -100, max: 100}},
+		apply: func(img image.Image, p map[string]float64) image.Image {
+			return imaging.AdjustBrightness(img, p["percent"])
+		},
+	},
+	"contrast": {
+		params: map[string]param{"percent": {min: -100, max: 100}},
+		apply: func(img image.Image, p map[string]float64) image.Image {
+			return imaging.AdjustContrast(img, p["percent"])
+		},
+	},
+	"gamma": {
+		params: map[string]param{"gamma": {min: 0.1, max: 10}},
+		apply: func(img image.Image, p map[string]float64) image.Image {
+			return imaging.AdjustGamma(img, p["gamma"])
+		},
+	},
+	"saturation": {
+		params: map[string]param{"percent": {min: -100, max: 500}},
+		apply: func(img image.Image, p map[string]float64) image.Image {
+			return imaging.AdjustSaturation(img, p["percent"])
+		},
+	},
+	"grayscale": {
+		apply: func(img image.Image, _ map[string]float64) image.Image {
+			return imaging.Grayscale(img)
+		},
+	},
+	"invert": {
+		apply: func(img image.Image, _ map[string]float64) image.Image {
+			return imaging.Invert(img)
+		},
+	},
+	"resize": {
+		params: map[string]param{
+			"width":  {min: 0, max: maxSize, integer: true, optional: true},
+			"height": {min: 0, max: maxSize, integer: true, optional: true},
+		},
+		check: func(p map[string]float64) error {
+			if p["width"] == 0 && p["height"] == 0 {
+				return errors.New("width or height is required")
+			}
+			return nil
+		},
+		size: resizedSize,
+		apply: func(img image.Image, p map[string]float64) image.Image {
+			return imaging.Resize(img, int(p["width"]), int(p["height"]), imaging.Lanczos)
+		},
+	},
+	"smartcrop": {
+		params: map[string]param{
+			"width":  {min: 1, max: maxSize, integer: true},
+			"height": {min: 1, max: maxSize, integer: true},
+		},
+		size: resizedSize,
+		crop: func(img image.Image, p map[string]float64, hints smartcrop.Hints) (image.Image, smartcrop.Hints) {
+			w, h := int(p["width"]), int(p["height"])
+			window := hints.Window(img, w, h)
+
+			return imaging.Resize(imaging.Crop(img, window), w, h, imaging.Lanczos), hints.Within(img.Bounds(), window)
+		},
+	},
+}
+
+// resizedSize returns the size imaging.Resize produces for the width and
+// height parameters, where a zero side keeps the aspect ratio of src.
+func resizedSize(src image.Rectangle, p map[string]float64) (int, int) {
+	w, h := p["width"], p["height"]
+	srcW, srcH := float64(src.Dx()), float64(src.Dy())
+	if srcW == 0 || srcH == 0 {
+		return 0, 0
+	}
+
+	switch {
+	case w == 0:
+		w = math.Max(1, math.Floor(h*srcW/srcH+0.5))
+	case h == 0:
+		h = math.Max(1, math.Floor(w*srcH/srcW+0.5))
+	}
+
+	return int(math.Min(w, math.MaxInt32)), int(math.Min(h, math.MaxInt32))
+}
+
+// checkSize rejects results larger than maxSize on a side or maxPixels in area.
+func checkSize(width, height int) error {
+	if width > maxSize || height > maxSize {
+		return fmt.Errorf("result of %dx%d exceeds %d pixels on a side", width, height, maxSize)
+	}
+	if int64(width)*int64(height) > maxPixels {
+		return fmt.Errorf("result of %dx%d exceeds %d pixels", width, height, maxPixels)
+	}
+
+	return nil
+}
+
+// Names lists the supported operations.
+func Names() []string {
+	return slices.Sorted(maps.Keys(specs))
+}
+
+type step struct {
+	spec   spec
+	params map[string]float64
+}
+
+// Pipeline is a parsed list of operations. The zero value applies nothing.
+type Pipeline struct {
+	steps []step
+	ops   []variantcache.Op
+}
+
+// Parse validates ops: known names, known parameters within their bounds,
+// and at most maxOps operations. Parameter values are numbers.
+func Parse(ops []variantcache.Op, maxOps int) (*Pipeline, error) {
+	if maxOps > 0 && len(ops) > maxOps {
+		return nil, fmt.Errorf("%w: at most %d operations are allowed", ErrInvalid, maxOps)
+	}
+
+	p := &Pipeline{}
+	for i, op := range ops {
+		name := strings.ToLower(strings.TrimSpace(op.Name))
+		s, ok := specs[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: operation %d: unknown operation %q, want one of %s", ErrInvalid, i+1, op.Name, strings.Join(Names(), ", "))
+		}
+
+		params, err := parseParams(s, op.Params)
+		if err != nil {
+			return nil, fmt.Errorf("%w: operation %d (%s): %v", ErrInvalid, i+1, name, err)
+		}
+
+		p.steps = append(p.steps, step{spec: s, params: params})
+		p.ops = append(p.ops, variantcache.Op{Name: name, Params: formatParams(params)})
+	}
+
+	return p, nil
+}
+
+func parseParams(s spec, raw map[string]string) (map[string]float64, error) {
+	values := make(map[string]float64, len(s.params))
+	for key, value := range raw {
+		key = strings.ToLower(strings.TrimSpace(key))
+		bounds, ok := s.params[key]
+		if !ok {
+			return nil, fmt.Errorf("unknown parameter %q", key)
+		}
+
+		v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || math.IsNaN(v) {
+			return nil, fmt.Errorf("%s must be a number", key)
+		}
+		if bounds.integer && v != math.Trunc(v) {
+			return nil, fmt.Errorf("%s must be a whole number", key)
+		}
+		if v < bounds.min || v > bounds.max {
+			return nil, fmt.Errorf("%s must be between %g and %g", key, bounds.min, bounds.max)
+		}
+
+		values[key] = v
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(s.params)) {
+		if _, ok := values[key]; !ok && !s.params[key].optional {
+			return nil, fmt.Errorf("%s is required", key)
+		}
+	}
+
+	if s.check != nil {
+		if err := s.check(values); err != nil {
+			return nil, err
+		}
+	}
+
+	return values, nil
+}
+
+// formatParams renders parsed values canonically, so "2", "2.0" and " 2"
+// share a cache key.
+func formatParams(values map[string]float64) map[string]string {
+	params := make(map[string]string, len(values))
+	for k, v := range values {
+		params[k] = strconv.FormatFloat(v, 'g', -1, 64)
+	}
+
+	return params
+}
+
+// Ops returns the normalized operations, for cache keys.
+func (p *Pipeline) Ops() []variantcache.Op {
+	return slices.Clone(p.ops)
+}
+
+// Len is the number of operations.
+func (p *Pipeline) Len() int {
+	return len(p.steps)
+}
+
+// Crops reports whether any operation crops, so its result depends on the
+// crop hints.
+func (p *Pipeline) Crops() bool {
+	for _, s := range p.steps {
+		if s.spec.crop != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Apply runs the operations on img in order. Crops follow hints, the crop
+// hints of img; without a focal point they find one with smartcrop. It
+// stops between operations once ctx is done. An operation that would
+// produce an oversized image fails with ErrInvalid before it runs.
+func (p *Pipeline) Apply(ctx context.Context, img image.Image, hints smartcrop.Hints) (image.Image, error) {
+	for i, s := range p.steps {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if s.spec.size != nil {
+			if err := checkSize(s.spec.size(img.Bounds(), s.params)); err != nil {
+				return nil, fmt.Errorf("%w: operation %d (%s): %v", ErrInvalid, i+1, p.ops[i].Name, err)
+			}
+		}
+
+		if s.spec.crop == nil {
+			img = s.spec.apply(img, s.params)
+			continue
+		}
+
+		img, hints = s.spec.crop(img, s.params, hints)
+	}
+
+	return img, nil
+}
+
+// LoadPresets parses the configured presets. Presets are not subject to the
+// per-request operation cap.
+func LoadPresets(cfg map[string][]config.OperationConfig) (map[string]*Pipeline, error) {
+	const op = "pipeline.LoadPresets"
+
+	presets := make(map[string]*Pipeline, len(cfg))
+	for name, steps := range cfg {
+		ops := make([]variantcache.Op, 0, len(steps))
+		for _, s := range steps {
+			ops = append(ops, variantcache.Op{Name: s.Name, Params: s.Params})
+		}
+
+		p, err := Parse(ops, 0)
+		if err != nil {
+			return nil, fmt.Errorf("%s: preset %q: %w", op, name, err)
+		}
+		presets[name] = p
+	}
+
+	return presets, nil
+}
@@ -0,0 +1,216 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aidosgal/image-processing-service/internal/lib/smartcrop"
+	"github.com/aidosgal/image-processing-service/internal/lib/variantcache"
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden images in testdata")
+
+// scene is a small deterministic input: a color gradient with a light
+// square and a dark stripe, so every operation visibly changes it.
+func scene() image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 48))
+	for y := 0; y < 48; y++ {
+		for x := 0; x < 64; x++ {
+			c := color.NRGBA{R: uint8(x * 4), G: uint8(y * 5), B: 120, A: 255}
+			if x > 16 && x < 32 && y > 12 && y < 28 {
+				c = color.NRGBA{R: 240, G: 230, B: 200, A: 255}
+			}
+			if y > 36 && y < 40 {
+				c = color.NRGBA{R: 20, G: 20, B: 30, A: 255}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+
+	return img
+}
+
+func TestApply_Golden(t *testing.T) {
+	tests := []struct {
+		golden string
+		ops    []variantcache.Op
+	}{
+		{"blur", []variantcache.Op{{Name: "blur", Params: map[string]string{"sigma": "1.5"}}}},
+		{"sharpen", []variantcache.Op{{Name: "sharpen", Params: map[string]string{"sigma": "1"}}}},
+		{"brightness", []variantcache.Op{{Name: "brightness", Params: map[string]string{"percent": "30"}}}},
+		{"contrast", []variantcache.Op{{Name: "contrast", Params: map[string]string{"percent": "-40"}}}},
+		{"gamma", []variantcache.Op{{Name: "gamma", Params: map[string]string{"gamma": "0.6"}}}},
+		{"saturation", []variantcache.Op{{Name: "saturation", Params: map[string]string{"percent": "80"}}}},
+		{"grayscale", []variantcache.Op{{Name: "grayscale"}}},
+		{"invert", []variantcache.Op{{Name: "invert"}}},
+		{"resize", []variantcache.Op{{Name: "resize", Params: map[string]string{"width": "32"}}}},
+		{"smartcrop", []variantcache.Op{{Name: "smartcrop", Params: map[string]string{"width": "24", "height": "24"}}}},
+		{"composed", []variantcache.Op{
+			{Name: "contrast", Params: map[string]string{"percent": "20"}},
+			{Name: "blur", Params: map[string]string{"sigma": "0.8"}},
+			{Name: "grayscale"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			p, err := Parse(tt.ops, 0)
+			require.NoError(t, err)
+
+			out, err := p.Apply(context.Background(), scene(), smartcrop.Hints{})
+			require.NoError(t, err)
+
+			path := filepath.Join("testdata", tt.golden+".png")
+			if *update {
+				require.NoError(t, imaging.Save(out, path))
+			}
+
+			want, err := imaging.Open(path)
+			require.NoError(t, err, "run go test -update to create the golden image")
+			assert.Equal(t, imaging.Clone(want), imaging.Clone(out))
+		})
+	}
+}
+
+func TestParse_Normalizes(t *testing.T) {
+	a, err := Parse([]variantcache.Op{{Name: " Blur", Params: map[string]string{"Sigma": "2.0"}}}, 0)
+	require.NoError(t, err)
+	b, err := Parse([]variantcache.Op{{Name: "blur", Params: map[string]string{"sigma": "2"}}}, 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, variantcache.NormalizeOps(a.Ops()), variantcache.NormalizeOps(b.Ops()))
+	assert.Equal(t, "blur(sigma=2)", variantcache.NormalizeOps(a.Ops()))
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := map[string][]variantcache.Op{
+		"unknown operation": {{Name: "emboss"}},
+		"unknown parameter": {{Name: "grayscale", Params: map[string]string{"amount": "1"}}},
+		"missing parameter": {{Name: "blur"}},
+		"not a number":      {{Name: "blur", Params: map[string]string{"sigma": "soft"}}},
+		"out of range":      {{Name: "brightness", Params: map[string]string{"percent": "150"}}},
+		"zero gamma":        {{Name: "gamma", Params: map[string]string{"gamma": "0"}}},
+		"fractional size":   {{Name: "resize", Params: map[string]string{"width": "10.5"}}},
+		"no size":           {{Name: "resize"}},
+		"no crop height":    {{Name: "smartcrop", Params: map[string]string{"width": "10"}}},
+		"too many": {
+			{Name: "invert"}, {Name: "invert"}, {Name: "invert"},
+		},
+	}
+
+	for name, ops := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(ops, 2)
+			assert.True(t, errors.Is(err, ErrInvalid), "got %v", err)
+		})
+	}
+}
+
+func TestApply_OversizedResult(t *testing.T) {
+	tall := image.NewNRGBA(image.Rect(0, 0, 1, 4000))
+
+	tests := []struct {
+		name string
+		img  image.Image
+		op   variantcache.Op
+	}{
+		{"width keeps the aspect ratio of a tall image", tall, variantcache.Op{Name: "resize", Params: map[string]string{"width": "8192"}}},
+		{"height keeps the aspect ratio of a wide image", image.NewNRGBA(image.Rect(0, 0, 4000, 1)), variantcache.Op{Name: "resize", Params: map[string]string{"height": "20"}}},
+		{"too many pixels", scene(), variantcache.Op{Name: "resize", Params: map[string]string{"width": "8000", "height": "8000"}}},
+		{"crop with too many pixels", scene(), variantcache.Op{Name: "smartcrop", Params: map[string]string{"width": "8192", "height": "8192"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := Parse([]variantcache.Op{tt.op}, 0)
+			require.NoError(t, err, "the parameters alone are within bounds")
+
+			_, err = p.Apply(context.Background(), tt.img, smartcrop.Hints{})
+			assert.ErrorIs(t, err, ErrInvalid)
+		})
+	}
+
+	p, err := Parse([]variantcache.Op{{Name: "resize", Params: map[string]string{"width": "2"}}}, 0)
+	require.NoError(t, err)
+	out, err := p.Apply(context.Background(), tall, smartcrop.Hints{})
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 2, 8000), out.Bounds())
+}
+
+func TestApply_CropKeepsFocus(t *testing.T) {
+	p, err := Parse([]variantcache.Op{
+		{Name: "smartcrop", Params: map[string]string{"width": "48", "height": "48"}},
+		{Name: "smartcrop", Params: map[string]string{"width": "24", "height": "48"}},
+	}, 0)
+	require.NoError(t, err)
+
+	// The light square spans x 17 to 31; a focus on it keeps it in frame,
+	// through both crops.
+	out, err := p.Apply(context.Background(), scene(), smartcrop.Hints{Focus: &smartcrop.Point{X: 0.38, Y: 0.4}})
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 24, 48), out.Bounds())
+	assert.Equal(t, color.NRGBA{R: 240, G: 230, B: 200, A: 255}, imaging.Clone(out).NRGBAAt(12, 20))
+
+	right, err := p.Apply(context.Background(), scene(), smartcrop.Hints{Focus: &smartcrop.Point{X: 1, Y: 0.5}})
+	require.NoError(t, err)
+	assert.NotEqual(t, color.NRGBA{R: 240, G: 230, B: 200, A: 255}, imaging.Clone(right).NRGBAAt(12, 20))
+}
+
+func TestApply_CropHint(t *testing.T) {
+	p, err := Parse([]variantcache.Op{
+		{Name: "smartcrop", Params: map[string]string{"width": "16", "height": "16"}},
+	}, 0)
+	require.NoError(t, err)
+
+	// A 1:1 hint on the light square wins over a focal point elsewhere.
+	hints := smartcrop.Hints{
+		Focus: &smartcrop.Point{X: 1, Y: 1},
+		Crops: map[smartcrop.Aspect]smartcrop.Rect{
+			smartcrop.NewAspect(1, 1): {X: 18.0 / 64, Y: 14.0 / 48, W: 12.0 / 64, H: 12.0 / 48},
+		},
+	}
+	out, err := p.Apply(context.Background(), scene(), hints)
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 16, 16), out.Bounds())
+	assert.Equal(t, color.NRGBA{R: 240, G: 230, B: 200, A: 255}, imaging.Clone(out).NRGBAAt(8, 8))
+
+	// Hints for other aspect ratios are ignored.
+	wide, err := Parse([]variantcache.Op{
+		{Name: "smartcrop", Params: map[string]string{"width": "32", "height": "16"}},
+	}, 0)
+	require.NoError(t, err)
+	out, err = wide.Apply(context.Background(), scene(), hints)
+	require.NoError(t, err)
+	assert.NotEqual(t, color.NRGBA{R: 240, G: 230, B: 200, A: 255}, imaging.Clone(out).NRGBAAt(8, 8))
+}
+
+func TestApply_Cancelled(t *testing.T) {
+	p, err := Parse([]variantcache.Op{{Name: "invert"}}, 0)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = p.Apply(ctx, scene(), smartcrop.Hints{})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			panic(err)
+		}
+	}
+
+	os.Exit(m.Run())
+}
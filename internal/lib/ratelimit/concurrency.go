@@ -0,0 +1,98 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+var ErrBusy = errors.New("too many concurrent operations")
+
+// BusyError is returned by ConcurrencyLimiter.Acquire when no slot frees up
+// in time. It matches ErrBusy and carries the suggested back-off.
+type BusyError struct {
+	RetryAfter time.Duration
+}
+
+func (e *BusyError) Error() string {
+	return ErrBusy.Error()
+}
+
+func (e *BusyError) Is(target error) bool {
+	return target == ErrBusy
+}
+
+// ConcurrencyLimiter caps the number of operations running at once.
+// Callers that can't get a slot within waitTimeout get a *BusyError.
+type ConcurrencyLimiter struct {
+	slots       chan struct{}
+	waitTimeout time.Duration
+	waiting     atomic.Int64
+}
+
+// NewConcurrencyLimiter returns nil when max is not positive, which disables
+// the limit. All methods are safe to call on a nil limiter.
+func NewConcurrencyLimiter(max int, waitTimeout time.Duration) *ConcurrencyLimiter {
+	if max <= 0 {
+		return nil
+	}
+
+	return &ConcurrencyLimiter{
+		slots:       make(chan struct{}, max),
+		waitTimeout: waitTimeout,
+	}
+}
+
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	l.waiting.Add(1)
+	defer l.waiting.Add(-1)
+
+	timer := time.NewTimer(l.waitTimeout)
+	defer timer.Stop()
+
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return &BusyError{RetryAfter: l.waitTimeout}
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *ConcurrencyLimiter) Release() {
+	if l == nil {
+		return
+	}
+
+	<-l.slots
+}
+
+// InUse returns the number of taken slots.
+func (l *ConcurrencyLimiter) InUse() int {
+	if l == nil {
+		return 0
+	}
+
+	return len(l.slots)
+}
+
+// Waiting returns the number of callers blocked in Acquire.
+func (l *ConcurrencyLimiter) Waiting() int {
+	if l == nil {
+		return 0
+	}
+
+	return int(l.waiting.Load())
+}
@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrencyLimiter_Disabled(t *testing.T) {
+	l := NewConcurrencyLimiter(0, time.Second)
+	require.Nil(t, l)
+
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, l.Acquire(context.Background()))
+	}
+	l.Release()
+	assert.Zero(t, l.InUse())
+	assert.Zero(t, l.Waiting())
+}
+
+func TestConcurrencyLimiter_Cap(t *testing.T) {
+	l := NewConcurrencyLimiter(2, 20*time.Millisecond)
+
+	require.NoError(t, l.Acquire(context.Background()))
+	require.NoError(t, l.Acquire(context.Background()))
+	assert.Equal(t, 2, l.InUse())
+
+	err := l.Acquire(context.Background())
+	assert.ErrorIs(t, err, ErrBusy)
+	var busy *BusyError
+	require.True(t, errors.As(err, &busy))
+	assert.Equal(t, 20*time.Millisecond, busy.RetryAfter)
+	assert.Equal(t, 2, l.InUse(), "a rejected caller takes no slot")
+	assert.Zero(t, l.Waiting())
+
+	l.Release()
+	assert.NoError(t, l.Acquire(context.Background()))
+}
+
+func TestConcurrencyLimiter_WaiterGetsReleasedSlot(t *testing.T) {
+	l := NewConcurrencyLimiter(1, time.Minute)
+	require.NoError(t, l.Acquire(context.Background()))
+
+	acquired := make(chan error, 1)
+	go func() { acquired <- l.Acquire(context.Background()) }()
+
+	require.Eventually(t, func() bool { return l.Waiting() == 1 }, time.Second, time.Millisecond)
+
+	l.Release()
+	select {
+	case err := <-acquired:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("waiter did not get the released slot")
+	}
+	assert.Equal(t, 1, l.InUse())
+	assert.Zero(t, l.Waiting())
+}
+
+func TestConcurrencyLimiter_ContextCancelled(t *testing.T) {
+	l := NewConcurrencyLimiter(1, time.Minute)
+	require.NoError(t, l.Acquire(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := l.Acquire(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NotErrorIs(t, err, ErrBusy)
+	assert.Equal(t, 1, l.InUse())
+}
@@ -0,0 +1,106 @@
+package ratelimit
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// RetryAfterKey is the trailer telling rejected clients when to retry.
+const RetryAfterKey = "retry-after"
+
+// RetryAfterSeconds formats d as whole seconds, rounded up, for RetryAfterKey.
+func RetryAfterSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// Limit is a token bucket: Rate tokens per second with room for Burst.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+type entry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// KeyedLimiter keeps a token bucket per key. Buckets idle for longer than
+// idleTTL are dropped.
+type KeyedLimiter struct {
+	mu       sync.Mutex
+	limiters map[string]*entry
+	idleTTL  time.Duration
+	lastGC   time.Time
+	now      func() time.Time
+}
+
+// Option configures a KeyedLimiter.
+type Option func(*KeyedLimiter)
+
+// WithClock makes the limiter read the current time from now instead of
+// time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(l *KeyedLimiter) {
+		l.now = now
+	}
+}
+
+func NewKeyedLimiter(idleTTL time.Duration, opts ...Option) *KeyedLimiter {
+	l := &KeyedLimiter{
+		limiters: make(map[string]*entry),
+		idleTTL:  idleTTL,
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	l.lastGC = l.now()
+
+	return l
+}
+
+// Allow takes a token from the bucket of key. When the bucket is empty it
+// returns false and how long the caller should wait before retrying.
+func (l *KeyedLimiter) Allow(key string, limit Limit) (bool, time.Duration) {
+	now := l.now()
+
+	l.mu.Lock()
+	e, ok := l.limiters[key]
+	if !ok {
+		e = &entry{limiter: rate.NewLimiter(rate.Limit(limit.Rate), limit.Burst)}
+		l.limiters[key] = e
+	}
+	e.lastSeen = now
+	l.gc(now)
+	l.mu.Unlock()
+
+	r := e.limiter.ReserveN(now, 1)
+	if !r.OK() {
+		return false, time.Second
+	}
+
+	if delay := r.DelayFrom(now); delay > 0 {
+		r.CancelAt(now)
+		return false, delay
+	}
+
+	return true, 0
+}
+
+// gc must be called with l.mu held.
+func (l *KeyedLimiter) gc(now time.Time) {
+	if l.idleTTL <= 0 || now.Sub(l.lastGC) < l.idleTTL {
+		return
+	}
+
+	for key, e := range l.limiters {
+		if now.Sub(e.lastSeen) > l.idleTTL {
+			delete(l.limiters, key)
+		}
+	}
+	l.lastGC = now
+}
@@ -0,0 +1,147 @@
+package ratelimit
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock is a manually advanced clock for WithClock.
+type fakeClock struct {
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func (l *KeyedLimiter) keys() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	keys := make([]string, 0, len(l.limiters))
+	for key := range l.limiters {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	return keys
+}
+
+func TestKeyedLimiter_Refill(t *testing.T) {
+	clock := newFakeClock()
+	l := NewKeyedLimiter(time.Hour, WithClock(clock.Now))
+	limit := Limit{Rate: 2, Burst: 3}
+
+	for i := 0; i < 3; i++ {
+		ok, _ := l.Allow("alice", limit)
+		assert.True(t, ok, "burst request %d", i)
+	}
+
+	ok, retryAfter := l.Allow("alice", limit)
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+	// A rejected call doesn't consume a token, so the wait only shrinks.
+	clock.Advance(499 * time.Millisecond)
+	ok, retryAfter = l.Allow("alice", limit)
+	assert.False(t, ok)
+	assert.Equal(t, time.Millisecond, retryAfter)
+
+	clock.Advance(time.Millisecond)
+	ok, _ = l.Allow("alice", limit)
+	assert.True(t, ok, "one token refilled")
+	ok, _ = l.Allow("alice", limit)
+	assert.False(t, ok)
+
+	// The bucket never holds more than the burst.
+	clock.Advance(time.Minute)
+	for i := 0; i < 3; i++ {
+		ok, _ := l.Allow("alice", limit)
+		assert.True(t, ok, "refilled request %d", i)
+	}
+	ok, _ = l.Allow("alice", limit)
+	assert.False(t, ok)
+}
+
+func TestKeyedLimiter_SeparateKeys(t *testing.T) {
+	clock := newFakeClock()
+	l := NewKeyedLimiter(time.Hour, WithClock(clock.Now))
+	limit := Limit{Rate: 1, Burst: 1}
+
+	ok, _ := l.Allow("alice", limit)
+	assert.True(t, ok)
+	ok, _ = l.Allow("alice", limit)
+	assert.False(t, ok)
+
+	ok, _ = l.Allow("bob", limit)
+	assert.True(t, ok, "another key has its own bucket")
+}
+
+func TestKeyedLimiter_ZeroBurstRejects(t *testing.T) {
+	l := NewKeyedLimiter(time.Hour, WithClock(newFakeClock().Now))
+
+	ok, retryAfter := l.Allow("alice", Limit{Rate: 10, Burst: 0})
+	assert.False(t, ok)
+	assert.Equal(t, time.Second, retryAfter)
+}
+
+func TestKeyedLimiter_EvictsIdleBuckets(t *testing.T) {
+	clock := newFakeClock()
+	l := NewKeyedLimiter(time.Minute, WithClock(clock.Now))
+	limit := Limit{Rate: 0.001, Burst: 1}
+
+	ok, _ := l.Allow("alice", limit)
+	assert.True(t, ok)
+	ok, _ = l.Allow("alice", limit)
+	assert.False(t, ok)
+
+	clock.Advance(30 * time.Second)
+	l.Allow("bob", limit)
+	assert.Equal(t, []string{"alice", "bob"}, l.keys(), "nothing is idle long enough yet")
+
+	clock.Advance(31 * time.Second)
+	l.Allow("carol", limit)
+	assert.Equal(t, []string{"bob", "carol"}, l.keys(), "alice idled past the ttl")
+
+	// The evicted bucket starts over full.
+	ok, _ = l.Allow("alice", limit)
+	assert.True(t, ok)
+}
+
+func TestKeyedLimiter_NoEvictionWithoutTTL(t *testing.T) {
+	clock := newFakeClock()
+	l := NewKeyedLimiter(0, WithClock(clock.Now))
+	limit := Limit{Rate: 1, Burst: 1}
+
+	l.Allow("alice", limit)
+	clock.Advance(24 * time.Hour)
+	l.Allow("bob", limit)
+
+	assert.Equal(t, []string{"alice", "bob"}, l.keys())
+}
+
+func TestRetryAfterSeconds(t *testing.T) {
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{in: 0, want: "0"},
+		{in: time.Millisecond, want: "1"},
+		{in: time.Second, want: "1"},
+		{in: 1500 * time.Millisecond, want: "2"},
+		{in: 2 * time.Second, want: "2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in.String(), func(t *testing.T) {
+			assert.Equal(t, tt.want, RetryAfterSeconds(tt.in))
+		})
+	}
+}
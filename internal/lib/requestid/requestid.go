@@ -0,0 +1,38 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+)
+
+// MetadataKey is the gRPC metadata key carrying the request id, both on the
+// way in and in the response header.
+const MetadataKey = "x-request-id"
+
+// idPattern limits ids taken from callers to something safe to log.
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+type ctxKey struct{}
+
+// New returns a random 128-bit id in hex.
+func New() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+func Valid(id string) bool {
+	return idPattern.MatchString(id)
+}
+
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(ctxKey{}).(string)
+	return id, ok && id != ""
+}
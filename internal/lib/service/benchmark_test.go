@@ -0,0 +1,86 @@
+package lib
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/disintegration/imaging"
+)
+
+// twoDecodePipeline is the processing an upload went through before the
+// single-decode pipeline: the original was written, then opened and decoded
+// in full for metadata, reopened to sniff its MIME type, and decoded in full
+// again for the thumbnail. It is kept here as the baseline.
+func twoDecodePipeline(b *testing.B, data []byte, dir string) {
+	path := filepath.Join(dir, "photo.jpg")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		b.Fatal(err)
+	}
+
+	img, err := imaging.Open(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		b.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	head := make([]byte, 512)
+	f.Read(head)
+	f.Close()
+	_ = http.DetectContentType(head)
+	_ = generateImageTags(img.Bounds().Dx(), img.Bounds().Dy())
+
+	img, err = imaging.Open(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	thumb := imaging.Resize(img, 200, 0, imaging.Lanczos)
+	if err := imaging.Save(thumb, filepath.Join(dir, "thumb_photo.jpg")); err != nil {
+		b.Fatal(err)
+	}
+}
+
+func singleDecodePipeline(b *testing.B, data []byte, dir string) {
+	ctx := context.Background()
+	path := filepath.Join(dir, "photo.jpg")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		b.Fatal(err)
+	}
+
+	d, err := Decode(ctx, data, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	_ = ExtractImageMetadata(d, path, "photo.jpg")
+	if _, err := GenerateVariant(ctx, d, path, Thumbnail(dir)); err != nil {
+		b.Fatal(err)
+	}
+}
+
+func benchmarkPipeline(b *testing.B, pipeline func(*testing.B, []byte, string)) {
+	data := testJPEG(b, 1920, 1080)
+	dir := b.TempDir()
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+
+	for range b.N {
+		pipeline(b, data, dir)
+	}
+}
+
+func BenchmarkPipeline_TwoDecodes(b *testing.B) {
+	benchmarkPipeline(b, twoDecodePipeline)
+}
+
+func BenchmarkPipeline_SingleDecode(b *testing.B) {
+	benchmarkPipeline(b, singleDecodePipeline)
+}
@@ -1,137 +1,256 @@
 package lib
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"image"
+	"io"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
 
+	"github.com/aidosgal/image-processing-service/internal/lib/smartcrop"
+	"github.com/aidosgal/image-processing-service/internal/lib/tracing"
 	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
 	"github.com/disintegration/imaging"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-func GenerateUniqueFilename(originalFilename string) string {
-	ext := filepath.Ext(originalFilename)
-	baseName := strings.TrimSuffix(originalFilename, ext)
-	timestamp := time.Now().Format("20060102_150405")
-	return fmt.Sprintf("%s_%s%s", baseName, timestamp, ext)
+// ErrTooLarge is returned by Decode for images whose header declares more
+// pixels than allowed.
+var ErrTooLarge = errors.New("image is too large")
+
+// DisplayName is the name an upload is shown under: the last element of
+// the name the client sent, so it never carries a directory.
+func DisplayName(filename string) string {
+	return filepath.Base(filepath.Clean("/" + filename))
 }
 
-func getMimeType(filePath string) string {
-	file, err := os.Open(filePath)
+// StorageName returns a random name to store an upload under, so client
+// file names never choose or collide on a storage path. The extension of
+// filename is kept when variants can be saved in that format; otherwise it
+// follows the format in the image header.
+func StorageName(filename string, data []byte) string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	name := hex.EncodeToString(b)
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	if _, err := imaging.FormatFromExtension(ext); err == nil {
+		return name + ext
+	}
+	if _, format, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		if _, err := imaging.FormatFromExtension(format); err == nil {
+			return name + "." + format
+		}
+	}
+
+	return name
+}
+
+// Decoded is an upload decoded once. It is shared read-only by metadata
+// extraction and every variant generator.
+type Decoded struct {
+	Image image.Image
+	// Config holds the dimensions read from the header.
+	Config image.Config
+	// Format is the name of the decoder, e.g. "jpeg" or "png".
+	Format   string
+	MimeType string
+	Size     int64
+	// Hints direct the crops of variants.
+	Hints smartcrop.Hints
+}
+
+// Decode reads the image header for its format and dimensions, then decodes
+// the pixels from memory. Unsupported data, and images declaring more than
+// maxPixels pixels, fail on the header without any pixel decoding, so a small
+// file can't claim a huge canvas. maxPixels 0 disables the limit. Decoding
+// stops as soon as ctx is done.
+func Decode(ctx context.Context, data []byte, maxPixels int64) (*Decoded, error) {
+	ctx, span := tracing.Start(ctx, "lib.decode", attribute.Int("image.size", len(data)))
+
+	d, err := decode(ctx, data, maxPixels)
+	tracing.End(span, err)
+
+	return d, err
+}
+
+func decode(ctx context.Context, data []byte, maxPixels int64) (*Decoded, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
 	if err != nil {
-		return "application/octet-stream"
+		return nil, fmt.Errorf("failed to read image header: %w", err)
 	}
-	defer file.Close()
 
-	buffer := make([]byte, 512)
-	_, err = file.Read(buffer)
+	if pixels := int64(cfg.Width) * int64(cfg.Height); maxPixels > 0 && pixels > maxPixels {
+		return nil, fmt.Errorf("%w: %dx%d exceeds %d pixels", ErrTooLarge, cfg.Width, cfg.Height, maxPixels)
+	}
+
+	img, _, err := image.Decode(&ctxReader{ctx: ctx, r: bytes.NewReader(data)})
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		// The decoder may have wrapped or replaced the read error.
+		return nil, ctxErr
+	}
 	if err != nil {
-		return "application/octet-stream"
+		return nil, fmt.Errorf("failed to decode image: %w", err)
 	}
 
-	return http.DetectContentType(buffer)
+	return &Decoded{
+		Image:    img,
+		Config:   cfg,
+		Format:   format,
+		MimeType: http.DetectContentType(data),
+		Size:     int64(len(data)),
+	}, nil
 }
 
-func ExtractImageMetadata(filePath string, filename string) (*imagev1.ImageMetadata, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// ExtractImageMetadata describes a decoded upload stored at filePath. The
+// format follows the extension of filePath, which StorageName checked.
+func ExtractImageMetadata(d *Decoded, filePath string, filename string) *imagev1.ImageMetadata {
+	format := strings.TrimPrefix(filepath.Ext(filePath), ".")
+	if format == "" {
+		format = d.Format
+	}
 
-	metadataChan := make(chan *imagev1.ImageMetadata, 1)
-	errChan := make(chan error, 1)
+	return &imagev1.ImageMetadata{
+		Filename:    filename,
+		FileSize:    d.Size,
+		MimeType:    d.MimeType,
+		Width:       int32(d.Config.Width),
+		Height:      int32(d.Config.Height),
+		FilePath:    filePath,
+		ImageFormat: format,
+		Tags:        generateImageTags(d.Config.Width, d.Config.Height),
+	}
+}
 
-	go func() {
-		img, err := imaging.Open(filePath)
-		if err != nil {
-			errChan <- fmt.Errorf("failed to open image: %w", err)
-			return
-		}
+// Variant is a derived image, such as a thumbnail, rendered from the decoded
+// original and stored in Dir under the original's name with Prefix.
+type Variant struct {
+	Name   string
+	Dir    string
+	Prefix string
+	Render func(ctx context.Context, d *Decoded) (image.Image, error)
+}
 
-		fileInfo, err := os.Stat(filePath)
-		if err != nil {
-			errChan <- fmt.Errorf("failed to get file info: %w", err)
-			return
-		}
+// Thumbnail is the 200px wide variant every upload gets.
+func Thumbnail(dir string) Variant {
+	return Variant{
+		Name:   "thumbnail",
+		Dir:    dir,
+		Prefix: "thumb_",
+		Render: func(_ context.Context, d *Decoded) (image.Image, error) {
+			return imaging.Resize(d.Image, 200, 0, imaging.Lanczos), nil
+		},
+	}
+}
 
-		mimeType := getMimeType(filePath)
-
-		metadata := &imagev1.ImageMetadata{
-			Filename:    filename,
-			FileSize:    fileInfo.Size(),
-			MimeType:    mimeType,
-			Width:       int32(img.Bounds().Dx()),
-			Height:      int32(img.Bounds().Dy()),
-			FilePath:    filePath,
-			ImageFormat: filepath.Ext(filename)[1:],
-			Tags:        generateImageTags(img),
-		}
+// GenerateVariant renders v from d and saves it next to the other variants
+// of filePath. It stops as soon as ctx is done and never leaves a partial
+// file behind.
+func GenerateVariant(ctx context.Context, d *Decoded, filePath string, v Variant) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
 
-		metadataChan <- metadata
-	}()
+	renderCtx, span := tracing.Start(ctx, "lib.render", attribute.String("variant", v.Name))
+	variantImg, err := v.Render(renderCtx, d)
+	tracing.End(span, err)
+	if err != nil {
+		return "", err
+	}
 
-	select {
-	case metadata := <-metadataChan:
-		return metadata, nil
-	case err := <-errChan:
-		return nil, err
-	case <-ctx.Done():
-		return nil, ctx.Err()
+	if err := ctx.Err(); err != nil {
+		return "", err
 	}
-}
 
-func GenerateThumbnail(filePath string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	if err := os.MkdirAll(v.Dir, os.ModePerm); err != nil {
+		return "", fmt.Errorf("failed to create %s directory: %w", v.Name, err)
+	}
+	variantPath := filepath.Join(v.Dir, v.Prefix+filepath.Base(filePath))
 
-	thumbnailChan := make(chan string, 1)
-	errChan := make(chan error, 1)
+	_, span = tracing.Start(ctx, "storage.write", attribute.String("file.path", variantPath))
+	err = save(ctx, variantImg, variantPath)
+	tracing.End(span, err)
+	if err != nil {
+		return "", fmt.Errorf("failed to save %s: %w", v.Name, err)
+	}
 
-	go func() {
-		img, err := imaging.Open(filePath)
+	return variantPath, nil
+}
+
+// save encodes img into a temporary file next to path and renames it into
+// place, so a failed or cancelled save never leaves a partial file at path.
+func save(ctx context.Context, img image.Image, path string) (err error) {
+	format, err := imaging.FormatFromFilename(path)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*-"+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	defer func() {
 		if err != nil {
-			errChan <- fmt.Errorf("failed to open image for thumbnail: %w", err)
-			return
+			tmp.Close()
+			os.Remove(tmp.Name())
 		}
+	}()
 
-		thumbnailImg := imaging.Resize(img, 200, 0, imaging.Lanczos)
+	if err = imaging.Encode(&ctxWriter{ctx: ctx, w: tmp}, img, format); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = ctx.Err(); err != nil {
+		return err
+	}
 
-		thumbnailDir := "./uploads/thumbnails"
-		if err := os.MkdirAll(thumbnailDir, os.ModePerm); err != nil {
-			errChan <- fmt.Errorf("failed to create directory thumbnail: %w", err)
-			return
-		}
-		thumbnailFilename := "thumb_" + filepath.Base(filePath)
-		thumbnailPath := filepath.Join(thumbnailDir, thumbnailFilename)
+	return os.Rename(tmp.Name(), path)
+}
 
-		err = imaging.Save(thumbnailImg, thumbnailPath)
-		if err != nil {
-			errChan <- fmt.Errorf("failed to save thumbnail: %w", err)
-			return
-		}
+// ctxReader fails reads once ctx is done, so a decode in progress stops at
+// its next read instead of running to the end.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
 
-		thumbnailChan <- thumbnailPath
-	}()
+func (r *ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
 
-	select {
-	case thumbnailPath := <-thumbnailChan:
-		return thumbnailPath, nil
-	case err := <-errChan:
-		return "", err
-	case <-ctx.Done():
-		return "", ctx.Err()
+	return r.r.Read(p)
+}
+
+type ctxWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+func (w *ctxWriter) Write(p []byte) (int, error) {
+	if err := w.ctx.Err(); err != nil {
+		return 0, err
 	}
+
+	return w.w.Write(p)
 }
 
-func generateImageTags(img image.Image) string {
+func generateImageTags(width, height int) string {
 	var tags string
 
-	bounds := img.Bounds()
-	width, height := bounds.Dx(), bounds.Dy()
-
 	if width > height {
 		tags += " landscape"
 	} else if width < height {
@@ -0,0 +1,200 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/aidosgal/image-processing-service/internal/lib/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testJPEG encodes a width x height gradient, which compresses like a photo
+// rather than a flat color.
+func testJPEG(t testing.TB, width, height int) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: uint8(x ^ y), A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, nil))
+
+	return buf.Bytes()
+}
+
+func TestDecode(t *testing.T) {
+	d, err := Decode(context.Background(), testJPEG(t, 640, 480), 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, "jpeg", d.Format)
+	assert.Equal(t, "image/jpeg", d.MimeType)
+	assert.Equal(t, 640, d.Config.Width)
+	assert.Equal(t, 480, d.Config.Height)
+	assert.Equal(t, image.Rect(0, 0, 640, 480), d.Image.Bounds())
+}
+
+func TestDecode_UnsupportedFormat(t *testing.T) {
+	_, err := Decode(context.Background(), []byte("definitely not an image"), 0)
+	require.ErrorIs(t, err, image.ErrFormat)
+}
+
+// oversizedPNG returns a tiny PNG whose header claims width x height pixels.
+func oversizedPNG(t *testing.T, width, height uint32) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1))))
+	data := buf.Bytes()
+
+	// The IHDR chunk follows the 8 byte signature: length, type, then width
+	// and height, and its CRC over type and data.
+	ihdr := data[8+4 : 8+4+4+13]
+	binary.BigEndian.PutUint32(ihdr[4:], width)
+	binary.BigEndian.PutUint32(ihdr[8:], height)
+	binary.BigEndian.PutUint32(data[8+4+4+13:], crc32.ChecksumIEEE(ihdr))
+
+	return data
+}
+
+func TestDecode_TooLarge(t *testing.T) {
+	bomb := oversizedPNG(t, 100_000, 100_000)
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(bomb))
+	require.NoError(t, err, "the crafted header is valid")
+	require.Equal(t, 100_000, cfg.Width)
+
+	_, err = Decode(context.Background(), bomb, 50_000_000)
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	_, err = Decode(context.Background(), testJPEG(t, 640, 480), 640*480-1)
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	_, err = Decode(context.Background(), testJPEG(t, 640, 480), 640*480)
+	assert.NoError(t, err, "the limit is inclusive")
+}
+
+func TestDecode_DeadlineExceeded(t *testing.T) {
+	data := testJPEG(t, 640, 480)
+	base := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	_, err := Decode(ctx, data, 0)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	testutil.AssertNoLeakedGoroutines(t, base)
+}
+
+func TestExtractImageMetadata(t *testing.T) {
+	data := testJPEG(t, 1920, 1080)
+	d, err := Decode(context.Background(), data, 0)
+	require.NoError(t, err)
+
+	metadata := ExtractImageMetadata(d, "uploads/photo.jpg", "photo.jpg")
+
+	assert.Equal(t, int64(len(data)), metadata.GetFileSize())
+	assert.Equal(t, int32(1920), metadata.GetWidth())
+	assert.Equal(t, int32(1080), metadata.GetHeight())
+	assert.Equal(t, "jpg", metadata.GetImageFormat())
+	assert.Equal(t, " landscape large", metadata.GetTags())
+}
+
+func TestDisplayName(t *testing.T) {
+	assert.Equal(t, "photo.jpg", DisplayName("photo.jpg"))
+	assert.Equal(t, "x.png", DisplayName("../other/x.png"))
+	assert.Equal(t, "x.png", DisplayName("/etc/../x.png"))
+}
+
+func TestStorageName(t *testing.T) {
+	data := testJPEG(t, 16, 16)
+
+	name := StorageName("../other/photo.JPG", data)
+	assert.Regexp(t, `^[0-9a-f]{32}\.jpg$`, name)
+	assert.NotEqual(t, name, StorageName("../other/photo.JPG", data), "names are random")
+
+	assert.Regexp(t, `^[0-9a-f]{32}\.jpeg$`, StorageName("photo.exe", data), "unsupported extensions follow the header")
+	assert.Regexp(t, `^[0-9a-f]{32}$`, StorageName("notes.txt", []byte("not an image")))
+}
+
+func TestGenerateVariant(t *testing.T) {
+	dir := t.TempDir()
+	d, err := Decode(context.Background(), testJPEG(t, 640, 480), 0)
+	require.NoError(t, err)
+
+	path, err := GenerateVariant(context.Background(), d, filepath.Join(dir, "photo.jpg"), Thumbnail(dir))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "thumb_photo.jpg"), path)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "temporary files left behind")
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	cfg, _, err := image.DecodeConfig(f)
+	require.NoError(t, err)
+	assert.Equal(t, 200, cfg.Width)
+	assert.Equal(t, 150, cfg.Height)
+}
+
+func TestGenerateVariant_Cancelled(t *testing.T) {
+	dir := t.TempDir()
+	d, err := Decode(context.Background(), testJPEG(t, 640, 480), 0)
+	require.NoError(t, err)
+
+	base := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = GenerateVariant(ctx, d, filepath.Join(dir, "photo.jpg"), Thumbnail(dir))
+	require.ErrorIs(t, err, context.Canceled)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "partial variant left behind")
+	testutil.AssertNoLeakedGoroutines(t, base)
+}
+
+func TestSave_CancelledRemovesTempFile(t *testing.T) {
+	dir := t.TempDir()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := save(ctx, image.NewRGBA(image.Rect(0, 0, 10, 10)), filepath.Join(dir, "out.jpg"))
+	require.ErrorIs(t, err, context.Canceled)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestCtxReader_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &ctxReader{ctx: ctx, r: bytes.NewReader(make([]byte, 64))}
+
+	buf := make([]byte, 16)
+	_, err := r.Read(buf)
+	require.NoError(t, err)
+
+	cancel()
+	_, err = r.Read(buf)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,124 @@
+package smartcrop
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Aspect is an aspect ratio in lowest terms, such as 16:9.
+type Aspect struct {
+	W, H int
+}
+
+// NewAspect reduces width:height to lowest terms.
+func NewAspect(width, height int) Aspect {
+	d := gcd(width, height)
+	if d == 0 {
+		return Aspect{W: width, H: height}
+	}
+
+	return Aspect{W: width / d, H: height / d}
+}
+
+// ParseAspect parses "width:height", such as "16:9" or "1:1".
+func ParseAspect(s string) (Aspect, error) {
+	w, h, ok := strings.Cut(strings.TrimSpace(s), ":")
+	if !ok {
+		return Aspect{}, errors.New("aspect ratio must be width:height")
+	}
+
+	width, err := strconv.Atoi(strings.TrimSpace(w))
+	if err != nil || width <= 0 {
+		return Aspect{}, errors.New("aspect ratio width must be a positive whole number")
+	}
+	height, err := strconv.Atoi(strings.TrimSpace(h))
+	if err != nil || height <= 0 {
+		return Aspect{}, errors.New("aspect ratio height must be a positive whole number")
+	}
+
+	return NewAspect(width, height), nil
+}
+
+func (a Aspect) String() string {
+	return fmt.Sprintf("%d:%d", a.W, a.H)
+}
+
+// Rect is a rectangle relative to the image, like Point: its top-left corner
+// and its size.
+type Rect struct {
+	X, Y, W, H float64
+}
+
+// Valid reports whether r has an area and lies within the image.
+func (r Rect) Valid() bool {
+	const eps = 1e-9
+
+	return r.W > 0 && r.H > 0 && r.X >= 0 && r.Y >= 0 &&
+		r.X+r.W <= 1+eps && r.Y+r.H <= 1+eps
+}
+
+// Pixels converts r to pixels of bounds, at least one pixel across.
+func (r Rect) Pixels(bounds image.Rectangle) image.Rectangle {
+	bw, bh := float64(bounds.Dx()), float64(bounds.Dy())
+
+	x0 := clamp(int(math.Round(r.X*bw)), 0, bounds.Dx()-1)
+	y0 := clamp(int(math.Round(r.Y*bh)), 0, bounds.Dy()-1)
+	x1 := clamp(int(math.Round((r.X+r.W)*bw)), x0+1, bounds.Dx())
+	y1 := clamp(int(math.Round((r.Y+r.H)*bh)), y0+1, bounds.Dy())
+
+	return image.Rect(x0, y0, x1, y1).Add(bounds.Min)
+}
+
+// Hints are a user's directions for cropping an image.
+type Hints struct {
+	// Focus is the focal point, nil to find it with FocalPoint.
+	Focus *Point
+	// Crops are the rectangles that crops to an aspect ratio use instead of
+	// the window around the focal point.
+	Crops map[Aspect]Rect
+}
+
+// Window returns the window of img that a crop to the aspect ratio
+// width:height keeps. That is the crop hint for the ratio, trimmed to it
+// exactly around its center, or else the largest window around the focal
+// point.
+func (h Hints) Window(img image.Image, width, height int) image.Rectangle {
+	bounds := img.Bounds()
+	if r, ok := h.Crops[NewAspect(width, height)]; ok {
+		return Window(r.Pixels(bounds), Center, width, height)
+	}
+
+	var focus Point
+	if h.Focus != nil {
+		focus = *h.Focus
+	} else {
+		focus = FocalPoint(img)
+	}
+
+	return Window(bounds, focus, width, height)
+}
+
+// Within returns the hints for the crop of bounds to window: the focal
+// point mapped into it. Crop hints describe the uncropped image, so they
+// are dropped.
+func (h Hints) Within(bounds, window image.Rectangle) Hints {
+	if h.Focus == nil {
+		return Hints{}
+	}
+
+	focus := Within(*h.Focus, bounds, window)
+
+	return Hints{Focus: &focus}
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+
+	return a
+}
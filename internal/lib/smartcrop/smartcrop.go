@@ -0,0 +1,250 @@
+// Package smartcrop finds the subject of an image, so crops to another
+// aspect ratio keep it in frame. Regions are scored by edge density,
+// saturation and skin tones; no models are involved.
+package smartcrop
+
+import (
+	"image"
+	"math"
+
+	"github.com/disintegration/imaging"
+)
+
+// Point is a position relative to the image: X and Y run from 0 at the
+// left and top edges to 1 at the right and bottom ones.
+type Point struct {
+	X, Y float64
+}
+
+// Center is the focal point of images without a distinct subject.
+var Center = Point{X: 0.5, Y: 0.5}
+
+// Valid reports whether p lies within the image.
+func (p Point) Valid() bool {
+	return p.X >= 0 && p.X <= 1 && p.Y >= 0 && p.Y <= 1
+}
+
+const (
+	// analysisSize is the longest side of the copy that is scored.
+	analysisSize = 128
+
+	detailWeight     = 0.2
+	saturationWeight = 0.1
+	skinWeight       = 1.8
+
+	// Saturation below saturationThreshold, and skin-likeness below
+	// skinThreshold, scores nothing.
+	saturationThreshold = 0.4
+	skinThreshold       = 0.8
+
+	// baseline is added to every pixel, so flat images fall back to the
+	// center through the center bias.
+	baseline = 0.001
+)
+
+// skin is the normalized RGB direction of a typical skin tone.
+var skin = normalize(0.78, 0.57, 0.44)
+
+// FocalPoint returns the most interesting point of img. It finds the square
+// window, half the shorter side across, with the highest score, slightly
+// preferring windows near the center, and returns the score-weighted center
+// of that window.
+func FocalPoint(img image.Image) Point {
+	small := imaging.Fit(img, analysisSize, analysisSize, imaging.Box)
+	w, h := small.Bounds().Dx(), small.Bounds().Dy()
+	if w < 3 || h < 3 {
+		return Center
+	}
+
+	scores := score(small)
+	sums := summedArea(scores, w, h)
+	side := max(1, min(w, h)/2)
+
+	best, bestX, bestY := -1.0, 0, 0
+	for y := 0; y+side <= h; y++ {
+		for x := 0; x+side <= w; x++ {
+			cx := (float64(x) + float64(side)/2) / float64(w)
+			cy := (float64(y) + float64(side)/2) / float64(h)
+			s := windowSum(sums, w, x, y, side) * centerBias(cx, cy)
+			if s > best {
+				best, bestX, bestY = s, x, y
+			}
+		}
+	}
+
+	return centroid(scores, w, h, image.Rect(bestX, bestY, bestX+side, bestY+side))
+}
+
+// centroid is the score-weighted center of window, in pixel centers.
+func centroid(scores []float64, w, h int, window image.Rectangle) Point {
+	var total, sx, sy float64
+	for y := window.Min.Y; y < window.Max.Y; y++ {
+		for x := window.Min.X; x < window.Max.X; x++ {
+			s := scores[y*w+x]
+			total += s
+			sx += s * (float64(x) + 0.5)
+			sy += s * (float64(y) + 0.5)
+		}
+	}
+	if total == 0 {
+		return Center
+	}
+
+	return Point{X: sx / total / float64(w), Y: sy / total / float64(h)}
+}
+
+// Window returns the largest rectangle within bounds with the aspect ratio
+// width:height, placed so focus is as close to its center as bounds allow.
+func Window(bounds image.Rectangle, focus Point, width, height int) image.Rectangle {
+	bw, bh := bounds.Dx(), bounds.Dy()
+	if width <= 0 || height <= 0 || bw <= 0 || bh <= 0 {
+		return bounds
+	}
+
+	cw, ch := bw, bh
+	if bw*height > bh*width {
+		cw = max(1, int(math.Round(float64(bh)*float64(width)/float64(height))))
+	} else {
+		ch = max(1, int(math.Round(float64(bw)*float64(height)/float64(width))))
+	}
+
+	x := clamp(int(math.Round(focus.X*float64(bw)-float64(cw)/2)), 0, bw-cw)
+	y := clamp(int(math.Round(focus.Y*float64(bh)-float64(ch)/2)), 0, bh-ch)
+
+	return image.Rect(x, y, x+cw, y+ch).Add(bounds.Min)
+}
+
+// Within maps focus, relative to bounds, to the same pixel relative to
+// window, clamped to the window's edges.
+func Within(focus Point, bounds, window image.Rectangle) Point {
+	if window.Dx() <= 0 || window.Dy() <= 0 {
+		return Center
+	}
+
+	x := float64(bounds.Min.X) + focus.X*float64(bounds.Dx())
+	y := float64(bounds.Min.Y) + focus.Y*float64(bounds.Dy())
+
+	return Point{
+		X: math.Min(1, math.Max(0, (x-float64(window.Min.X))/float64(window.Dx()))),
+		Y: math.Min(1, math.Max(0, (y-float64(window.Min.Y))/float64(window.Dy()))),
+	}
+}
+
+// score rates every pixel of img: edges, strong colors and skin tones are
+// interesting, transparent pixels are not.
+func score(img *image.NRGBA) []float64 {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+
+	luma := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			p := img.Pix[y*img.Stride+x*4:]
+			luma[y*w+x] = (0.2126*float64(p[0]) + 0.7152*float64(p[1]) + 0.0722*float64(p[2])) / 255
+		}
+	}
+
+	scores := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			p := img.Pix[y*img.Stride+x*4:]
+			r, g, b := float64(p[0])/255, float64(p[1])/255, float64(p[2])/255
+			l := luma[y*w+x]
+
+			s := detailWeight*detail(luma, w, h, x, y) +
+				saturationWeight*saturation(r, g, b, l) +
+				skinWeight*skinTone(r, g, b, l)
+			scores[y*w+x] = (s + baseline) * float64(p[3]) / 255
+		}
+	}
+
+	return scores
+}
+
+// detail is the Laplacian of the luma at (x, y), a measure of edges.
+func detail(luma []float64, w, h, x, y int) float64 {
+	at := func(x, y int) float64 {
+		return luma[clamp(y, 0, h-1)*w+clamp(x, 0, w-1)]
+	}
+
+	lap := 4*at(x, y) - at(x-1, y) - at(x+1, y) - at(x, y-1) - at(x, y+1)
+
+	return math.Min(1, math.Abs(lap)*4)
+}
+
+// saturation scores strong colors that are neither nearly black nor nearly
+// white.
+func saturation(r, g, b, l float64) float64 {
+	if l < 0.05 || l > 0.9 {
+		return 0
+	}
+
+	mx := math.Max(r, math.Max(g, b))
+	mn := math.Min(r, math.Min(g, b))
+	if mx == 0 {
+		return 0
+	}
+
+	s := (mx - mn) / mx
+	if s < saturationThreshold {
+		return 0
+	}
+
+	return (s - saturationThreshold) / (1 - saturationThreshold)
+}
+
+// skinTone scores colors close in hue to skin, at any brightness that is not
+// too dark.
+func skinTone(r, g, b, l float64) float64 {
+	if l < 0.2 {
+		return 0
+	}
+
+	n := normalize(r, g, b)
+	d := math.Sqrt((n[0]-skin[0])*(n[0]-skin[0]) + (n[1]-skin[1])*(n[1]-skin[1]) + (n[2]-skin[2])*(n[2]-skin[2]))
+	likeness := 1 - d
+	if likeness < skinThreshold {
+		return 0
+	}
+
+	return (likeness - skinThreshold) / (1 - skinThreshold)
+}
+
+// centerBias weighs a window centered at (x, y) from 1 at the center of the
+// image down to 0.75 at its corners.
+func centerBias(x, y float64) float64 {
+	dx, dy := x-0.5, y-0.5
+
+	return 1 - 0.5*(dx*dx+dy*dy)
+}
+
+// summedArea returns the summed-area table of scores: entry (x, y), in a
+// table w+1 wide, is the sum of the scores above and left of (x, y).
+func summedArea(scores []float64, w, h int) []float64 {
+	sums := make([]float64, (w+1)*(h+1))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			sums[(y+1)*(w+1)+x+1] = scores[y*w+x] + sums[y*(w+1)+x+1] + sums[(y+1)*(w+1)+x] - sums[y*(w+1)+x]
+		}
+	}
+
+	return sums
+}
+
+func windowSum(sums []float64, w, x, y, side int) float64 {
+	stride := w + 1
+
+	return sums[(y+side)*stride+x+side] - sums[y*stride+x+side] - sums[(y+side)*stride+x] + sums[y*stride+x]
+}
+
+func normalize(r, g, b float64) [3]float64 {
+	m := math.Sqrt(r*r + g*g + b*b)
+	if m == 0 {
+		return [3]float64{}
+	}
+
+	return [3]float64{r / m, g / m, b / m}
+}
+
+func clamp(v, lo, hi int) int {
+	return max(lo, min(v, hi))
+}
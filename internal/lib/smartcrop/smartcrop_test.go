@@ -0,0 +1,131 @@
+package smartcrop
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+)
+
+// subject draws a detailed, colorful patch centered at (cx, cy) on a flat
+// gray background.
+func subject(w, h, cx, cy, r int, c color.NRGBA) image.Image {
+	img := imaging.New(w, h, color.NRGBA{R: 128, G: 128, B: 128, A: 255})
+	for y := cy - r; y < cy+r; y++ {
+		for x := cx - r; x < cx+r; x++ {
+			v := c
+			if (x/4+y/4)%2 == 0 {
+				v = color.NRGBA{R: c.R / 2, G: c.G / 2, B: c.B / 2, A: 255}
+			}
+			img.SetNRGBA(x, y, v)
+		}
+	}
+
+	return img
+}
+
+func TestFocalPoint_FindsSubject(t *testing.T) {
+	tests := []struct {
+		name   string
+		img    image.Image
+		wantX  float64
+		wantY  float64
+		within float64
+	}{
+		{"colorful on the left", subject(800, 400, 150, 200, 60, color.NRGBA{R: 230, G: 40, B: 40, A: 255}), 150.0 / 800, 0.5, 0.1},
+		{"skin tones bottom right", subject(600, 600, 470, 480, 60, color.NRGBA{R: 224, G: 172, B: 140, A: 255}), 470.0 / 600, 480.0 / 600, 0.12},
+		{"portrait top", subject(300, 900, 150, 150, 60, color.NRGBA{R: 40, G: 90, B: 230, A: 255}), 0.5, 150.0 / 900, 0.1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := FocalPoint(tt.img)
+			assert.InDelta(t, tt.wantX, p.X, tt.within)
+			assert.InDelta(t, tt.wantY, p.Y, tt.within)
+		})
+	}
+}
+
+func TestFocalPoint_FlatImageIsCentered(t *testing.T) {
+	p := FocalPoint(imaging.New(640, 480, color.White))
+
+	assert.InDelta(t, 0.5, p.X, 0.02)
+	assert.InDelta(t, 0.5, p.Y, 0.02)
+}
+
+func TestFocalPoint_Deterministic(t *testing.T) {
+	img := subject(500, 300, 320, 120, 40, color.NRGBA{R: 20, G: 200, B: 60, A: 255})
+
+	assert.Equal(t, FocalPoint(img), FocalPoint(img))
+}
+
+func TestWindow(t *testing.T) {
+	bounds := image.Rect(0, 0, 400, 200)
+
+	tests := []struct {
+		name          string
+		focus         Point
+		width, height int
+		want          image.Rectangle
+	}{
+		{"square centered", Center, 1, 1, image.Rect(100, 0, 300, 200)},
+		{"square on the left edge", Point{X: 0.1, Y: 0.5}, 1, 1, image.Rect(0, 0, 200, 200)},
+		{"square right of center", Point{X: 0.6, Y: 0.5}, 100, 100, image.Rect(140, 0, 340, 200)},
+		{"tall clamped to the bottom", Point{X: 0.5, Y: 1}, 400, 100, image.Rect(0, 100, 400, 200)},
+		{"same aspect", Point{X: 0.9, Y: 0.9}, 2, 1, bounds},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Window(bounds, tt.focus, tt.width, tt.height))
+		})
+	}
+}
+
+func TestWithin(t *testing.T) {
+	bounds := image.Rect(0, 0, 400, 200)
+	window := image.Rect(100, 0, 300, 200)
+
+	assert.Equal(t, Point{X: 0.5, Y: 0.25}, Within(Point{X: 0.5, Y: 0.25}, bounds, window))
+	assert.Equal(t, Point{X: 0, Y: 0.5}, Within(Point{X: 0.1, Y: 0.5}, bounds, window), "clamped to the window")
+}
+
+func TestParseAspect(t *testing.T) {
+	a, err := ParseAspect(" 32:18 ")
+	assert.NoError(t, err)
+	assert.Equal(t, Aspect{W: 16, H: 9}, a)
+	assert.Equal(t, "16:9", a.String())
+
+	for _, s := range []string{"", "16", "16:0", "-1:1", "a:b", "1.5:1"} {
+		_, err := ParseAspect(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestRect_Valid(t *testing.T) {
+	assert.True(t, Rect{X: 0, Y: 0, W: 1, H: 1}.Valid())
+	assert.True(t, Rect{X: 0.7, Y: 0.1, W: 0.3, H: 0.5}.Valid())
+	assert.False(t, Rect{X: 0.8, Y: 0, W: 0.3, H: 0.5}.Valid(), "past the right edge")
+	assert.False(t, Rect{X: 0, Y: 0, W: 0, H: 0.5}.Valid(), "no area")
+	assert.False(t, Rect{X: -0.1, Y: 0, W: 0.5, H: 0.5}.Valid())
+}
+
+func TestHints_Window(t *testing.T) {
+	img := imaging.New(400, 200, color.White)
+	hints := Hints{
+		Focus: &Point{X: 0, Y: 0.5},
+		Crops: map[Aspect]Rect{
+			// 160x100 pixels, trimmed to 100x100 around its center.
+			NewAspect(1, 1): {X: 0.5, Y: 0.25, W: 0.4, H: 0.5},
+		},
+	}
+
+	assert.Equal(t, image.Rect(230, 50, 330, 150), hints.Window(img, 64, 64))
+	assert.Equal(t, image.Rect(0, 0, 100, 200), hints.Window(img, 1, 2), "no hint for 1:2, the focal point decides")
+
+	within := hints.Within(img.Bounds(), image.Rect(230, 50, 330, 150))
+	assert.Nil(t, within.Crops)
+	assert.Equal(t, Point{X: 0, Y: 0.5}, *within.Focus)
+}
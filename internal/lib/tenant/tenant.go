@@ -0,0 +1,44 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"regexp"
+)
+
+// MetadataKey is the gRPC metadata key carrying the tenant (owner) id.
+const MetadataKey = "x-tenant-id"
+
+var (
+	ErrMissingTenant = errors.New("tenant id is missing")
+	ErrInvalidTenant = errors.New("tenant id is invalid")
+)
+
+// idPattern keeps tenant ids safe to use as a storage path segment.
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+type ctxKey struct{}
+
+func Validate(id string) error {
+	if id == "" {
+		return ErrMissingTenant
+	}
+	if !idPattern.MatchString(id) {
+		return ErrInvalidTenant
+	}
+
+	return nil
+}
+
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+func FromContext(ctx context.Context) (string, error) {
+	id, ok := ctx.Value(ctxKey{}).(string)
+	if !ok || id == "" {
+		return "", ErrMissingTenant
+	}
+
+	return id, nil
+}
@@ -0,0 +1,23 @@
+// Package testutil holds helpers shared by the tests of several packages.
+package testutil
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// AssertNoLeakedGoroutines waits briefly for the goroutine count to settle
+// back to base, taken with runtime.NumGoroutine before the code under test
+// ran.
+func AssertNoLeakedGoroutines(t testing.TB, base int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > base && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), base, "goroutines leaked")
+}
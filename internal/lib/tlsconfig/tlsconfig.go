@@ -0,0 +1,166 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aidosgal/image-processing-service/internal/config"
+)
+
+// Reloader serves a TLS configuration built from files on disk and picks up
+// changed certificates, keys and client CAs without a restart. Files are
+// checked at most once per reload interval, during handshakes.
+type Reloader struct {
+	log *slog.Logger
+	cfg config.TLSConfig
+
+	minVersion uint16
+
+	mu        sync.RWMutex
+	current   *tls.Config
+	modTimes  map[string]time.Time
+	lastCheck time.Time
+}
+
+func NewReloader(log *slog.Logger, cfg config.TLSConfig) (*Reloader, error) {
+	const op = "tlsconfig.NewReloader"
+
+	minVersion, err := parseVersion(cfg.MinVersion)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	r := &Reloader{
+		log:        log,
+		cfg:        cfg,
+		minVersion: minVersion,
+	}
+
+	if err := r.load(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return r, nil
+}
+
+// Config returns the base config to hand to the server. Every handshake
+// gets the most recently loaded files through GetConfigForClient.
+func (r *Reloader) Config() *tls.Config {
+	return &tls.Config{
+		MinVersion: r.minVersion,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.maybeReload()
+
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			return r.current, nil
+		},
+	}
+}
+
+func (r *Reloader) maybeReload() {
+	const op = "tlsconfig.maybeReload"
+
+	r.mu.RLock()
+	due := time.Since(r.lastCheck) >= r.cfg.ReloadInterval
+	r.mu.RUnlock()
+	if !due {
+		return
+	}
+
+	r.mu.Lock()
+	r.lastCheck = time.Now()
+	changed := r.changed()
+	r.mu.Unlock()
+	if !changed {
+		return
+	}
+
+	log := r.log.With(slog.String("op", op))
+
+	if err := r.load(); err != nil {
+		// Keep serving the previous certificates until the files are fixed.
+		log.Error("failed to reload tls files", slog.String("error", err.Error()))
+		return
+	}
+
+	log.Info("tls files reloaded")
+}
+
+// changed must be called with r.mu held.
+func (r *Reloader) changed() bool {
+	for path, modTime := range r.modTimes {
+		info, err := os.Stat(path)
+		if err != nil || !info.ModTime().Equal(modTime) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Reloader) load() error {
+	files := []string{r.cfg.CertFile, r.cfg.KeyFile}
+	if r.cfg.ClientCAFile != "" {
+		files = append(files, r.cfg.ClientCAFile)
+	}
+
+	modTimes := make(map[string]time.Time, len(files))
+	for _, path := range files {
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+		modTimes[path] = info.ModTime()
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load key pair: %w", err)
+	}
+
+	tlsCfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   r.minVersion,
+		NextProtos:   []string{"h2"},
+	}
+
+	if r.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client ca: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in %s", r.cfg.ClientCAFile)
+		}
+
+		tlsCfg.ClientCAs = pool
+		tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	r.mu.Lock()
+	r.current = tlsCfg
+	r.modTimes = modTimes
+	r.lastCheck = time.Now()
+	r.mu.Unlock()
+
+	return nil
+}
+
+func parseVersion(v string) (uint16, error) {
+	switch v {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	}
+
+	return 0, fmt.Errorf("unsupported tls min version %q", v)
+}
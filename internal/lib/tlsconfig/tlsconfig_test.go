@@ -0,0 +1,293 @@
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aidosgal/image-processing-service/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// keyPair is a certificate with its key, signed by a CA or by itself.
+type keyPair struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+func newCA(t *testing.T, name string) keyPair {
+	t.Helper()
+
+	return issue(t, name, nil, &x509.Certificate{
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	})
+}
+
+func newLeaf(t *testing.T, name string, ca keyPair, usage x509.ExtKeyUsage) keyPair {
+	t.Helper()
+
+	return issue(t, name, &ca, &x509.Certificate{
+		DNSNames:    []string{"localhost"},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{usage},
+	})
+}
+
+func issue(t *testing.T, name string, parent *keyPair, template *x509.Certificate) keyPair {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+
+	template.SerialNumber = serial
+	template.Subject = pkix.Name{CommonName: name}
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return keyPair{cert: cert, key: key, der: der}
+}
+
+func (p keyPair) certPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: p.der})
+}
+
+func (p keyPair) keyPEM(t *testing.T) []byte {
+	t.Helper()
+
+	der, err := x509.MarshalECPrivateKey(p.key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+}
+
+func (p keyPair) tlsCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+
+	cert, err := tls.X509KeyPair(p.certPEM(), p.keyPEM(t))
+	require.NoError(t, err)
+
+	return cert
+}
+
+// writeFile writes data and moves the modification time forward, so a
+// rewrite within the file system's time granularity is still noticed.
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+
+	modTime := time.Now()
+	if info, err := os.Stat(path); err == nil {
+		modTime = info.ModTime().Add(time.Second)
+	}
+
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func writeKeyPair(t *testing.T, cfg config.TLSConfig, p keyPair) {
+	t.Helper()
+
+	writeFile(t, cfg.CertFile, p.certPEM())
+	writeFile(t, cfg.KeyFile, p.keyPEM(t))
+}
+
+func testConfig(t *testing.T) config.TLSConfig {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	return config.TLSConfig{
+		Enabled:  true,
+		CertFile: filepath.Join(dir, "server.crt"),
+		KeyFile:  filepath.Join(dir, "server.key"),
+	}
+}
+
+func newReloader(t *testing.T, cfg config.TLSConfig) *Reloader {
+	t.Helper()
+
+	r, err := NewReloader(slog.New(slog.NewTextHandler(io.Discard, nil)), cfg)
+	require.NoError(t, err)
+
+	return r
+}
+
+// handshake connects a client to a server using the given configs and
+// returns the server's handshake error and the certificate the client saw.
+func handshake(t *testing.T, server, client *tls.Config) (*x509.Certificate, error) {
+	t.Helper()
+
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		conn := tls.Server(serverConn, server)
+		err := conn.Handshake()
+		if err == nil {
+			// Complete the handshake on the client side under TLS 1.3.
+			_, err = conn.Write([]byte("ok"))
+		}
+		// Unblock the client when the handshake failed.
+		serverConn.Close()
+		serverErr <- err
+	}()
+
+	conn := tls.Client(clientConn, client)
+	var peer *x509.Certificate
+	if err := conn.Handshake(); err == nil {
+		peer = conn.ConnectionState().PeerCertificates[0]
+		_, _ = conn.Read(make([]byte, 2))
+	}
+
+	return peer, <-serverErr
+}
+
+func clientConfig(ca keyPair, certs ...tls.Certificate) *tls.Config {
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	return &tls.Config{
+		RootCAs:      roots,
+		ServerName:   "localhost",
+		Certificates: certs,
+		MinVersion:   tls.VersionTLS12,
+	}
+}
+
+func TestReloader_RotatesCertificate(t *testing.T) {
+	ca := newCA(t, "ca")
+	cfg := testConfig(t)
+	writeKeyPair(t, cfg, newLeaf(t, "first", ca, x509.ExtKeyUsageServerAuth))
+
+	r := newReloader(t, cfg)
+
+	peer, err := handshake(t, r.Config(), clientConfig(ca))
+	require.NoError(t, err)
+	require.NotNil(t, peer)
+	assert.Equal(t, "first", peer.Subject.CommonName)
+
+	writeKeyPair(t, cfg, newLeaf(t, "second", ca, x509.ExtKeyUsageServerAuth))
+
+	peer, err = handshake(t, r.Config(), clientConfig(ca))
+	require.NoError(t, err)
+	require.NotNil(t, peer)
+	assert.Equal(t, "second", peer.Subject.CommonName, "the next handshake uses the rotated certificate")
+}
+
+func TestReloader_WaitsForTheReloadInterval(t *testing.T) {
+	ca := newCA(t, "ca")
+	cfg := testConfig(t)
+	cfg.ReloadInterval = time.Hour
+	writeKeyPair(t, cfg, newLeaf(t, "first", ca, x509.ExtKeyUsageServerAuth))
+
+	r := newReloader(t, cfg)
+	writeKeyPair(t, cfg, newLeaf(t, "second", ca, x509.ExtKeyUsageServerAuth))
+
+	peer, err := handshake(t, r.Config(), clientConfig(ca))
+	require.NoError(t, err)
+	require.NotNil(t, peer)
+	assert.Equal(t, "first", peer.Subject.CommonName, "files are not checked before the interval passed")
+}
+
+func TestReloader_KeepsCertificateWhenReloadFails(t *testing.T) {
+	ca := newCA(t, "ca")
+	cfg := testConfig(t)
+	writeKeyPair(t, cfg, newLeaf(t, "first", ca, x509.ExtKeyUsageServerAuth))
+
+	r := newReloader(t, cfg)
+
+	// A rotation caught halfway: the new certificate with the old key.
+	writeFile(t, cfg.CertFile, newLeaf(t, "second", ca, x509.ExtKeyUsageServerAuth).certPEM())
+
+	peer, err := handshake(t, r.Config(), clientConfig(ca))
+	require.NoError(t, err)
+	require.NotNil(t, peer)
+	assert.Equal(t, "first", peer.Subject.CommonName)
+}
+
+func TestReloader_ClientAuth(t *testing.T) {
+	ca, clientCA, otherCA := newCA(t, "ca"), newCA(t, "client ca"), newCA(t, "other ca")
+
+	cfg := testConfig(t)
+	cfg.ClientCAFile = filepath.Join(t.TempDir(), "client-ca.crt")
+	writeKeyPair(t, cfg, newLeaf(t, "server", ca, x509.ExtKeyUsageServerAuth))
+	writeFile(t, cfg.ClientCAFile, clientCA.certPEM())
+
+	r := newReloader(t, cfg)
+
+	tests := []struct {
+		name    string
+		certs   []tls.Certificate
+		wantErr bool
+	}{
+		{name: "no client certificate", wantErr: true},
+		{name: "certificate of another ca", certs: []tls.Certificate{newLeaf(t, "mallory", otherCA, x509.ExtKeyUsageClientAuth).tlsCertificate(t)}, wantErr: true},
+		{name: "certificate of the client ca", certs: []tls.Certificate{newLeaf(t, "alice", clientCA, x509.ExtKeyUsageClientAuth).tlsCertificate(t)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := handshake(t, r.Config(), clientConfig(ca, tt.certs...))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestNewReloader_Errors(t *testing.T) {
+	ca := newCA(t, "ca")
+	valid := testConfig(t)
+	writeKeyPair(t, valid, newLeaf(t, "server", ca, x509.ExtKeyUsageServerAuth))
+
+	tests := []struct {
+		name string
+		cfg  func(config.TLSConfig) config.TLSConfig
+	}{
+		{name: "unsupported min version", cfg: func(c config.TLSConfig) config.TLSConfig { c.MinVersion = "1.1"; return c }},
+		{name: "missing certificate", cfg: func(c config.TLSConfig) config.TLSConfig { c.CertFile += ".missing"; return c }},
+		{name: "key file holding a certificate", cfg: func(c config.TLSConfig) config.TLSConfig { c.KeyFile = c.CertFile; return c }},
+		{name: "missing client ca", cfg: func(c config.TLSConfig) config.TLSConfig { c.ClientCAFile = c.CertFile + ".missing"; return c }},
+		{name: "client ca without certificates", cfg: func(c config.TLSConfig) config.TLSConfig { c.ClientCAFile = c.KeyFile; return c }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewReloader(slog.New(slog.NewTextHandler(io.Discard, nil)), tt.cfg(valid))
+			assert.Error(t, err)
+		})
+	}
+}
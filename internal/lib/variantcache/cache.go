@@ -0,0 +1,157 @@
+package variantcache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aidosgal/image-processing-service/internal/lib/metrics"
+	"golang.org/x/sync/singleflight"
+)
+
+// Config bounds the tiers. DiskDir empty disables the disk tier.
+type Config struct {
+	MemoryBytes int64
+	DiskDir     string
+	DiskBytes   int64
+	// Ephemeral clears the disk tier on start instead of reusing it. Set it
+	// when image ids do not survive a restart, since a new image could then
+	// be served the variants of an old one with the same id.
+	Ephemeral bool
+}
+
+// Cache keeps derived variants in a memory LRU backed by a larger disk tier.
+// Concurrent requests for the same missing variant compute it only once.
+//
+// A nil *Cache is valid and caches nothing.
+type Cache struct {
+	memory *memoryTier
+	disk   *diskTier
+
+	group singleflight.Group
+
+	// generations counts invalidations per image. A variant computed across
+	// an invalidation of its image is returned but not stored.
+	mu          sync.RWMutex
+	generations map[int64]uint64
+}
+
+func New(cfg Config) (*Cache, error) {
+	const op = "variantcache.New"
+
+	c := &Cache{
+		memory:      newMemoryTier(cfg.MemoryBytes),
+		generations: make(map[int64]uint64),
+	}
+
+	if cfg.DiskDir != "" {
+		disk, err := newDiskTier(cfg.DiskDir, cfg.DiskBytes, cfg.Ephemeral)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		c.disk = disk
+	}
+
+	return c, nil
+}
+
+// Get returns the variant for key, computing and storing it on a miss.
+// compute runs detached from the caller's cancellation, since other callers
+// may be waiting for the same result, so it must bound its own run time.
+// The caller stops waiting as soon as its ctx is done.
+func (c *Cache) Get(ctx context.Context, key Key, compute func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	if c == nil {
+		return compute(ctx)
+	}
+
+	if data, ok := c.memory.get(key); ok {
+		metrics.VariantCacheLookups.WithLabelValues("memory_hit").Inc()
+		return data, nil
+	}
+
+	generation := c.generation(key.ImageID)
+
+	ch := c.group.DoChan(key.String(), func() (any, error) {
+		if c.disk != nil {
+			if data, ok := c.disk.get(key); ok {
+				metrics.VariantCacheLookups.WithLabelValues("disk_hit").Inc()
+				c.store(key, generation, data, false)
+				return data, nil
+			}
+		}
+
+		metrics.VariantCacheLookups.WithLabelValues("miss").Inc()
+
+		data, err := compute(context.WithoutCancel(ctx))
+		if err != nil {
+			return nil, err
+		}
+		c.store(key, generation, data, true)
+
+		return data, nil
+	})
+
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.([]byte), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Invalidate drops every cached variant of the image. It is called when the
+// image is updated or deleted.
+func (c *Cache) Invalidate(imageID int64) error {
+	const op = "variantcache.Invalidate"
+
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generations[imageID]++
+	c.memory.removeImage(imageID)
+
+	if c.disk != nil {
+		if err := c.disk.removeImage(imageID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+func (c *Cache) generation(imageID int64) uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.generations[imageID]
+}
+
+// store adds data to the memory tier and, when toDisk is set, to the disk
+// tier, unless the image was invalidated since generation was read.
+func (c *Cache) store(key Key, generation uint64, data []byte, toDisk bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.generations[key.ImageID] != generation {
+		return
+	}
+
+	if evicted := c.memory.put(key, data); evicted > 0 {
+		metrics.VariantCacheEvictions.WithLabelValues("memory").Add(float64(evicted))
+	}
+
+	if toDisk && c.disk != nil {
+		// A failed disk write only costs a recomputation later.
+		evicted, _ := c.disk.put(key, data)
+		if evicted > 0 {
+			metrics.VariantCacheEvictions.WithLabelValues("disk").Add(float64(evicted))
+		}
+	}
+}
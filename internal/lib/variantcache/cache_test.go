@@ -0,0 +1,248 @@
+package variantcache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// counter returns a compute function that yields data and counts its calls.
+func counter(data string) (func(context.Context) ([]byte, error), *atomic.Int32) {
+	var calls atomic.Int32
+	return func(context.Context) ([]byte, error) {
+		calls.Add(1)
+		return []byte(data), nil
+	}, &calls
+}
+
+func key(imageID int64, width int) Key {
+	return NewKey(imageID, 1, []Op{{Name: "resize", Params: map[string]string{"w": strconv.Itoa(width)}}}, "jpeg")
+}
+
+func TestNormalizeOps(t *testing.T) {
+	a := NormalizeOps([]Op{
+		{Name: "Resize", Params: map[string]string{"W": "200", "h": " 100 "}},
+		{Name: "grayscale"},
+	})
+	b := NormalizeOps([]Op{
+		{Name: "resize ", Params: map[string]string{"h": "100", "w": "200"}},
+		{Name: "grayscale"},
+	})
+
+	assert.Equal(t, "resize(h=100,w=200)|grayscale()", a)
+	assert.Equal(t, a, b)
+
+	reordered := NormalizeOps([]Op{
+		{Name: "grayscale"},
+		{Name: "resize", Params: map[string]string{"h": "100", "w": "200"}},
+	})
+	assert.NotEqual(t, a, reordered, "op order changes the result")
+}
+
+func TestGet_MemoryHit(t *testing.T) {
+	c, err := New(Config{MemoryBytes: 1024})
+	require.NoError(t, err)
+
+	compute, calls := counter("variant")
+	for range 3 {
+		data, err := c.Get(context.Background(), key(1, 200), compute)
+		require.NoError(t, err)
+		assert.Equal(t, "variant", string(data))
+	}
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestGet_MemoryEvictsLeastRecentlyUsed(t *testing.T) {
+	c, err := New(Config{MemoryBytes: 8})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	first, firstCalls := counter("aaaa")
+	second, _ := counter("bbbb")
+	third, _ := counter("cccc")
+
+	_, _ = c.Get(ctx, key(1, 1), first)
+	_, _ = c.Get(ctx, key(1, 2), second)
+	_, _ = c.Get(ctx, key(1, 3), third)
+
+	_, _ = c.Get(ctx, key(1, 1), first)
+	assert.Equal(t, int32(2), firstCalls.Load(), "least recently used entry was not evicted")
+}
+
+func TestGet_DiskTierSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	c, err := New(Config{MemoryBytes: 1024, DiskDir: dir, DiskBytes: 1024})
+	require.NoError(t, err)
+
+	compute, calls := counter("variant")
+	_, err = c.Get(ctx, key(1, 200), compute)
+	require.NoError(t, err)
+
+	restarted, err := New(Config{MemoryBytes: 1024, DiskDir: dir, DiskBytes: 1024})
+	require.NoError(t, err)
+
+	data, err := restarted.Get(ctx, key(1, 200), compute)
+	require.NoError(t, err)
+	assert.Equal(t, "variant", string(data))
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestGet_EphemeralDiskTierStartsEmpty(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	c, err := New(Config{MemoryBytes: 1024, DiskDir: dir, DiskBytes: 1024})
+	require.NoError(t, err)
+
+	compute, calls := counter("variant")
+	_, err = c.Get(ctx, key(1, 200), compute)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "keep"), []byte("not a variant"), 0o644))
+
+	restarted, err := New(Config{MemoryBytes: 1024, DiskDir: dir, DiskBytes: 1024, Ephemeral: true})
+	require.NoError(t, err)
+
+	_, err = restarted.Get(ctx, key(1, 200), compute)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), calls.Load(), "variant of the previous run was reused")
+	assert.FileExists(t, filepath.Join(dir, "keep"))
+}
+
+func TestGet_DiskEvictsToLimit(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	c, err := New(Config{MemoryBytes: 0, DiskDir: dir, DiskBytes: 8})
+	require.NoError(t, err)
+
+	for width := range 3 {
+		compute, _ := counter("abcd")
+		_, err := c.Get(ctx, key(1, width), compute)
+		require.NoError(t, err)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, "1"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.LessOrEqual(t, c.disk.size, int64(8))
+}
+
+func TestGet_SingleFlight(t *testing.T) {
+	c, err := New(Config{MemoryBytes: 1024})
+	require.NoError(t, err)
+
+	release := make(chan struct{})
+	var calls atomic.Int32
+	compute := func(context.Context) ([]byte, error) {
+		calls.Add(1)
+		<-release
+		return []byte("variant"), nil
+	}
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data, err := c.Get(context.Background(), key(1, 200), compute)
+			assert.NoError(t, err)
+			assert.Equal(t, "variant", string(data))
+		}()
+	}
+
+	// Callers that arrive after the flight completes hit the memory tier,
+	// so compute runs once either way.
+	for calls.Load() == 0 {
+		runtime.Gosched()
+	}
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestGet_CallerCancelled(t *testing.T) {
+	c, err := New(Config{MemoryBytes: 1024})
+	require.NoError(t, err)
+
+	release := make(chan struct{})
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = c.Get(ctx, key(1, 200), func(context.Context) ([]byte, error) {
+		<-release
+		return nil, nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestInvalidate(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	c, err := New(Config{MemoryBytes: 1024, DiskDir: dir, DiskBytes: 1024})
+	require.NoError(t, err)
+
+	compute, calls := counter("variant")
+	_, err = c.Get(ctx, key(1, 200), compute)
+	require.NoError(t, err)
+	other, otherCalls := counter("other")
+	_, err = c.Get(ctx, key(2, 200), other)
+	require.NoError(t, err)
+
+	require.NoError(t, c.Invalidate(1))
+
+	_, err = os.Stat(filepath.Join(dir, "1"))
+	assert.True(t, os.IsNotExist(err), "disk entries of the image were not removed")
+
+	_, err = c.Get(ctx, key(1, 200), compute)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), calls.Load())
+
+	_, err = c.Get(ctx, key(2, 200), other)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), otherCalls.Load(), "other images must stay cached")
+}
+
+func TestInvalidate_DuringCompute(t *testing.T) {
+	c, err := New(Config{MemoryBytes: 1024})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = c.Get(ctx, key(1, 200), func(context.Context) ([]byte, error) {
+		require.NoError(t, c.Invalidate(1))
+		return []byte("stale"), nil
+	})
+	require.NoError(t, err)
+
+	compute, calls := counter("fresh")
+	data, err := c.Get(ctx, key(1, 200), compute)
+	require.NoError(t, err)
+	assert.Equal(t, "fresh", string(data))
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestNilCache(t *testing.T) {
+	var c *Cache
+
+	compute, calls := counter("variant")
+	_, err := c.Get(context.Background(), key(1, 200), compute)
+	require.NoError(t, err)
+	_, err = c.Get(context.Background(), key(1, 200), compute)
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(2), calls.Load())
+	assert.NoError(t, c.Invalidate(1))
+}
@@ -0,0 +1,229 @@
+package variantcache
+
+import (
+	"container/list"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const tempPrefix = ".tmp-"
+
+// diskTier stores variants as files under dir/<image id>/<key hash>, bounded
+// by their total size and evicted least recently used first.
+type diskTier struct {
+	mu       sync.Mutex
+	dir      string
+	maxBytes int64
+	size     int64
+	order    *list.List // front is most recently used
+	entries  map[string]*list.Element
+}
+
+type diskEntry struct {
+	path string
+	size int64
+}
+
+// newDiskTier indexes the variants already in dir, oldest first, so the
+// cache survives restarts. With clear, they are removed instead.
+func newDiskTier(dir string, maxBytes int64, clear bool) (*diskTier, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	if clear {
+		if err := clearImages(dir); err != nil {
+			return nil, err
+		}
+	}
+
+	d := &diskTier{
+		dir:      dir,
+		maxBytes: maxBytes,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+
+	type file struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var files []file
+
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		// Left over from a write interrupted by a crash.
+		if strings.HasPrefix(entry.Name(), tempPrefix) {
+			return os.Remove(path)
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, file{path: path, size: info.Size(), modTime: info.ModTime()})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	for _, f := range files {
+		d.entries[f.path] = d.order.PushFront(&diskEntry{path: f.path, size: f.size})
+		d.size += f.size
+	}
+	d.evict()
+
+	return d, nil
+}
+
+// clearImages removes the variants of every image in dir. Anything else in
+// dir is left alone.
+func clearImages(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if _, err := strconv.ParseInt(entry.Name(), 10, 64); err != nil || !entry.IsDir() {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *diskTier) imageDir(imageID int64) string {
+	return filepath.Join(d.dir, strconv.FormatInt(imageID, 10))
+}
+
+func (d *diskTier) path(key Key) string {
+	name := key.hash()
+	if key.Format != "" {
+		name += "." + key.Format
+	}
+
+	return filepath.Join(d.imageDir(key.ImageID), name)
+}
+
+func (d *diskTier) get(key Key) ([]byte, bool) {
+	path := d.path(key)
+
+	d.mu.Lock()
+	elem, ok := d.entries[path]
+	if ok {
+		d.order.MoveToFront(elem)
+	}
+	d.mu.Unlock()
+
+	if !ok {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		d.mu.Lock()
+		if elem, ok := d.entries[path]; ok {
+			d.removeElement(elem)
+		}
+		d.mu.Unlock()
+		return nil, false
+	}
+
+	return data, true
+}
+
+// put writes data through a temporary file, so readers never see a partial
+// variant, and returns how many entries were evicted to make room.
+func (d *diskTier) put(key Key, data []byte) (int, error) {
+	size := int64(len(data))
+	if size > d.maxBytes {
+		return 0, nil
+	}
+
+	path := d.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return 0, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), tempPrefix+"*")
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return 0, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return 0, fmt.Errorf("failed to store variant: %w", err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if elem, ok := d.entries[path]; ok {
+		d.size -= elem.Value.(*diskEntry).size
+		d.order.Remove(elem)
+	}
+	d.entries[path] = d.order.PushFront(&diskEntry{path: path, size: size})
+	d.size += size
+
+	return d.evict(), nil
+}
+
+// evict removes least recently used entries until the tier fits its limit.
+// d.mu must be held.
+func (d *diskTier) evict() int {
+	evicted := 0
+	for d.size > d.maxBytes && d.order.Len() > 0 {
+		elem := d.order.Back()
+		os.Remove(elem.Value.(*diskEntry).path)
+		d.removeElement(elem)
+		evicted++
+	}
+
+	return evicted
+}
+
+func (d *diskTier) removeImage(imageID int64) error {
+	dir := d.imageDir(imageID)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for path, elem := range d.entries {
+		if filepath.Dir(path) == dir {
+			d.removeElement(elem)
+		}
+	}
+
+	return os.RemoveAll(dir)
+}
+
+// removeElement drops an entry from the index. d.mu must be held.
+func (d *diskTier) removeElement(elem *list.Element) {
+	entry := d.order.Remove(elem).(*diskEntry)
+	delete(d.entries, entry.path)
+	d.size -= entry.size
+}
@@ -0,0 +1,76 @@
+package variantcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Op is one step of a transform, such as {Name: "resize", Params: {"w": "200"}}.
+type Op struct {
+	Name   string
+	Params map[string]string
+}
+
+// Key identifies a derived variant. Revision changes whenever the image is
+// updated, so stale variants are never served for a new revision.
+type Key struct {
+	ImageID  int64
+	Revision int64
+	// Ops is the normalized operation list, see NormalizeOps.
+	Ops    string
+	Format string
+}
+
+func NewKey(imageID, revision int64, ops []Op, format string) Key {
+	return Key{
+		ImageID:  imageID,
+		Revision: revision,
+		Ops:      NormalizeOps(ops),
+		Format:   strings.ToLower(strings.TrimSpace(format)),
+	}
+}
+
+// NormalizeOps renders ops in a canonical form: names and parameter keys
+// lowercased and trimmed, parameters sorted by key. The order of the ops
+// themselves is kept, since it changes the result.
+func NormalizeOps(ops []Op) string {
+	var b strings.Builder
+	for i, op := range ops {
+		if i > 0 {
+			b.WriteByte('|')
+		}
+		b.WriteString(strings.ToLower(strings.TrimSpace(op.Name)))
+
+		params := make(map[string]string, len(op.Params))
+		for k, v := range op.Params {
+			params[strings.ToLower(strings.TrimSpace(k))] = strings.TrimSpace(v)
+		}
+
+		b.WriteByte('(')
+		for j, k := range slices.Sorted(maps.Keys(params)) {
+			if j > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(k)
+			b.WriteByte('=')
+			b.WriteString(params[k])
+		}
+		b.WriteByte(')')
+	}
+
+	return b.String()
+}
+
+func (k Key) String() string {
+	return fmt.Sprintf("%d@%d:%s:%s", k.ImageID, k.Revision, k.Ops, k.Format)
+}
+
+// hash names the variant on disk.
+func (k Key) hash() string {
+	sum := sha256.Sum256([]byte(k.String()))
+	return hex.EncodeToString(sum[:])
+}
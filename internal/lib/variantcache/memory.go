@@ -0,0 +1,85 @@
+package variantcache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// memoryTier is an LRU of variant bytes bounded by their total size.
+type memoryTier struct {
+	mu       sync.Mutex
+	maxBytes int64
+	size     int64
+	order    *list.List // front is most recently used
+	entries  map[Key]*list.Element
+}
+
+type memoryEntry struct {
+	key  Key
+	data []byte
+}
+
+func newMemoryTier(maxBytes int64) *memoryTier {
+	return &memoryTier{
+		maxBytes: maxBytes,
+		order:    list.New(),
+		entries:  make(map[Key]*list.Element),
+	}
+}
+
+func (m *memoryTier) get(key Key) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	elem, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	m.order.MoveToFront(elem)
+
+	return elem.Value.(*memoryEntry).data, true
+}
+
+// put stores data and returns how many entries were evicted to make room.
+// Entries larger than the whole tier are not stored.
+func (m *memoryTier) put(key Key, data []byte) int {
+	size := int64(len(data))
+	if size > m.maxBytes {
+		return 0
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if elem, ok := m.entries[key]; ok {
+		m.removeElement(elem)
+	}
+
+	evicted := 0
+	for m.size+size > m.maxBytes {
+		m.removeElement(m.order.Back())
+		evicted++
+	}
+
+	m.entries[key] = m.order.PushFront(&memoryEntry{key: key, data: data})
+	m.size += size
+
+	return evicted
+}
+
+func (m *memoryTier) removeImage(imageID int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, elem := range m.entries {
+		if key.ImageID == imageID {
+			m.removeElement(elem)
+		}
+	}
+}
+
+func (m *memoryTier) removeElement(elem *list.Element) {
+	entry := m.order.Remove(elem).(*memoryEntry)
+	delete(m.entries, entry.key)
+	m.size -= int64(len(entry.data))
+}
@@ -0,0 +1,251 @@
+// Package watermark overlays a logo or a line of text on images according
+// to named profiles from config.
+package watermark
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/aidosgal/image-processing-service/internal/config"
+	"github.com/aidosgal/image-processing-service/internal/lib/palette"
+	"github.com/disintegration/imaging"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Anchor is where a single watermark is placed.
+type Anchor string
+
+const (
+	TopLeft     Anchor = "top_left"
+	Top         Anchor = "top"
+	TopRight    Anchor = "top_right"
+	Left        Anchor = "left"
+	Center      Anchor = "center"
+	Right       Anchor = "right"
+	BottomLeft  Anchor = "bottom_left"
+	Bottom      Anchor = "bottom"
+	BottomRight Anchor = "bottom_right"
+)
+
+var anchors = []Anchor{TopLeft, Top, TopRight, Left, Center, Right, BottomLeft, Bottom, BottomRight}
+
+const (
+	defaultAnchor  = BottomRight
+	defaultMargin  = 0.02
+	defaultOpacity = 0.5
+	defaultScale   = 0.2
+)
+
+// Profile is a validated watermark ready to be applied.
+type Profile struct {
+	Name string
+	// OnUpload renders a watermarked variant of every upload.
+	OnUpload bool
+
+	mark    image.Image
+	anchor  Anchor
+	margin  float64
+	opacity float64
+	scale   float64
+	tile    bool
+	version string
+}
+
+// Load validates the configured profiles and loads their logos.
+func Load(cfg map[string]config.WatermarkProfile) (map[string]*Profile, error) {
+	const op = "watermark.Load"
+
+	profiles := make(map[string]*Profile, len(cfg))
+	for name, c := range cfg {
+		p, err := newProfile(name, c)
+		if err != nil {
+			return nil, fmt.Errorf("%s: profile %q: %w", op, name, err)
+		}
+		profiles[name] = p
+	}
+
+	return profiles, nil
+}
+
+func newProfile(name string, c config.WatermarkProfile) (*Profile, error) {
+	p := &Profile{
+		Name:     name,
+		OnUpload: c.OnUpload,
+		anchor:   Anchor(c.Anchor),
+		margin:   c.Margin,
+		opacity:  c.Opacity,
+		scale:    c.Scale,
+		tile:     c.Tile,
+	}
+	if p.anchor == "" {
+		p.anchor = defaultAnchor
+	}
+	if p.opacity == 0 {
+		p.opacity = defaultOpacity
+	}
+	if p.scale == 0 {
+		p.scale = defaultScale
+	}
+	if c.Margin == 0 {
+		p.margin = defaultMargin
+	}
+
+	switch {
+	case !slices.Contains(anchors, p.anchor):
+		return nil, fmt.Errorf("unknown anchor %q", c.Anchor)
+	case p.margin < 0 || p.margin >= 0.5:
+		return nil, errors.New("margin must be at least 0 and below 0.5")
+	case p.opacity < 0 || p.opacity > 1:
+		return nil, errors.New("opacity must be between 0 and 1")
+	case p.scale < 0 || p.scale > 1:
+		return nil, errors.New("scale must be between 0 and 1")
+	case (c.Image == "") == (c.Text == ""):
+		return nil, errors.New("exactly one of image and text must be set")
+	}
+
+	var source []byte
+	if c.Image != "" {
+		data, err := os.ReadFile(c.Image)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read logo: %w", err)
+		}
+		logo, err := imaging.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode logo: %w", err)
+		}
+		p.mark = logo
+		// The logo file may be replaced between restarts.
+		source = data
+	} else {
+		textColor := palette.Color{R: 255, G: 255, B: 255}
+		if c.Color != "" {
+			parsed, err := palette.ParseHex(c.Color)
+			if err != nil {
+				return nil, fmt.Errorf("text color: %w", err)
+			}
+			textColor = parsed
+		}
+		p.mark = renderText(c.Text, textColor)
+		source = []byte(c.Text + textColor.Hex())
+	}
+
+	sum := sha256.New()
+	fmt.Fprintf(sum, "%s|%g|%g|%g|%t|", p.anchor, p.margin, p.opacity, p.scale, p.tile)
+	sum.Write(source)
+	p.version = hex.EncodeToString(sum.Sum(nil))[:12]
+
+	return p, nil
+}
+
+// Version changes whenever the profile would render differently, so cached
+// watermarked variants can be keyed by it.
+func (p *Profile) Version() string {
+	return p.version
+}
+
+// Apply returns img with the watermark drawn over it. The watermark is
+// scaled to Scale of the image width and kept Margin of the shorter side
+// away from the edges. A tiled profile repeats it over the whole image,
+// Margin apart.
+func (p *Profile) Apply(img image.Image) image.Image {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+
+	mark := p.scaled(dst.Bounds().Size())
+	size := mark.Bounds().Size()
+	margin := int(math.Round(p.margin * float64(min(b.Dx(), b.Dy()))))
+	mask := image.NewUniform(color.Alpha{A: uint8(math.Round(p.opacity * 255))})
+
+	overlay := func(at image.Point) {
+		draw.DrawMask(dst, image.Rectangle{Min: at, Max: at.Add(size)}, mark, image.Point{}, mask, image.Point{}, draw.Over)
+	}
+
+	if p.tile {
+		step := size.Add(image.Pt(max(margin, 1), max(margin, 1)))
+		for y := margin; y < b.Dy(); y += step.Y {
+			for x := margin; x < b.Dx(); x += step.X {
+				overlay(image.Pt(x, y))
+			}
+		}
+		return dst
+	}
+
+	overlay(p.position(dst.Bounds().Size(), size, margin))
+
+	return dst
+}
+
+// scaled resizes the mark to Scale of the image, never larger than the
+// image itself.
+func (p *Profile) scaled(img image.Point) *image.NRGBA {
+	mb := p.mark.Bounds()
+	w := max(int(math.Round(p.scale*float64(img.X))), 1)
+	h := max(int(math.Round(float64(w)*float64(mb.Dy())/float64(mb.Dx()))), 1)
+	if h > img.Y {
+		h = img.Y
+		w = max(int(math.Round(float64(h)*float64(mb.Dx())/float64(mb.Dy()))), 1)
+	}
+
+	return imaging.Resize(p.mark, w, h, imaging.Linear)
+}
+
+// position is the top-left corner of a mark of the given size at the anchor.
+func (p *Profile) position(img, mark image.Point, margin int) image.Point {
+	x := (img.X - mark.X) / 2
+	y := (img.Y - mark.Y) / 2
+
+	if strings.HasSuffix(string(p.anchor), "left") {
+		x = margin
+	} else if strings.HasSuffix(string(p.anchor), "right") {
+		x = img.X - mark.X - margin
+	}
+	if strings.HasPrefix(string(p.anchor), "top") {
+		y = margin
+	} else if strings.HasPrefix(string(p.anchor), "bottom") {
+		y = img.Y - mark.Y - margin
+	}
+
+	return image.Pt(x, y)
+}
+
+// renderText draws text with a dark shadow, so it stays readable on light
+// images.
+func renderText(text string, c palette.Color) *image.NRGBA {
+	face := basicfont.Face7x13
+	width := font.MeasureString(face, text).Ceil()
+	height := face.Metrics().Height.Ceil()
+
+	img := image.NewNRGBA(image.Rect(0, 0, width+1, height+1))
+	baseline := face.Metrics().Ascent
+
+	for _, layer := range []struct {
+		offset int
+		color  color.Color
+	}{
+		{1, color.NRGBA{A: 160}},
+		{0, color.NRGBA{R: c.R, G: c.G, B: c.B, A: 255}},
+	} {
+		d := font.Drawer{
+			Dst:  img,
+			Src:  image.NewUniform(layer.color),
+			Face: face,
+			Dot:  fixed.Point26_6{X: fixed.I(layer.offset), Y: baseline + fixed.I(layer.offset)},
+		}
+		d.DrawString(text)
+	}
+
+	return img
+}
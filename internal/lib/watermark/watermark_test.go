@@ -0,0 +1,136 @@
+package watermark
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aidosgal/image-processing-service/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gray(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = 100, 100, 100, 255
+	}
+
+	return img
+}
+
+// changed reports whether any pixel in r differs from the gray background.
+func changed(img image.Image, r image.Rectangle) bool {
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if color.NRGBAModel.Convert(img.At(x, y)) != (color.NRGBA{R: 100, G: 100, B: 100, A: 255}) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func load(t *testing.T, c config.WatermarkProfile) *Profile {
+	t.Helper()
+
+	profiles, err := Load(map[string]config.WatermarkProfile{"test": c})
+	require.NoError(t, err)
+
+	return profiles["test"]
+}
+
+func TestApply_Anchor(t *testing.T) {
+	img := gray(400, 300)
+
+	out := load(t, config.WatermarkProfile{Text: "PROOF", Anchor: "bottom_right", Opacity: 1}).Apply(img)
+
+	assert.Equal(t, img.Bounds(), out.Bounds())
+	assert.True(t, changed(out, image.Rect(300, 250, 400, 300)), "watermark in the bottom right corner")
+	assert.False(t, changed(out, image.Rect(0, 0, 300, 200)), "rest of the image untouched")
+	assert.False(t, changed(out, image.Rect(0, 294, 400, 300)), "margin kept")
+	assert.False(t, changed(img, img.Bounds()), "input not modified")
+}
+
+func TestApply_Tile(t *testing.T) {
+	img := gray(400, 300)
+
+	out := load(t, config.WatermarkProfile{Text: "PROOF", Tile: true, Scale: 0.1}).Apply(img)
+
+	for _, r := range []image.Rectangle{
+		image.Rect(0, 0, 100, 100),
+		image.Rect(300, 0, 400, 100),
+		image.Rect(0, 200, 100, 300),
+		image.Rect(300, 200, 400, 300),
+	} {
+		assert.True(t, changed(out, r), "tile in %v", r)
+	}
+}
+
+// writeLogo saves a w x h logo of a single color and returns its path.
+func writeLogo(t *testing.T, w, h int, c color.NRGBA) string {
+	t.Helper()
+
+	logo := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < len(logo.Pix); i += 4 {
+		logo.Pix[i], logo.Pix[i+1], logo.Pix[i+2], logo.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+
+	path := filepath.Join(t.TempDir(), "logo.png")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, png.Encode(f, logo))
+	require.NoError(t, f.Close())
+
+	return path
+}
+
+func TestApply_Logo(t *testing.T) {
+	path := writeLogo(t, 20, 10, color.NRGBA{R: 255, A: 255})
+
+	p := load(t, config.WatermarkProfile{Image: path, Anchor: "top_left", Margin: 0.1, Scale: 0.5, Opacity: 1})
+	out := p.Apply(gray(200, 100))
+
+	// 10px margin, then a 100x50 red logo.
+	assert.Equal(t, color.RGBA{R: 255, A: 255}, out.At(50, 30))
+	assert.False(t, changed(out, image.Rect(0, 0, 200, 10)))
+	assert.False(t, changed(out, image.Rect(111, 0, 200, 100)))
+}
+
+func TestApply_Opacity(t *testing.T) {
+	path := writeLogo(t, 10, 10, color.NRGBA{R: 255, G: 255, B: 255, A: 255})
+
+	out := load(t, config.WatermarkProfile{Image: path, Anchor: "center", Scale: 0.5, Opacity: 0.5}).Apply(gray(100, 100))
+
+	r, _, _, _ := out.At(50, 50).RGBA()
+	assert.InDelta(t, 178, r>>8, 2, "half way between gray and white")
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	for name, c := range map[string]config.WatermarkProfile{
+		"no mark":      {},
+		"both marks":   {Text: "a", Image: "logo.png"},
+		"anchor":       {Text: "a", Anchor: "middle"},
+		"opacity":      {Text: "a", Opacity: 1.5},
+		"scale":        {Text: "a", Scale: -0.1},
+		"margin":       {Text: "a", Margin: 0.5},
+		"color":        {Text: "a", Color: "red"},
+		"missing logo": {Image: filepath.Join(t.TempDir(), "missing.png")},
+	} {
+		_, err := Load(map[string]config.WatermarkProfile{name: c})
+		assert.Error(t, err, name)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	a := load(t, config.WatermarkProfile{Text: "PROOF"})
+	b := load(t, config.WatermarkProfile{Text: "PROOF"})
+	c := load(t, config.WatermarkProfile{Text: "PROOF", Opacity: 0.9})
+
+	assert.Equal(t, a.Version(), b.Version())
+	assert.NotEqual(t, a.Version(), c.Version())
+}
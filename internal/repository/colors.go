@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
+)
+
+// ColorColumns is the color summary of an image as the SQL repositories
+// store it. The palette is a JSON array; every column is NULL for images
+// stored before colors were extracted.
+type ColorColumns struct {
+	Palette      sql.NullString
+	AverageColor sql.NullString
+	Brightness   sql.NullFloat64
+	Colorfulness sql.NullFloat64
+	Grayscale    sql.NullBool
+}
+
+type paletteColor struct {
+	Color    string  `json:"color"`
+	Fraction float64 `json:"fraction"`
+}
+
+// NewColorColumns converts a summary to its columns, all NULL for nil.
+func NewColorColumns(c *imagev1.ColorSummary) (ColorColumns, error) {
+	if c == nil {
+		return ColorColumns{}, nil
+	}
+
+	palette := make([]paletteColor, 0, len(c.GetPalette()))
+	for _, p := range c.GetPalette() {
+		palette = append(palette, paletteColor{Color: p.GetColor(), Fraction: p.GetFraction()})
+	}
+
+	data, err := json.Marshal(palette)
+	if err != nil {
+		return ColorColumns{}, fmt.Errorf("failed to encode palette: %w", err)
+	}
+
+	return ColorColumns{
+		Palette:      sql.NullString{String: string(data), Valid: true},
+		AverageColor: sql.NullString{String: c.GetAverageColor(), Valid: true},
+		Brightness:   sql.NullFloat64{Float64: c.GetBrightness(), Valid: true},
+		Colorfulness: sql.NullFloat64{Float64: c.GetColorfulness(), Valid: true},
+		Grayscale:    sql.NullBool{Bool: c.GetGrayscale(), Valid: true},
+	}, nil
+}
+
+// Dest returns scan destinations for the columns palette, average_color,
+// brightness, colorfulness and grayscale, in that order.
+func (c *ColorColumns) Dest() []any {
+	return []any{&c.Palette, &c.AverageColor, &c.Brightness, &c.Colorfulness, &c.Grayscale}
+}
+
+// Summary converts the scanned columns back, nil when they are NULL.
+func (c *ColorColumns) Summary() (*imagev1.ColorSummary, error) {
+	if !c.Palette.Valid {
+		return nil, nil
+	}
+
+	var palette []paletteColor
+	if err := json.Unmarshal([]byte(c.Palette.String), &palette); err != nil {
+		return nil, fmt.Errorf("failed to decode palette: %w", err)
+	}
+
+	summary := &imagev1.ColorSummary{
+		Palette:      make([]*imagev1.PaletteColor, 0, len(palette)),
+		AverageColor: c.AverageColor.String,
+		Brightness:   c.Brightness.Float64,
+		Colorfulness: c.Colorfulness.Float64,
+		Grayscale:    c.Grayscale.Bool,
+	}
+	for _, p := range palette {
+		summary.Palette = append(summary.Palette, &imagev1.PaletteColor{Color: p.Color, Fraction: p.Fraction})
+	}
+
+	return summary, nil
+}
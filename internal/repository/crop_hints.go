@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
+)
+
+// Cropping is what decides how an image is cropped, stored together with
+// the size of the variants rendered with it.
+type Cropping struct {
+	FocalPoint   *imagev1.FocalPoint
+	CropHints    []*imagev1.CropHint
+	VariantsSize int64
+}
+
+// CropHintColumns are the crop hints of an image as the SQL repositories
+// store them: a JSON array, NULL when there are none.
+type CropHintColumns struct {
+	Hints sql.NullString
+}
+
+type cropHint struct {
+	AspectRatio string  `json:"aspect_ratio"`
+	X           float64 `json:"x"`
+	Y           float64 `json:"y"`
+	Width       float64 `json:"width"`
+	Height      float64 `json:"height"`
+}
+
+// NewCropHintColumns converts crop hints to their columns, NULL for none.
+func NewCropHintColumns(hints []*imagev1.CropHint) (CropHintColumns, error) {
+	if len(hints) == 0 {
+		return CropHintColumns{}, nil
+	}
+
+	stored := make([]cropHint, 0, len(hints))
+	for _, h := range hints {
+		stored = append(stored, cropHint{
+			AspectRatio: h.GetAspectRatio(),
+			X:           h.GetX(),
+			Y:           h.GetY(),
+			Width:       h.GetWidth(),
+			Height:      h.GetHeight(),
+		})
+	}
+
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return CropHintColumns{}, fmt.Errorf("failed to encode crop hints: %w", err)
+	}
+
+	return CropHintColumns{Hints: sql.NullString{String: string(data), Valid: true}}, nil
+}
+
+// Dest returns scan destinations for the column crop_hints.
+func (c *CropHintColumns) Dest() []any {
+	return []any{&c.Hints}
+}
+
+// CropHints converts the scanned columns back, nil when they are NULL.
+func (c *CropHintColumns) CropHints() ([]*imagev1.CropHint, error) {
+	if !c.Hints.Valid {
+		return nil, nil
+	}
+
+	var stored []cropHint
+	if err := json.Unmarshal([]byte(c.Hints.String), &stored); err != nil {
+		return nil, fmt.Errorf("failed to decode crop hints: %w", err)
+	}
+
+	hints := make([]*imagev1.CropHint, 0, len(stored))
+	for _, h := range stored {
+		hints = append(hints, &imagev1.CropHint{
+			AspectRatio: h.AspectRatio,
+			X:           h.X,
+			Y:           h.Y,
+			Width:       h.Width,
+			Height:      h.Height,
+		})
+	}
+
+	return hints, nil
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"database/sql"
+
+	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
+)
+
+// FocalColumns is the focal point of an image as the SQL repositories store
+// it, NULL for images stored before focal points were found.
+type FocalColumns struct {
+	X      sql.NullFloat64
+	Y      sql.NullFloat64
+	Manual bool
+}
+
+// NewFocalColumns converts a focal point to its columns, NULL for nil.
+func NewFocalColumns(p *imagev1.FocalPoint) FocalColumns {
+	if p == nil {
+		return FocalColumns{}
+	}
+
+	return FocalColumns{
+		X:      sql.NullFloat64{Float64: p.GetX(), Valid: true},
+		Y:      sql.NullFloat64{Float64: p.GetY(), Valid: true},
+		Manual: p.GetManual(),
+	}
+}
+
+// Dest returns scan destinations for the columns focal_x, focal_y and
+// focal_manual, in that order.
+func (f *FocalColumns) Dest() []any {
+	return []any{&f.X, &f.Y, &f.Manual}
+}
+
+// FocalPoint converts the scanned columns back, nil when they are NULL.
+func (f *FocalColumns) FocalPoint() *imagev1.FocalPoint {
+	if !f.X.Valid || !f.Y.Valid {
+		return nil
+	}
+
+	return &imagev1.FocalPoint{X: f.X.Float64, Y: f.Y.Float64, Manual: f.Manual}
+}
@@ -0,0 +1,509 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math/bits"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aidosgal/image-processing-service/internal/domain/model"
+	"github.com/aidosgal/image-processing-service/internal/lib/permission"
+	"github.com/aidosgal/image-processing-service/internal/repository"
+	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
+	"google.golang.org/protobuf/proto"
+)
+
+// Repository keeps everything in process memory. It is safe for concurrent
+// use and loses its data on restart, so it is meant for tests and demos.
+type Repository struct {
+	mu sync.RWMutex
+
+	nextImageID int64
+	nextAlbumID int64
+
+	images      map[int64]*imagev1.ImageMetadata
+	albums      map[int64]string
+	albumImages map[int64]map[int64]struct{}
+	imageShares map[share]permission.Level
+	albumShares map[share]permission.Level
+	usage       map[string]model.Usage
+	quotas      map[string]model.Quota
+}
+
+// share is a grant on an image or album, identified by its id.
+type share struct {
+	id      int64
+	grantee permission.Grantee
+}
+
+func NewRepository() *Repository {
+	return &Repository{
+		images:      make(map[int64]*imagev1.ImageMetadata),
+		albums:      make(map[int64]string),
+		albumImages: make(map[int64]map[int64]struct{}),
+		imageShares: make(map[share]permission.Level),
+		albumShares: make(map[share]permission.Level),
+		usage:       make(map[string]model.Usage),
+		quotas:      make(map[string]model.Quota),
+	}
+}
+
+// Ping always succeeds.
+func (r *Repository) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Close is a no-op; the data stays readable.
+func (r *Repository) Close() error {
+	return nil
+}
+
+func (r *Repository) StoreImage(ctx context.Context, ownerID string, metadata *imagev1.ImageMetadata, quota model.Quota) (int64, error) {
+	const op = "memory.StoreImage"
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	usage := r.usageOf(ownerID)
+
+	storedBytes := metadata.GetFileSize() + metadata.GetVariantsSize()
+	if err := quota.Check(usage, metadata.GetFileSize(), storedBytes); err != nil {
+		return -1, fmt.Errorf("%s: %w", op, err)
+	}
+
+	r.nextImageID++
+	img := stored(metadata)
+	img.ImageId = r.nextImageID
+	img.OwnerId = ownerID
+	img.Revision = 1
+	r.images[img.ImageId] = img
+
+	usage.TotalBytes += storedBytes
+	usage.ImageCount++
+	usage.MonthlyUploadBytes += metadata.GetFileSize()
+	r.usage[ownerID] = usage
+
+	return img.ImageId, nil
+}
+
+func (r *Repository) GetAllImages(ctx context.Context, ownerID string) ([]*imagev1.ImageMetadata, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var images []*imagev1.ImageMetadata
+	for _, img := range r.images {
+		if img.GetOwnerId() == ownerID {
+			images = append(images, stored(img))
+		}
+	}
+
+	// Ids grow with every upload, so this is newest first.
+	sort.Slice(images, func(a, b int) bool {
+		return images[a].GetImageId() > images[b].GetImageId()
+	})
+
+	return images, nil
+}
+
+func (r *Repository) GetImageById(ctx context.Context, ownerID string, imageID int64) (*imagev1.ImageMetadata, error) {
+	const op = "memory.GetImageById"
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	img, ok := r.images[imageID]
+	if !ok || img.GetOwnerId() != ownerID {
+		return nil, fmt.Errorf("%s: %w", op, repository.ErrImageNotFound)
+	}
+
+	return stored(img), nil
+}
+
+// UpdateCropping replaces the focal point, crop hints and variants size of
+// the image and bumps its revision, returning the new one. The owner's usage
+// follows the change in variants size.
+func (r *Repository) UpdateCropping(ctx context.Context, ownerID string, imageID int64, cropping repository.Cropping) (int64, error) {
+	const op = "memory.UpdateCropping"
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	img, ok := r.images[imageID]
+	if !ok || img.GetOwnerId() != ownerID {
+		return 0, fmt.Errorf("%s: %w", op, repository.ErrImageNotFound)
+	}
+
+	if usage, ok := r.usage[ownerID]; ok {
+		usage.TotalBytes = max(usage.TotalBytes+cropping.VariantsSize-img.GetVariantsSize(), 0)
+		r.usage[ownerID] = usage
+	}
+
+	img.FocalPoint = focalPoint(cropping.FocalPoint)
+	img.CropHints = cropHints(cropping.CropHints)
+	img.VariantsSize = cropping.VariantsSize
+	img.Revision++
+
+	return img.Revision, nil
+}
+
+func (r *Repository) DeleteImageById(ctx context.Context, ownerID string, imageID int64) (bool, error) {
+	const op = "memory.DeleteImageById"
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	img, ok := r.images[imageID]
+	if !ok || img.GetOwnerId() != ownerID {
+		return false, fmt.Errorf("%s: %w", op, repository.ErrImageNotFound)
+	}
+
+	r.deleteImage(imageID)
+
+	if usage, ok := r.usage[ownerID]; ok {
+		usage.TotalBytes = max(usage.TotalBytes-img.GetFileSize()-img.GetVariantsSize(), 0)
+		usage.ImageCount = max(usage.ImageCount-1, 0)
+		r.usage[ownerID] = usage
+	}
+
+	return true, nil
+}
+
+func (r *Repository) DeleteImagesByOwner(ctx context.Context, ownerID string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for id, img := range r.images {
+		if img.GetOwnerId() == ownerID {
+			r.deleteImage(id)
+			deleted++
+		}
+	}
+
+	if usage, ok := r.usage[ownerID]; ok {
+		usage.TotalBytes = 0
+		usage.ImageCount = 0
+		r.usage[ownerID] = usage
+	}
+
+	return deleted, nil
+}
+
+// FindSimilarImages returns the owner's images within the query's Hamming
+// distance, closest first.
+func (r *Repository) FindSimilarImages(ctx context.Context, ownerID string, query model.SimilarityQuery) ([]model.SimilarImage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matches []model.SimilarImage
+	for id, img := range r.images {
+		if img.GetOwnerId() != ownerID || id == query.ExcludeID {
+			continue
+		}
+
+		hash, ok := query.Algorithm.Of(img.GetHashes())
+		if !ok {
+			continue
+		}
+
+		if distance := bits.OnesCount64(hash ^ query.Hash); distance <= query.MaxDistance {
+			matches = append(matches, model.SimilarImage{Image: stored(img), Distance: distance})
+		}
+	}
+
+	sort.Slice(matches, func(a, b int) bool {
+		if matches[a].Distance != matches[b].Distance {
+			return matches[a].Distance < matches[b].Distance
+		}
+		return matches[a].Image.GetImageId() < matches[b].Image.GetImageId()
+	})
+
+	if len(matches) > query.Limit {
+		matches = matches[:query.Limit]
+	}
+
+	return matches, nil
+}
+
+// deleteImage removes the image together with its album entries and shares.
+func (r *Repository) deleteImage(imageID int64) {
+	delete(r.images, imageID)
+
+	for _, images := range r.albumImages {
+		delete(images, imageID)
+	}
+	for s := range r.imageShares {
+		if s.id == imageID {
+			delete(r.imageShares, s)
+		}
+	}
+}
+
+func (r *Repository) CreateAlbum(ctx context.Context, ownerID string, name string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextAlbumID++
+	r.albums[r.nextAlbumID] = ownerID
+	r.albumImages[r.nextAlbumID] = make(map[int64]struct{})
+
+	return r.nextAlbumID, nil
+}
+
+func (r *Repository) AddImageToAlbum(ctx context.Context, ownerID string, albumID int64, imageID int64) error {
+	const op = "memory.AddImageToAlbum"
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkAlbumOwner(ownerID, albumID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := r.checkImageOwner(ownerID, imageID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	r.albumImages[albumID][imageID] = struct{}{}
+
+	return nil
+}
+
+func (r *Repository) ShareImage(ctx context.Context, ownerID string, imageID int64, grantee permission.Grantee, level permission.Level) error {
+	const op = "memory.ShareImage"
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkImageOwner(ownerID, imageID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	r.imageShares[share{id: imageID, grantee: grantee}] = level
+
+	return nil
+}
+
+func (r *Repository) ShareAlbum(ctx context.Context, ownerID string, albumID int64, grantee permission.Grantee, level permission.Level) error {
+	const op = "memory.ShareAlbum"
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkAlbumOwner(ownerID, albumID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	r.albumShares[share{id: albumID, grantee: grantee}] = level
+
+	return nil
+}
+
+func (r *Repository) RevokeImageShare(ctx context.Context, ownerID string, imageID int64, grantee permission.Grantee) error {
+	const op = "memory.RevokeImageShare"
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := share{id: imageID, grantee: grantee}
+	if _, ok := r.imageShares[s]; !ok || r.checkImageOwner(ownerID, imageID) != nil {
+		return fmt.Errorf("%s: %w", op, repository.ErrShareNotFound)
+	}
+	delete(r.imageShares, s)
+
+	return nil
+}
+
+func (r *Repository) RevokeAlbumShare(ctx context.Context, ownerID string, albumID int64, grantee permission.Grantee) error {
+	const op = "memory.RevokeAlbumShare"
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := share{id: albumID, grantee: grantee}
+	if _, ok := r.albumShares[s]; !ok || r.checkAlbumOwner(ownerID, albumID) != nil {
+		return fmt.Errorf("%s: %w", op, repository.ErrShareNotFound)
+	}
+	delete(r.albumShares, s)
+
+	return nil
+}
+
+// GetSharedImage returns an image shared with grantee directly or through
+// an album, together with the highest level granted.
+func (r *Repository) GetSharedImage(ctx context.Context, grantee permission.Grantee, imageID int64) (*imagev1.ImageMetadata, permission.Level, error) {
+	const op = "memory.GetSharedImage"
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	img, ok := r.images[imageID]
+	if !ok {
+		return nil, permission.LevelNone, fmt.Errorf("%s: %w", op, repository.ErrImageNotFound)
+	}
+
+	level := r.imageShares[share{id: imageID, grantee: grantee}]
+	for albumID, images := range r.albumImages {
+		if _, ok := images[imageID]; ok {
+			level = max(level, r.albumShares[share{id: albumID, grantee: grantee}])
+		}
+	}
+
+	if level == permission.LevelNone {
+		return nil, permission.LevelNone, fmt.Errorf("%s: %w", op, repository.ErrImageNotFound)
+	}
+
+	return stored(img), level, nil
+}
+
+func (r *Repository) GetUsage(ctx context.Context, ownerID string) (model.Usage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.usageOf(ownerID), nil
+}
+
+func (r *Repository) GetQuota(ctx context.Context, ownerID string) (model.Quota, error) {
+	const op = "memory.GetQuota"
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	quota, ok := r.quotas[ownerID]
+	if !ok {
+		return quota, fmt.Errorf("%s: %w", op, repository.ErrQuotaNotFound)
+	}
+
+	return quota, nil
+}
+
+func (r *Repository) SetQuota(ctx context.Context, ownerID string, quota model.Quota) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.quotas[ownerID] = quota
+
+	return nil
+}
+
+// GetTotalUsage sums the usage of all tenants.
+func (r *Repository) GetTotalUsage(ctx context.Context) (int64, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var bytes, images int64
+	for _, usage := range r.usage {
+		bytes += usage.TotalBytes
+		images += usage.ImageCount
+	}
+
+	return bytes, images, nil
+}
+
+// usageOf returns the owner's usage. Monthly counters of a past month read
+// as zero.
+func (r *Repository) usageOf(ownerID string) model.Usage {
+	month := currentMonth()
+
+	usage, ok := r.usage[ownerID]
+	if !ok {
+		return model.Usage{OwnerID: ownerID, Month: month}
+	}
+	if !usage.Month.Equal(month) {
+		usage.Month = month
+		usage.MonthlyUploadBytes = 0
+	}
+
+	return usage
+}
+
+func (r *Repository) checkAlbumOwner(ownerID string, albumID int64) error {
+	if owner, ok := r.albums[albumID]; !ok || owner != ownerID {
+		return repository.ErrAlbumNotFound
+	}
+
+	return nil
+}
+
+func (r *Repository) checkImageOwner(ownerID string, imageID int64) error {
+	if img, ok := r.images[imageID]; !ok || img.GetOwnerId() != ownerID {
+		return repository.ErrImageNotFound
+	}
+
+	return nil
+}
+
+func currentMonth() time.Time {
+	now := time.Now().UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// stored copies the fields the repositories persist, so callers never share
+// messages with the repository.
+func stored(img *imagev1.ImageMetadata) *imagev1.ImageMetadata {
+	return &imagev1.ImageMetadata{
+		ImageId:       img.GetImageId(),
+		OwnerId:       img.GetOwnerId(),
+		Filename:      img.GetFilename(),
+		FileSize:      img.GetFileSize(),
+		MimeType:      img.GetMimeType(),
+		Width:         img.GetWidth(),
+		Height:        img.GetHeight(),
+		FilePath:      img.GetFilePath(),
+		ThumbnailPath: img.GetThumbnailPath(),
+		ImageFormat:   img.GetImageFormat(),
+		VariantsSize:  img.GetVariantsSize(),
+		Revision:      img.GetRevision(),
+		Hashes:        hashes(img.GetHashes()),
+		Colors:        colors(img.GetColors()),
+		Placeholder:   placeholder(img.GetPlaceholder()),
+		FocalPoint:    focalPoint(img.GetFocalPoint()),
+		CropHints:     cropHints(img.GetCropHints()),
+	}
+}
+
+func cropHints(hints []*imagev1.CropHint) []*imagev1.CropHint {
+	if len(hints) == 0 {
+		return nil
+	}
+
+	copied := make([]*imagev1.CropHint, 0, len(hints))
+	for _, h := range hints {
+		copied = append(copied, proto.Clone(h).(*imagev1.CropHint))
+	}
+
+	return copied
+}
+
+func focalPoint(p *imagev1.FocalPoint) *imagev1.FocalPoint {
+	if p == nil {
+		return nil
+	}
+
+	return &imagev1.FocalPoint{X: p.GetX(), Y: p.GetY(), Manual: p.GetManual()}
+}
+
+func placeholder(p *imagev1.Placeholder) *imagev1.Placeholder {
+	if p == nil {
+		return nil
+	}
+
+	return &imagev1.Placeholder{Blurhash: p.GetBlurhash(), Preview: p.GetPreview()}
+}
+
+func colors(c *imagev1.ColorSummary) *imagev1.ColorSummary {
+	if c == nil {
+		return nil
+	}
+
+	return proto.Clone(c).(*imagev1.ColorSummary)
+}
+
+func hashes(h *imagev1.PerceptualHash) *imagev1.PerceptualHash {
+	if h == nil {
+		return nil
+	}
+
+	return &imagev1.PerceptualHash{Ahash: h.GetAhash(), Dhash: h.GetDhash(), Phash: h.GetPhash()}
+}
@@ -0,0 +1,13 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/aidosgal/image-processing-service/internal/repository/repositorytest"
+)
+
+func TestConformance(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repositorytest.Repository {
+		return NewRepository()
+	})
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"database/sql"
+
+	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
+)
+
+// PlaceholderColumns is the placeholder of an image as the SQL repositories
+// store it, NULL for images stored before placeholders were generated.
+type PlaceholderColumns struct {
+	Blurhash sql.NullString
+	Preview  sql.NullString
+}
+
+// NewPlaceholderColumns converts a placeholder to its columns, both NULL for nil.
+func NewPlaceholderColumns(p *imagev1.Placeholder) PlaceholderColumns {
+	if p == nil {
+		return PlaceholderColumns{}
+	}
+
+	return PlaceholderColumns{
+		Blurhash: sql.NullString{String: p.GetBlurhash(), Valid: true},
+		Preview:  sql.NullString{String: p.GetPreview(), Valid: true},
+	}
+}
+
+// Dest returns scan destinations for the columns blurhash and preview, in
+// that order.
+func (p *PlaceholderColumns) Dest() []any {
+	return []any{&p.Blurhash, &p.Preview}
+}
+
+// Placeholder converts the scanned columns back, nil when they are NULL.
+func (p *PlaceholderColumns) Placeholder() *imagev1.Placeholder {
+	if !p.Blurhash.Valid {
+		return nil
+	}
+
+	return &imagev1.Placeholder{Blurhash: p.Blurhash.String, Preview: p.Preview.String}
+}
@@ -0,0 +1,44 @@
+package psql
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/aidosgal/image-processing-service/internal/config"
+)
+
+// DSN returns the connection string for cfg. A URL set in the config or in
+// DATABASE_URL is used as is; otherwise the URL is built from the individual
+// fields, escaping the user name, password and database name.
+func DSN(cfg config.DatabaseConfig) (string, error) {
+	if cfg.URL != "" {
+		u, err := url.Parse(cfg.URL)
+		if err != nil {
+			// The parse error would echo the password.
+			return "", errors.New("invalid database url")
+		}
+		if u.Scheme != "postgres" && u.Scheme != "postgresql" {
+			return "", errors.New("database url must use the postgres scheme")
+		}
+
+		return cfg.URL, nil
+	}
+
+	if cfg.Host == "" || cfg.Name == "" {
+		return "", errors.New("database host and name are required")
+	}
+
+	u := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(cfg.User, cfg.Password),
+		Host:   net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		Path:   "/" + cfg.Name,
+	}
+	if cfg.SSLMode != "" {
+		u.RawQuery = url.Values{"sslmode": {cfg.SSLMode}}.Encode()
+	}
+
+	return u.String(), nil
+}
@@ -3,42 +3,271 @@ package psql
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"log/slog"
+	"time"
 
 	"github.com/aidosgal/image-processing-service/internal/config"
+	"github.com/aidosgal/image-processing-service/internal/domain/model"
+	"github.com/aidosgal/image-processing-service/internal/lib/logger"
+	"github.com/aidosgal/image-processing-service/internal/lib/metrics"
+	"github.com/aidosgal/image-processing-service/internal/lib/tracing"
+	"github.com/aidosgal/image-processing-service/internal/repository"
 	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
 	_ "github.com/lib/pq"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type Repository struct {
-	db *sql.DB
+	db           *sql.DB
+	queryTimeout time.Duration
 }
 
-func NewRepository(cfg config.DatabaseConfig) (*Repository, error) {
-	connStr := fmt.Sprintf("postgres://%s:%s@%s:%d/postgres?sslmode=%s",
-		cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.SSLMode)
-	db, err := sql.Open("postgres", connStr)
+// NewRepository opens a connection pool sized by cfg and pings the database
+// until it answers, waiting ConnectBackoff after the first failure and twice
+// as long after each further one, for at most ConnectAttempts attempts.
+func NewRepository(ctx context.Context, log *slog.Logger, cfg config.DatabaseConfig) (*Repository, error) {
+	const op = "psql.NewRepository"
+
+	dsn, err := DSN(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	db, err := sql.Open("postgres", dsn)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to the database: %w", err)
+		return nil, fmt.Errorf("%s: failed to open database: %w", op, err)
+	}
+
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	if err := pingWithRetry(ctx, log, db, cfg.ConnectAttempts, cfg.ConnectBackoff); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Repository{db: db, queryTimeout: cfg.QueryTimeout}, nil
+}
+
+func pingWithRetry(ctx context.Context, log *slog.Logger, db *sql.DB, attempts int, backoff time.Duration) error {
+	attempts = max(attempts, 1)
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = db.PingContext(ctx); err == nil {
+			return nil
+		}
+		if attempt == attempts {
+			return fmt.Errorf("database unreachable after %d attempts: %w", attempts, err)
+		}
+
+		log.Warn("database unreachable, retrying",
+			slog.Int("attempt", attempt),
+			slog.Duration("backoff", backoff),
+			slog.String("error", err.Error()),
+		)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("database unreachable: %w", errors.Join(ctx.Err(), err))
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// startQuery starts the span of a repository call and bounds the call by
+// the query timeout, so a stuck statement is cancelled instead of holding
+// a pooled connection.
+func (r *Repository) startQuery(ctx context.Context, op string) (context.Context, func()) {
+	ctx, span := tracing.Start(ctx, op, attribute.String("db.system", "postgresql"))
+	if r.queryTimeout <= 0 {
+		return ctx, func() { span.End() }
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	return ctx, func() {
+		cancel()
+		span.End()
+	}
+}
+
+// Ping checks that the database is reachable.
+func (r *Repository) Ping(ctx context.Context) error {
+	const op = "psql.Ping"
+
+	if err := r.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Close closes the connection pool.
+func (r *Repository) Close() error {
+	const op = "psql.Close"
+
+	if err := r.db.Close(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// rollback aborts tx unless it was already committed. Failures are logged
+// with the request logger, since the caller is already returning an error.
+func rollback(ctx context.Context, tx *sql.Tx) {
+	if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		logger.FromContext(ctx, slog.Default()).Warn("failed to roll back transaction", slog.String("error", err.Error()))
+	}
+}
+
+const imageColumns = `
+	id,
+	owner_id,
+	filename,
+	file_size,
+	mime_type,
+	width,
+	height,
+	file_path,
+	thumbnail_path,
+	image_format,
+	variants_size,
+	revision,
+	ahash,
+	dhash,
+	phash,
+	palette,
+	average_color,
+	brightness,
+	colorfulness,
+	grayscale,
+	blurhash,
+	preview,
+	focal_x,
+	focal_y,
+	focal_manual,
+	crop_hints
+`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanImage(row scanner) (*imagev1.ImageMetadata, error) {
+	var img imagev1.ImageMetadata
+	var ahash, dhash, phash sql.NullInt64
+	var colors repository.ColorColumns
+	var placeholder repository.PlaceholderColumns
+	var focal repository.FocalColumns
+	var cropHints repository.CropHintColumns
+
+	dest := []any{
+		&img.ImageId,
+		&img.OwnerId,
+		&img.Filename,
+		&img.FileSize,
+		&img.MimeType,
+		&img.Width,
+		&img.Height,
+		&img.FilePath,
+		&img.ThumbnailPath,
+		&img.ImageFormat,
+		&img.VariantsSize,
+		&img.Revision,
+		&ahash,
+		&dhash,
+		&phash,
+	}
+	dest = append(dest, colors.Dest()...)
+	dest = append(dest, placeholder.Dest()...)
+	dest = append(dest, focal.Dest()...)
+	if err := row.Scan(append(dest, cropHints.Dest()...)...); err != nil {
+		return nil, err
+	}
+
+	if ahash.Valid && dhash.Valid && phash.Valid {
+		img.Hashes = &imagev1.PerceptualHash{
+			Ahash: uint64(ahash.Int64),
+			Dhash: uint64(dhash.Int64),
+			Phash: uint64(phash.Int64),
+		}
 	}
-	defer db.Close()
 
-	connStr = fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s",
-		cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.Name, cfg.SSLMode)
-	db, err = sql.Open("postgres", connStr)
+	summary, err := colors.Summary()
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to the newly created database: %w", err)
+		return nil, err
+	}
+	img.Colors = summary
+	img.Placeholder = placeholder.Placeholder()
+	img.FocalPoint = focal.FocalPoint()
+	img.CropHints, err = cropHints.CropHints()
+	if err != nil {
+		return nil, err
 	}
 
-	return &Repository{db: db}, nil
+	return &img, nil
 }
 
-func (r *Repository) StoreImage(ctx context.Context, metadata *imagev1.ImageMetadata) (int64, error) {
+// hashColumns converts the hashes to the signed BIGINT columns, all NULL
+// when the image has none.
+func hashColumns(h *imagev1.PerceptualHash) (ahash, dhash, phash sql.NullInt64) {
+	if h == nil {
+		return
+	}
+
+	return sql.NullInt64{Int64: int64(h.GetAhash()), Valid: true},
+		sql.NullInt64{Int64: int64(h.GetDhash()), Valid: true},
+		sql.NullInt64{Int64: int64(h.GetPhash()), Valid: true}
+}
+
+// StoreImage inserts the image and charges it to the owner's usage in one
+// transaction. The quota is checked against the locked usage row, so
+// concurrent uploads cannot exceed it.
+func (r *Repository) StoreImage(ctx context.Context, ownerID string, metadata *imagev1.ImageMetadata, quota model.Quota) (int64, error) {
 	const op = "psql.StoreImage"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, end := r.startQuery(ctx, op)
+	defer end()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer rollback(ctx, tx)
+
+	usage, err := lockUsage(ctx, tx, ownerID)
+	if err != nil {
+		return -1, fmt.Errorf("%s: %w", op, err)
+	}
+
+	storedBytes := metadata.GetFileSize() + metadata.GetVariantsSize()
+	if err := quota.Check(usage, metadata.GetFileSize(), storedBytes); err != nil {
+		return -1, fmt.Errorf("%s: %w", op, err)
+	}
+
+	ahash, dhash, phash := hashColumns(metadata.GetHashes())
+	colors, err := repository.NewColorColumns(metadata.GetColors())
+	if err != nil {
+		return -1, fmt.Errorf("%s: %w", op, err)
+	}
+	placeholder := repository.NewPlaceholderColumns(metadata.GetPlaceholder())
+	focal := repository.NewFocalColumns(metadata.GetFocalPoint())
+	cropHints, err := repository.NewCropHintColumns(metadata.GetCropHints())
+	if err != nil {
+		return -1, fmt.Errorf("%s: %w", op, err)
+	}
 
 	var imageID int64
-	err := r.db.QueryRow(`
+	err = tx.QueryRowContext(ctx, `
 		INSERT INTO images (
+			owner_id,
 			filename,
 			file_size,
 			mime_type,
@@ -46,10 +275,26 @@ func (r *Repository) StoreImage(ctx context.Context, metadata *imagev1.ImageMeta
 			height,
 			file_path,
 			thumbnail_path,
-			image_format
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			image_format,
+			variants_size,
+			ahash,
+			dhash,
+			phash,
+			palette,
+			average_color,
+			brightness,
+			colorfulness,
+			grayscale,
+			blurhash,
+			preview,
+			focal_x,
+			focal_y,
+			focal_manual,
+			crop_hints
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24)
 		RETURNING id
-	`, metadata.GetFilename(),
+	`, ownerID,
+		metadata.GetFilename(),
 		metadata.GetFileSize(),
 		metadata.GetMimeType(),
 		metadata.GetWidth(),
@@ -57,31 +302,59 @@ func (r *Repository) StoreImage(ctx context.Context, metadata *imagev1.ImageMeta
 		metadata.GetFilePath(),
 		metadata.GetThumbnailPath(),
 		metadata.GetImageFormat(),
+		metadata.GetVariantsSize(),
+		ahash,
+		dhash,
+		phash,
+		colors.Palette,
+		colors.AverageColor,
+		colors.Brightness,
+		colors.Colorfulness,
+		colors.Grayscale,
+		placeholder.Blurhash,
+		placeholder.Preview,
+		focal.X,
+		focal.Y,
+		focal.Manual,
+		cropHints.Hints,
 	).Scan(&imageID)
 	if err != nil {
 		return -1, fmt.Errorf("%s: %w", op, err)
 	}
 
+	_, err = tx.ExecContext(ctx, `
+		UPDATE tenant_usage SET
+			total_bytes = total_bytes + $2,
+			image_count = image_count + 1,
+			monthly_upload_bytes = $3,
+			month = date_trunc('month', NOW())::date,
+			updated_at = NOW()
+		WHERE owner_id = $1
+	`, ownerID, storedBytes, usage.MonthlyUploadBytes+metadata.GetFileSize())
+	if err != nil {
+		return -1, fmt.Errorf("%s: failed to update usage: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return -1, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
 	return imageID, nil
 }
 
-func (r *Repository) GetAllImages(ctx context.Context) ([]*imagev1.ImageMetadata, error) {
+func (r *Repository) GetAllImages(ctx context.Context, ownerID string) ([]*imagev1.ImageMetadata, error) {
 	const op = "psql.GetAllImages"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, end := r.startQuery(ctx, op)
+	defer end()
 
 	rows, err := r.db.QueryContext(ctx, `
-		SELECT
-			id,
-			filename,
-			file_size,
-			mime_type,
-			width,
-			height,
-			file_path,
-			thumbnail_path,
-			image_format
+		SELECT `+imageColumns+`
 		FROM images
+		WHERE owner_id = $1
 		ORDER BY uploaded_at DESC
-	`)
+	`, ownerID)
 	if err != nil {
 		return nil, fmt.Errorf("%s: failed to query images: %w", op, err)
 	}
@@ -89,24 +362,12 @@ func (r *Repository) GetAllImages(ctx context.Context) ([]*imagev1.ImageMetadata
 
 	var images []*imagev1.ImageMetadata
 	for rows.Next() {
-		var img imagev1.ImageMetadata
-
-		err := rows.Scan(
-			&img.ImageId,
-			&img.Filename,
-			&img.FileSize,
-			&img.MimeType,
-			&img.Width,
-			&img.Height,
-			&img.FilePath,
-			&img.ThumbnailPath,
-			&img.ImageFormat,
-		)
+		img, err := scanImage(rows)
 		if err != nil {
 			return nil, fmt.Errorf("%s: failed to scan image row: %w", op, err)
 		}
 
-		images = append(images, &img)
+		images = append(images, img)
 	}
 
 	if err = rows.Err(); err != nil {
@@ -116,80 +377,175 @@ func (r *Repository) GetAllImages(ctx context.Context) ([]*imagev1.ImageMetadata
 	return images, nil
 }
 
-func (r *Repository) GetImageById(ctx context.Context, imageID int64) (*imagev1.ImageMetadata, error) {
+func (r *Repository) GetImageById(ctx context.Context, ownerID string, imageID int64) (*imagev1.ImageMetadata, error) {
 	const op = "psql.GetImageById"
+	defer metrics.ObserveQuery(op, time.Now())
 
-	var img imagev1.ImageMetadata
+	ctx, end := r.startQuery(ctx, op)
+	defer end()
 
-	err := r.db.QueryRowContext(ctx, `
-		SELECT
-			id,
-			filename,
-			file_size,
-			mime_type,
-			width,
-			height,
-			file_path,
-			thumbnail_path,
-			image_format
+	img, err := scanImage(r.db.QueryRowContext(ctx, `
+		SELECT `+imageColumns+`
 		FROM images
-		WHERE id = $1
-	`, imageID).Scan(
-		&img.ImageId,
-		&img.Filename,
-		&img.FileSize,
-		&img.MimeType,
-		&img.Width,
-		&img.Height,
-		&img.FilePath,
-		&img.ThumbnailPath,
-		&img.ImageFormat,
-	)
-
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("%s: image not found: %w", op, err)
+		WHERE id = $1 AND owner_id = $2
+	`, imageID, ownerID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %w", op, repository.ErrImageNotFound)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("%s: failed to retrieve image: %w", op, err)
 	}
 
-	return &img, nil
+	return img, nil
 }
 
-func (r *Repository) DeleteImageById(ctx context.Context, imageID int64) (bool, error) {
-	const op = "psql.DeleteImageById"
+// UpdateCropping replaces the focal point, crop hints and variants size of
+// the image and bumps its revision, returning the new one. The owner's usage
+// follows the change in variants size.
+func (r *Repository) UpdateCropping(ctx context.Context, ownerID string, imageID int64, cropping repository.Cropping) (int64, error) {
+	const op = "psql.UpdateCropping"
+	defer metrics.ObserveQuery(op, time.Now())
 
-	var exists bool
-	err := r.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM images WHERE id = $1)", imageID).Scan(&exists)
+	ctx, end := r.startQuery(ctx, op)
+	defer end()
+
+	focal := repository.NewFocalColumns(cropping.FocalPoint)
+	cropHints, err := repository.NewCropHintColumns(cropping.CropHints)
 	if err != nil {
-		return false, fmt.Errorf("%s: failed to check image existence: %w", op, err)
+		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
-	if !exists {
-		return false, fmt.Errorf("%s: image not found", op)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
 	}
+	defer rollback(ctx, tx)
+
+	var variantsSize int64
+	err = tx.QueryRowContext(ctx, `
+		SELECT variants_size FROM images
+		WHERE id = $1 AND owner_id = $2
+		FOR UPDATE
+	`, imageID, ownerID).Scan(&variantsSize)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%s: %w", op, repository.ErrImageNotFound)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to lock image: %w", op, err)
+	}
+
+	var revision int64
+	err = tx.QueryRowContext(ctx, `
+		UPDATE images SET
+			focal_x = $2,
+			focal_y = $3,
+			focal_manual = $4,
+			crop_hints = $5,
+			variants_size = $6,
+			revision = revision + 1,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING revision
+	`, imageID, focal.X, focal.Y, focal.Manual, cropHints.Hints, cropping.VariantsSize).Scan(&revision)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to update image: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE tenant_usage SET
+			total_bytes = GREATEST(total_bytes + $2, 0),
+			updated_at = NOW()
+		WHERE owner_id = $1
+	`, ownerID, cropping.VariantsSize-variantsSize)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to update usage: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return revision, nil
+}
+
+func (r *Repository) DeleteImageById(ctx context.Context, ownerID string, imageID int64) (bool, error) {
+	const op = "psql.DeleteImageById"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, end := r.startQuery(ctx, op)
+	defer end()
 
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return false, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
 	}
-	defer func() {
-		if err != nil {
-			tx.Rollback()
-			return
-		}
-		err = tx.Commit()
-	}()
+	defer rollback(ctx, tx)
 
-	result, err := tx.ExecContext(ctx, "DELETE FROM images WHERE id = $1", imageID)
+	var storedBytes int64
+	err = tx.QueryRowContext(ctx, `
+		DELETE FROM images
+		WHERE id = $1 AND owner_id = $2
+		RETURNING file_size + variants_size
+	`, imageID, ownerID).Scan(&storedBytes)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, fmt.Errorf("%s: %w", op, repository.ErrImageNotFound)
+	}
 	if err != nil {
 		return false, fmt.Errorf("%s: failed to delete image record: %w", op, err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	_, err = tx.ExecContext(ctx, `
+		UPDATE tenant_usage SET
+			total_bytes = GREATEST(total_bytes - $2, 0),
+			image_count = GREATEST(image_count - 1, 0),
+			updated_at = NOW()
+		WHERE owner_id = $1
+	`, ownerID, storedBytes)
+	if err != nil {
+		return false, fmt.Errorf("%s: failed to update usage: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return true, nil
+}
+
+func (r *Repository) DeleteImagesByOwner(ctx context.Context, ownerID string) (int64, error) {
+	const op = "psql.DeleteImagesByOwner"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, end := r.startQuery(ctx, op)
+	defer end()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer rollback(ctx, tx)
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM images WHERE owner_id = $1", ownerID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to delete images: %w", op, err)
+	}
+
+	deleted, err := result.RowsAffected()
 	if err != nil {
-		return false, fmt.Errorf("%s: failed to verify deletion: %w", op, err)
+		return 0, fmt.Errorf("%s: failed to verify deletion: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE tenant_usage SET total_bytes = 0, image_count = 0, updated_at = NOW()
+		WHERE owner_id = $1
+	`, ownerID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to update usage: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
 	}
 
-	return rowsAffected > 0, nil
+	return deleted, nil
 }
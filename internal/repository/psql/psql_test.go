@@ -0,0 +1,127 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aidosgal/image-processing-service/internal/config"
+	"github.com/aidosgal/image-processing-service/internal/repository/repositorytest"
+)
+
+var errUnreachable = errors.New("connection refused")
+
+// flakyDriver fails the first failures connection attempts.
+type flakyDriver struct {
+	failures int32
+	attempts atomic.Int32
+}
+
+func (d *flakyDriver) Open(string) (driver.Conn, error) {
+	if d.attempts.Add(1) <= d.failures {
+		return nil, errUnreachable
+	}
+
+	return conn{}, nil
+}
+
+type conn struct{}
+
+func (conn) Prepare(string) (driver.Stmt, error) { return nil, errors.ErrUnsupported }
+func (conn) Close() error                        { return nil }
+func (conn) Begin() (driver.Tx, error)           { return nil, errors.ErrUnsupported }
+
+var driverSeq atomic.Int32
+
+func openFlaky(t *testing.T, failures int32) (*sql.DB, *flakyDriver) {
+	t.Helper()
+
+	d := &flakyDriver{failures: failures}
+	name := fmt.Sprintf("flaky%d", driverSeq.Add(1))
+	sql.Register(name, d)
+
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db, d
+}
+
+func TestPingWithRetry_RecoversAfterFailures(t *testing.T) {
+	db, d := openFlaky(t, 2)
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	if err := pingWithRetry(context.Background(), log, db, 5, time.Millisecond); err != nil {
+		t.Fatalf("pingWithRetry() error = %v", err)
+	}
+	if got := d.attempts.Load(); got != 3 {
+		t.Errorf("attempts = %d, want 3", got)
+	}
+}
+
+func TestPingWithRetry_GivesUp(t *testing.T) {
+	db, d := openFlaky(t, 100)
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	err := pingWithRetry(context.Background(), log, db, 3, time.Millisecond)
+	if !errors.Is(err, errUnreachable) {
+		t.Fatalf("pingWithRetry() error = %v, want %v", err, errUnreachable)
+	}
+	if got := d.attempts.Load(); got != 3 {
+		t.Errorf("attempts = %d, want 3", got)
+	}
+}
+
+func TestPingWithRetry_StopsWhenCancelled(t *testing.T) {
+	db, d := openFlaky(t, 100)
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := pingWithRetry(ctx, log, db, 10, time.Hour)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("pingWithRetry() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("pingWithRetry() returned after %v, want it to stop with the context", elapsed)
+	}
+	if got := d.attempts.Load(); got != 1 {
+		t.Errorf("attempts = %d, want 1", got)
+	}
+}
+
+// TestConformance runs against the database in TEST_DATABASE_URL, which
+// must already be migrated.
+func TestConformance(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	r, err := NewRepository(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)), config.DatabaseConfig{
+		URL:             url,
+		MaxOpenConns:    10,
+		ConnectAttempts: 1,
+		QueryTimeout:    5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	t.Cleanup(func() { r.Close() })
+
+	repositorytest.Run(t, func(t *testing.T) repositorytest.Repository {
+		return r
+	})
+}
@@ -0,0 +1,222 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aidosgal/image-processing-service/internal/lib/metrics"
+	"github.com/aidosgal/image-processing-service/internal/lib/permission"
+	"github.com/aidosgal/image-processing-service/internal/repository"
+	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
+)
+
+func (r *Repository) CreateAlbum(ctx context.Context, ownerID string, name string) (int64, error) {
+	const op = "psql.CreateAlbum"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, end := r.startQuery(ctx, op)
+	defer end()
+
+	var albumID int64
+	err := r.db.QueryRowContext(ctx,
+		"INSERT INTO albums (owner_id, name) VALUES ($1, $2) RETURNING id",
+		ownerID, name,
+	).Scan(&albumID)
+	if err != nil {
+		return -1, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return albumID, nil
+}
+
+func (r *Repository) AddImageToAlbum(ctx context.Context, ownerID string, albumID int64, imageID int64) error {
+	const op = "psql.AddImageToAlbum"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, end := r.startQuery(ctx, op)
+	defer end()
+
+	if err := r.checkAlbumOwner(ctx, ownerID, albumID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := r.checkImageOwner(ctx, ownerID, imageID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO album_images (album_id, image_id) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`, albumID, imageID)
+	if err != nil {
+		return fmt.Errorf("%s: failed to add image to album: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *Repository) ShareImage(ctx context.Context, ownerID string, imageID int64, grantee permission.Grantee, level permission.Level) error {
+	const op = "psql.ShareImage"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, end := r.startQuery(ctx, op)
+	defer end()
+
+	if err := r.checkImageOwner(ctx, ownerID, imageID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO shares (owner_id, image_id, grantee_tenant, grantee, permission) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (image_id, grantee_tenant, grantee) WHERE image_id IS NOT NULL
+		DO UPDATE SET permission = EXCLUDED.permission
+	`, ownerID, imageID, grantee.Tenant, grantee.Subject, level.String())
+	if err != nil {
+		return fmt.Errorf("%s: failed to share image: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *Repository) ShareAlbum(ctx context.Context, ownerID string, albumID int64, grantee permission.Grantee, level permission.Level) error {
+	const op = "psql.ShareAlbum"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, end := r.startQuery(ctx, op)
+	defer end()
+
+	if err := r.checkAlbumOwner(ctx, ownerID, albumID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO shares (owner_id, album_id, grantee_tenant, grantee, permission) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (album_id, grantee_tenant, grantee) WHERE album_id IS NOT NULL
+		DO UPDATE SET permission = EXCLUDED.permission
+	`, ownerID, albumID, grantee.Tenant, grantee.Subject, level.String())
+	if err != nil {
+		return fmt.Errorf("%s: failed to share album: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *Repository) RevokeImageShare(ctx context.Context, ownerID string, imageID int64, grantee permission.Grantee) error {
+	const op = "psql.RevokeImageShare"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, end := r.startQuery(ctx, op)
+	defer end()
+
+	result, err := r.db.ExecContext(ctx,
+		"DELETE FROM shares WHERE owner_id = $1 AND image_id = $2 AND grantee_tenant = $3 AND grantee = $4",
+		ownerID, imageID, grantee.Tenant, grantee.Subject,
+	)
+
+	return checkRevoked(op, result, err)
+}
+
+func (r *Repository) RevokeAlbumShare(ctx context.Context, ownerID string, albumID int64, grantee permission.Grantee) error {
+	const op = "psql.RevokeAlbumShare"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, end := r.startQuery(ctx, op)
+	defer end()
+
+	result, err := r.db.ExecContext(ctx,
+		"DELETE FROM shares WHERE owner_id = $1 AND album_id = $2 AND grantee_tenant = $3 AND grantee = $4",
+		ownerID, albumID, grantee.Tenant, grantee.Subject,
+	)
+
+	return checkRevoked(op, result, err)
+}
+
+// GetSharedImage returns an image shared with grantee directly or through
+// an album, together with the highest level granted.
+func (r *Repository) GetSharedImage(ctx context.Context, grantee permission.Grantee, imageID int64) (*imagev1.ImageMetadata, permission.Level, error) {
+	const op = "psql.GetSharedImage"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, end := r.startQuery(ctx, op)
+	defer end()
+
+	var level permission.Level
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COALESCE(MAX(CASE permission WHEN 'write' THEN 2 WHEN 'read' THEN 1 END), 0)
+		FROM shares
+		WHERE grantee_tenant = $1 AND grantee = $2
+			AND (
+				image_id = $3
+				OR album_id IN (SELECT album_id FROM album_images WHERE image_id = $3)
+			)
+	`, grantee.Tenant, grantee.Subject, imageID).Scan(&level)
+	if err != nil {
+		return nil, permission.LevelNone, fmt.Errorf("%s: failed to check shares: %w", op, err)
+	}
+
+	if level == permission.LevelNone {
+		return nil, permission.LevelNone, fmt.Errorf("%s: %w", op, repository.ErrImageNotFound)
+	}
+
+	img, err := scanImage(r.db.QueryRowContext(ctx, `
+		SELECT `+imageColumns+`
+		FROM images
+		WHERE id = $1
+	`, imageID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, permission.LevelNone, fmt.Errorf("%s: %w", op, repository.ErrImageNotFound)
+	}
+	if err != nil {
+		return nil, permission.LevelNone, fmt.Errorf("%s: failed to retrieve image: %w", op, err)
+	}
+
+	return img, level, nil
+}
+
+func (r *Repository) checkAlbumOwner(ctx context.Context, ownerID string, albumID int64) error {
+	var exists bool
+	err := r.db.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM albums WHERE id = $1 AND owner_id = $2)", albumID, ownerID,
+	).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check album existence: %w", err)
+	}
+	if !exists {
+		return repository.ErrAlbumNotFound
+	}
+
+	return nil
+}
+
+func (r *Repository) checkImageOwner(ctx context.Context, ownerID string, imageID int64) error {
+	var exists bool
+	err := r.db.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM images WHERE id = $1 AND owner_id = $2)", imageID, ownerID,
+	).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check image existence: %w", err)
+	}
+	if !exists {
+		return repository.ErrImageNotFound
+	}
+
+	return nil
+}
+
+func checkRevoked(op string, result sql.Result, err error) error {
+	if err != nil {
+		return fmt.Errorf("%s: failed to revoke share: %w", op, err)
+	}
+
+	revoked, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to verify revocation: %w", op, err)
+	}
+	if revoked == 0 {
+		return fmt.Errorf("%s: %w", op, repository.ErrShareNotFound)
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package psql
+
+import (
+	"context"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aidosgal/image-processing-service/internal/domain/model"
+	"github.com/aidosgal/image-processing-service/internal/lib/metrics"
+	"github.com/lib/pq"
+)
+
+const (
+	// hashBands is the number of 16-bit bands each hash is indexed by, see
+	// migration 12.
+	hashBands = 4
+	bandBits  = 64 / hashBands
+	bandMask  = 1<<bandBits - 1
+
+	// maxBandRadius bounds the bits a band may differ by for the band
+	// indexes to be used. Beyond it, every band matches too many values to
+	// beat reading the owner's rows.
+	maxBandRadius = 2
+)
+
+// FindSimilarImages returns the owner's images within the query's Hamming
+// distance, closest first. The distance is computed by the database, so
+// only matches leave it. Distances up to hashBands*(maxBandRadius+1)-1 are
+// pre-filtered by the band indexes.
+func (r *Repository) FindSimilarImages(ctx context.Context, ownerID string, query model.SimilarityQuery) ([]model.SimilarImage, error) {
+	const op = "psql.FindSimilarImages"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	column, err := hashColumn(query.Algorithm)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	ctx, end := r.startQuery(ctx, op)
+	defer end()
+
+	// The column comes from hashColumn, never from the caller.
+	distance := "bit_count((" + column + " # $2)::bit(64))"
+
+	args := []any{ownerID, int64(query.Hash), query.ExcludeID, query.MaxDistance, query.Limit}
+
+	// Two hashes at most MaxDistance bits apart have a band at most
+	// MaxDistance/hashBands bits apart, so a match shares a band with one
+	// of the candidates.
+	var bandFilter string
+	if radius := query.MaxDistance / hashBands; radius <= maxBandRadius {
+		filters := make([]string, hashBands)
+		for band := range filters {
+			args = append(args, pq.Array(bandCandidates(query.Hash, band, radius)))
+			filters[band] = bandExpr(column, band) + " = ANY($" + strconv.Itoa(len(args)) + ")"
+		}
+		bandFilter = "AND (" + strings.Join(filters, " OR ") + ")"
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+imageColumns+`, `+distance+` AS distance
+		FROM images
+		WHERE owner_id = $1
+			AND `+column+` IS NOT NULL
+			AND id <> $3
+			`+bandFilter+`
+			AND `+distance+` <= $4
+		ORDER BY distance, id
+		LIMIT $5
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to query images: %w", op, err)
+	}
+	defer rows.Close()
+
+	var matches []model.SimilarImage
+	for rows.Next() {
+		var match model.SimilarImage
+
+		match.Image, err = scanImage(distanceScanner{rows, &match.Distance})
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan image row: %w", op, err)
+		}
+
+		matches = append(matches, match)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: row iteration error: %w", op, err)
+	}
+
+	return matches, nil
+}
+
+func hashColumn(algorithm model.HashAlgorithm) (string, error) {
+	switch algorithm {
+	case model.HashAHash:
+		return "ahash", nil
+	case model.HashDHash:
+		return "dhash", nil
+	case model.HashPHash:
+		return "phash", nil
+	}
+
+	return "", fmt.Errorf("unknown hash algorithm %q", algorithm)
+}
+
+// bandExpr is the indexed expression of a band of column. It must match
+// the expressions of the band indexes.
+func bandExpr(column string, band int) string {
+	return "((" + column + " >> " + strconv.Itoa(band*bandBits) + ") & " + strconv.Itoa(bandMask) + ")"
+}
+
+// bandCandidates returns the values of the band of hash, and every value
+// within radius bits of it.
+func bandCandidates(hash uint64, band, radius int) []int64 {
+	value := uint16(hash >> (band * bandBits))
+
+	var candidates []int64
+	for v := 0; v <= bandMask; v++ {
+		if bits.OnesCount16(uint16(v)^value) <= radius {
+			candidates = append(candidates, int64(v))
+		}
+	}
+
+	return candidates
+}
+
+// distanceScanner scans the distance column that follows the image columns.
+type distanceScanner struct {
+	scanner
+	distance *int
+}
+
+func (s distanceScanner) Scan(dest ...any) error {
+	return s.scanner.Scan(append(dest, s.distance)...)
+}
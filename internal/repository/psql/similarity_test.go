@@ -0,0 +1,79 @@
+package psql
+
+import (
+	"math/bits"
+	"slices"
+	"testing"
+)
+
+func TestBandExpr(t *testing.T) {
+	// The expressions of migration 12's band indexes.
+	want := []string{
+		"((phash >> 0) & 65535)",
+		"((phash >> 16) & 65535)",
+		"((phash >> 32) & 65535)",
+		"((phash >> 48) & 65535)",
+	}
+
+	for band, w := range want {
+		if got := bandExpr("phash", band); got != w {
+			t.Errorf("bandExpr(phash, %d) = %q, want %q", band, got, w)
+		}
+	}
+}
+
+func TestBandCandidates(t *testing.T) {
+	const hash uint64 = 0x8000_00ff_1234_0001
+
+	tests := []struct {
+		band, radius int
+		want         int
+	}{
+		{band: 0, radius: 0, want: 1},
+		{band: 1, radius: 1, want: 17},
+		{band: 2, radius: 2, want: 137},
+		{band: 3, radius: 2, want: 137},
+	}
+
+	for _, tt := range tests {
+		got := bandCandidates(hash, tt.band, tt.radius)
+		if len(got) != tt.want {
+			t.Errorf("bandCandidates(band %d, radius %d) has %d values, want %d", tt.band, tt.radius, len(got), tt.want)
+		}
+
+		value := int64(uint16(hash >> (tt.band * bandBits)))
+		if !slices.Contains(got, value) {
+			t.Errorf("bandCandidates(band %d) lacks the band's own value %#x", tt.band, value)
+		}
+	}
+}
+
+// TestBandCandidates_FindMatches checks the pigeonhole argument the band
+// filter relies on: a hash within the distance shares a band with the
+// candidates.
+func TestBandCandidates_FindMatches(t *testing.T) {
+	const hash uint64 = 0xdead_beef_0123_4567
+
+	for distance := 0; distance < hashBands*(maxBandRadius+1); distance++ {
+		radius := distance / hashBands
+
+		// Spread the flipped bits over the bands as evenly as possible,
+		// the worst case for the filter.
+		other := hash
+		for i := 0; i < distance; i++ {
+			other ^= 1 << ((i%hashBands)*bandBits + i/hashBands)
+		}
+		if got := bits.OnesCount64(hash ^ other); got != distance {
+			t.Fatalf("built a hash %d bits away, want %d", got, distance)
+		}
+
+		found := false
+		for band := 0; band < hashBands; band++ {
+			value := int64(uint16(other >> (band * bandBits)))
+			found = found || slices.Contains(bandCandidates(hash, band, radius), value)
+		}
+		if !found {
+			t.Errorf("a hash %d bits away shares no band with the candidates", distance)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aidosgal/image-processing-service/internal/domain/model"
+	"github.com/aidosgal/image-processing-service/internal/lib/metrics"
+	"github.com/aidosgal/image-processing-service/internal/repository"
+)
+
+func (r *Repository) GetUsage(ctx context.Context, ownerID string) (model.Usage, error) {
+	const op = "psql.GetUsage"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, end := r.startQuery(ctx, op)
+	defer end()
+
+	usage := model.Usage{OwnerID: ownerID}
+
+	err := r.db.QueryRowContext(ctx, `
+		SELECT
+			total_bytes,
+			image_count,
+			date_trunc('month', NOW())::date,
+			CASE WHEN month = date_trunc('month', NOW())::date THEN monthly_upload_bytes ELSE 0 END
+		FROM tenant_usage
+		WHERE owner_id = $1
+	`, ownerID).Scan(
+		&usage.TotalBytes,
+		&usage.ImageCount,
+		&usage.Month,
+		&usage.MonthlyUploadBytes,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		now := time.Now().UTC()
+		usage.Month = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return usage, nil
+	}
+	if err != nil {
+		return usage, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return usage, nil
+}
+
+func (r *Repository) GetQuota(ctx context.Context, ownerID string) (model.Quota, error) {
+	const op = "psql.GetQuota"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, end := r.startQuery(ctx, op)
+	defer end()
+
+	var quota model.Quota
+
+	err := r.db.QueryRowContext(ctx, `
+		SELECT max_total_bytes, max_images, max_monthly_upload_bytes
+		FROM tenant_quotas
+		WHERE owner_id = $1
+	`, ownerID).Scan(
+		&quota.MaxTotalBytes,
+		&quota.MaxImages,
+		&quota.MaxMonthlyUploadBytes,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return quota, fmt.Errorf("%s: %w", op, repository.ErrQuotaNotFound)
+	}
+	if err != nil {
+		return quota, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return quota, nil
+}
+
+func (r *Repository) SetQuota(ctx context.Context, ownerID string, quota model.Quota) error {
+	const op = "psql.SetQuota"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, end := r.startQuery(ctx, op)
+	defer end()
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO tenant_quotas (owner_id, max_total_bytes, max_images, max_monthly_upload_bytes)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (owner_id) DO UPDATE SET
+			max_total_bytes = EXCLUDED.max_total_bytes,
+			max_images = EXCLUDED.max_images,
+			max_monthly_upload_bytes = EXCLUDED.max_monthly_upload_bytes,
+			updated_at = NOW()
+	`, ownerID, quota.MaxTotalBytes, quota.MaxImages, quota.MaxMonthlyUploadBytes)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// lockUsage returns the owner's usage row, creating it if needed, and locks
+// it until the end of tx. Monthly counters of a past month read as zero.
+func lockUsage(ctx context.Context, tx *sql.Tx, ownerID string) (model.Usage, error) {
+	usage := model.Usage{OwnerID: ownerID}
+
+	_, err := tx.ExecContext(ctx,
+		"INSERT INTO tenant_usage (owner_id) VALUES ($1) ON CONFLICT (owner_id) DO NOTHING",
+		ownerID,
+	)
+	if err != nil {
+		return usage, fmt.Errorf("failed to init usage: %w", err)
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		SELECT
+			total_bytes,
+			image_count,
+			date_trunc('month', NOW())::date,
+			CASE WHEN month = date_trunc('month', NOW())::date THEN monthly_upload_bytes ELSE 0 END
+		FROM tenant_usage
+		WHERE owner_id = $1
+		FOR UPDATE
+	`, ownerID).Scan(
+		&usage.TotalBytes,
+		&usage.ImageCount,
+		&usage.Month,
+		&usage.MonthlyUploadBytes,
+	)
+	if err != nil {
+		return usage, fmt.Errorf("failed to lock usage: %w", err)
+	}
+
+	return usage, nil
+}
+
+// GetTotalUsage sums the usage of all tenants.
+func (r *Repository) GetTotalUsage(ctx context.Context) (int64, int64, error) {
+	const op = "psql.GetTotalUsage"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, end := r.startQuery(ctx, op)
+	defer end()
+
+	var bytes, images int64
+	err := r.db.QueryRowContext(ctx,
+		"SELECT COALESCE(SUM(total_bytes), 0), COALESCE(SUM(image_count), 0) FROM tenant_usage",
+	).Scan(&bytes, &images)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return bytes, images, nil
+}
@@ -0,0 +1,10 @@
+package repository
+
+import "errors"
+
+var (
+	ErrImageNotFound = errors.New("image not found")
+	ErrAlbumNotFound = errors.New("album not found")
+	ErrShareNotFound = errors.New("share not found")
+	ErrQuotaNotFound = errors.New("quota not found")
+)
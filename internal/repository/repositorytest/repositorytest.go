@@ -0,0 +1,673 @@
+// Package repositorytest is a conformance suite that every Repository
+// implementation must pass, so the service behaves the same on each of them.
+package repositorytest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aidosgal/image-processing-service/internal/domain/model"
+	"github.com/aidosgal/image-processing-service/internal/lib/permission"
+	"github.com/aidosgal/image-processing-service/internal/repository"
+	service "github.com/aidosgal/image-processing-service/internal/service/image"
+	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
+	"google.golang.org/protobuf/proto"
+)
+
+// Repository is what the service and the app need from a store.
+type Repository interface {
+	service.Repository
+	Ping(ctx context.Context) error
+	GetTotalUsage(ctx context.Context) (int64, int64, error)
+}
+
+// Run runs the suite against the repository returned by newRepository.
+// Every test works with fresh random tenants, so the repository may be
+// shared between tests and hold data of other tenants.
+func Run(t *testing.T, newRepository func(t *testing.T) Repository) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, r Repository)
+	}{
+		{"Ping", testPing},
+		{"StoreAndGetImage", testStoreAndGetImage},
+		{"GetImageOfOtherTenant", testGetImageOfOtherTenant},
+		{"GetAllImagesNewestFirst", testGetAllImagesNewestFirst},
+		{"UpdateCropping", testUpdateCropping},
+		{"DeleteImage", testDeleteImage},
+		{"DeleteImagesByOwner", testDeleteImagesByOwner},
+		{"Usage", testUsage},
+		{"QuotaEnforced", testQuotaEnforced},
+		{"QuotaEnforcedConcurrently", testQuotaEnforcedConcurrently},
+		{"Quotas", testQuotas},
+		{"Albums", testAlbums},
+		{"ShareImage", testShareImage},
+		{"ShareAlbum", testShareAlbum},
+		{"SharesRemovedWithImage", testSharesRemovedWithImage},
+		{"TotalUsage", testTotalUsage},
+		{"FindSimilarImages", testFindSimilarImages},
+		{"FindSimilarImagesByAlgorithm", testFindSimilarImagesByAlgorithm},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newRepository(t))
+		})
+	}
+}
+
+func testPing(t *testing.T, r Repository) {
+	if err := r.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+}
+
+func testStoreAndGetImage(t *testing.T, r Repository) {
+	ctx := context.Background()
+	owner := randomTenant()
+
+	metadata := newImage("photo.jpg", 1000, 200)
+	metadata.Hashes = &imagev1.PerceptualHash{Ahash: 1, Dhash: 1<<63 | 5, Phash: ^uint64(0)}
+	metadata.Colors = &imagev1.ColorSummary{
+		Palette: []*imagev1.PaletteColor{
+			{Color: "#1e3cc8", Fraction: 0.625},
+			{Color: "#f08c14", Fraction: 0.375},
+		},
+		AverageColor: "#7c5c7a",
+		Brightness:   0.4375,
+		Colorfulness: 52.5,
+		Grayscale:    false,
+	}
+	metadata.Placeholder = &imagev1.Placeholder{
+		Blurhash: "LEHV6nWB2yk8pyo0adR*.7kCMdnj",
+		Preview:  "data:image/jpeg;base64,/9j/2wBDAA==",
+	}
+	metadata.FocalPoint = &imagev1.FocalPoint{X: 0.25, Y: 0.625}
+	metadata.CropHints = []*imagev1.CropHint{{AspectRatio: "1:1", X: 0.125, Y: 0, Width: 0.5, Height: 1}}
+	id := storeImage(t, r, owner, metadata)
+
+	got, err := r.GetImageById(ctx, owner, id)
+	if err != nil {
+		t.Fatalf("GetImageById() error = %v", err)
+	}
+
+	want := proto.Clone(metadata).(*imagev1.ImageMetadata)
+	want.ImageId = id
+	want.OwnerId = owner
+	want.Revision = 1
+	if !proto.Equal(got, want) {
+		t.Errorf("GetImageById() = %v, want %v", got, want)
+	}
+
+	if _, err := r.GetImageById(ctx, owner, id+1_000_000); !errors.Is(err, repository.ErrImageNotFound) {
+		t.Errorf("GetImageById(missing) error = %v, want %v", err, repository.ErrImageNotFound)
+	}
+}
+
+func testGetImageOfOtherTenant(t *testing.T, r Repository) {
+	ctx := context.Background()
+	owner, other := randomTenant(), randomTenant()
+
+	id := storeImage(t, r, owner, newImage("photo.jpg", 1000, 0))
+
+	if _, err := r.GetImageById(ctx, other, id); !errors.Is(err, repository.ErrImageNotFound) {
+		t.Errorf("GetImageById() error = %v, want %v", err, repository.ErrImageNotFound)
+	}
+	if _, err := r.DeleteImageById(ctx, other, id); !errors.Is(err, repository.ErrImageNotFound) {
+		t.Errorf("DeleteImageById() error = %v, want %v", err, repository.ErrImageNotFound)
+	}
+	if images, err := r.GetAllImages(ctx, other); err != nil || len(images) != 0 {
+		t.Errorf("GetAllImages() = %d images, %v, want none", len(images), err)
+	}
+}
+
+func testGetAllImagesNewestFirst(t *testing.T, r Repository) {
+	ctx := context.Background()
+	owner := randomTenant()
+
+	var ids []int64
+	for _, name := range []string{"a.jpg", "b.jpg", "c.jpg"} {
+		ids = append(ids, storeImage(t, r, owner, newImage(name, 100, 0)))
+		// Keeps upload timestamps apart on stores with coarse clocks.
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	images, err := r.GetAllImages(ctx, owner)
+	if err != nil {
+		t.Fatalf("GetAllImages() error = %v", err)
+	}
+	if len(images) != len(ids) {
+		t.Fatalf("GetAllImages() = %d images, want %d", len(images), len(ids))
+	}
+	for i, img := range images {
+		if want := ids[len(ids)-1-i]; img.GetImageId() != want {
+			t.Errorf("GetAllImages()[%d] = image %d, want %d", i, img.GetImageId(), want)
+		}
+	}
+}
+
+func testUpdateCropping(t *testing.T, r Repository) {
+	ctx := context.Background()
+	owner, other := randomTenant(), randomTenant()
+
+	id := storeImage(t, r, owner, newImage("photo.jpg", 1000, 200))
+	cropping := repository.Cropping{
+		FocalPoint: &imagev1.FocalPoint{X: 0.125, Y: 0.75, Manual: true},
+		CropHints: []*imagev1.CropHint{
+			{AspectRatio: "1:1", X: 0, Y: 0.25, Width: 0.5, Height: 0.75},
+			{AspectRatio: "16:9", X: 0, Y: 0, Width: 1, Height: 0.5},
+		},
+		VariantsSize: 250,
+	}
+
+	revision, err := r.UpdateCropping(ctx, owner, id, cropping)
+	if err != nil {
+		t.Fatalf("UpdateCropping() error = %v", err)
+	}
+	if revision != 2 {
+		t.Errorf("UpdateCropping() revision = %d, want 2", revision)
+	}
+
+	got, err := r.GetImageById(ctx, owner, id)
+	if err != nil {
+		t.Fatalf("GetImageById() error = %v", err)
+	}
+	if !proto.Equal(got.GetFocalPoint(), cropping.FocalPoint) {
+		t.Errorf("GetImageById() focal point = %v, want %v", got.GetFocalPoint(), cropping.FocalPoint)
+	}
+	if len(got.GetCropHints()) != 2 || !proto.Equal(got.GetCropHints()[1], cropping.CropHints[1]) {
+		t.Errorf("GetImageById() crop hints = %v, want %v", got.GetCropHints(), cropping.CropHints)
+	}
+	if got.GetRevision() != 2 || got.GetVariantsSize() != 250 {
+		t.Errorf("GetImageById() = revision %d, variants size %d, want 2, 250", got.GetRevision(), got.GetVariantsSize())
+	}
+	if usage := getUsage(t, r, owner); usage.TotalBytes != 1250 {
+		t.Errorf("TotalBytes = %d, want 1250", usage.TotalBytes)
+	}
+
+	cleared, err := r.UpdateCropping(ctx, owner, id, repository.Cropping{VariantsSize: 250})
+	if err != nil || cleared != 3 {
+		t.Fatalf("UpdateCropping(cleared) = %d, %v, want 3", cleared, err)
+	}
+	got, err = r.GetImageById(ctx, owner, id)
+	if err != nil {
+		t.Fatalf("GetImageById() error = %v", err)
+	}
+	if got.GetFocalPoint() != nil || len(got.GetCropHints()) != 0 {
+		t.Errorf("GetImageById() = focal point %v, crop hints %v, want none", got.GetFocalPoint(), got.GetCropHints())
+	}
+
+	if _, err := r.UpdateCropping(ctx, other, id, cropping); !errors.Is(err, repository.ErrImageNotFound) {
+		t.Errorf("UpdateCropping(other tenant) error = %v, want %v", err, repository.ErrImageNotFound)
+	}
+}
+
+func testDeleteImage(t *testing.T, r Repository) {
+	ctx := context.Background()
+	owner := randomTenant()
+
+	id := storeImage(t, r, owner, newImage("a.jpg", 1000, 100))
+	storeImage(t, r, owner, newImage("b.jpg", 500, 50))
+
+	deleted, err := r.DeleteImageById(ctx, owner, id)
+	if err != nil || !deleted {
+		t.Fatalf("DeleteImageById() = %v, %v, want true", deleted, err)
+	}
+	if _, err := r.GetImageById(ctx, owner, id); !errors.Is(err, repository.ErrImageNotFound) {
+		t.Errorf("GetImageById() after delete error = %v, want %v", err, repository.ErrImageNotFound)
+	}
+	if _, err := r.DeleteImageById(ctx, owner, id); !errors.Is(err, repository.ErrImageNotFound) {
+		t.Errorf("second DeleteImageById() error = %v, want %v", err, repository.ErrImageNotFound)
+	}
+
+	usage := getUsage(t, r, owner)
+	if usage.TotalBytes != 550 || usage.ImageCount != 1 {
+		t.Errorf("usage = %d bytes, %d images, want 550 bytes, 1 image", usage.TotalBytes, usage.ImageCount)
+	}
+	// Deleting does not give back monthly upload allowance.
+	if usage.MonthlyUploadBytes != 1500 {
+		t.Errorf("monthly upload bytes = %d, want 1500", usage.MonthlyUploadBytes)
+	}
+}
+
+func testDeleteImagesByOwner(t *testing.T, r Repository) {
+	ctx := context.Background()
+	owner, other := randomTenant(), randomTenant()
+
+	storeImage(t, r, owner, newImage("a.jpg", 100, 0))
+	storeImage(t, r, owner, newImage("b.jpg", 100, 0))
+	kept := storeImage(t, r, other, newImage("c.jpg", 100, 0))
+
+	deleted, err := r.DeleteImagesByOwner(ctx, owner)
+	if err != nil {
+		t.Fatalf("DeleteImagesByOwner() error = %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("DeleteImagesByOwner() = %d, want 2", deleted)
+	}
+
+	if images, err := r.GetAllImages(ctx, owner); err != nil || len(images) != 0 {
+		t.Errorf("GetAllImages() = %d images, %v, want none", len(images), err)
+	}
+	if usage := getUsage(t, r, owner); usage.TotalBytes != 0 || usage.ImageCount != 0 {
+		t.Errorf("usage = %d bytes, %d images, want none", usage.TotalBytes, usage.ImageCount)
+	}
+	if _, err := r.GetImageById(ctx, other, kept); err != nil {
+		t.Errorf("GetImageById() of other tenant error = %v", err)
+	}
+}
+
+func testUsage(t *testing.T, r Repository) {
+	owner := randomTenant()
+
+	usage := getUsage(t, r, owner)
+	if usage.TotalBytes != 0 || usage.ImageCount != 0 || usage.MonthlyUploadBytes != 0 {
+		t.Errorf("usage of new tenant = %+v, want zero", usage)
+	}
+
+	now := time.Now().UTC()
+	if usage.Month.Year() != now.Year() || usage.Month.Month() != now.Month() || usage.Month.Day() != 1 {
+		t.Errorf("usage month = %v, want the first day of the current month", usage.Month)
+	}
+
+	storeImage(t, r, owner, newImage("a.jpg", 1000, 200))
+	storeImage(t, r, owner, newImage("b.jpg", 300, 0))
+
+	usage = getUsage(t, r, owner)
+	if usage.TotalBytes != 1500 {
+		t.Errorf("total bytes = %d, want 1500", usage.TotalBytes)
+	}
+	if usage.ImageCount != 2 {
+		t.Errorf("image count = %d, want 2", usage.ImageCount)
+	}
+	if usage.MonthlyUploadBytes != 1300 {
+		t.Errorf("monthly upload bytes = %d, want 1300", usage.MonthlyUploadBytes)
+	}
+}
+
+func testQuotaEnforced(t *testing.T, r Repository) {
+	ctx := context.Background()
+	owner := randomTenant()
+
+	quota := model.Quota{MaxTotalBytes: 1000}
+	if _, err := r.StoreImage(ctx, owner, newImage("a.jpg", 600, 100), quota); err != nil {
+		t.Fatalf("StoreImage() error = %v", err)
+	}
+
+	_, err := r.StoreImage(ctx, owner, newImage("b.jpg", 300, 100), quota)
+	if !errors.Is(err, model.ErrQuotaExceeded) {
+		t.Fatalf("StoreImage() over quota error = %v, want %v", err, model.ErrQuotaExceeded)
+	}
+
+	usage := getUsage(t, r, owner)
+	if usage.TotalBytes != 700 || usage.ImageCount != 1 {
+		t.Errorf("usage = %d bytes, %d images, want 700 bytes, 1 image", usage.TotalBytes, usage.ImageCount)
+	}
+	if images, _ := r.GetAllImages(ctx, owner); len(images) != 1 {
+		t.Errorf("GetAllImages() = %d images, want 1", len(images))
+	}
+}
+
+func testQuotaEnforcedConcurrently(t *testing.T, r Repository) {
+	ctx := context.Background()
+	owner := randomTenant()
+
+	const uploads, limit = 10, 4
+	quota := model.Quota{MaxImages: limit}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, uploads)
+	for range uploads {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := r.StoreImage(ctx, owner, newImage("a.jpg", 100, 0), quota)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	var stored int
+	for err := range errs {
+		switch {
+		case err == nil:
+			stored++
+		case !errors.Is(err, model.ErrQuotaExceeded):
+			t.Errorf("StoreImage() error = %v", err)
+		}
+	}
+
+	if stored != limit {
+		t.Errorf("stored %d images, want %d", stored, limit)
+	}
+	if usage := getUsage(t, r, owner); usage.ImageCount != limit {
+		t.Errorf("image count = %d, want %d", usage.ImageCount, limit)
+	}
+}
+
+func testQuotas(t *testing.T, r Repository) {
+	ctx := context.Background()
+	owner := randomTenant()
+
+	if _, err := r.GetQuota(ctx, owner); !errors.Is(err, repository.ErrQuotaNotFound) {
+		t.Errorf("GetQuota() error = %v, want %v", err, repository.ErrQuotaNotFound)
+	}
+
+	for _, want := range []model.Quota{
+		{MaxTotalBytes: 1 << 20, MaxImages: 10, MaxMonthlyUploadBytes: 1 << 30},
+		{MaxImages: 5},
+	} {
+		if err := r.SetQuota(ctx, owner, want); err != nil {
+			t.Fatalf("SetQuota() error = %v", err)
+		}
+
+		got, err := r.GetQuota(ctx, owner)
+		if err != nil {
+			t.Fatalf("GetQuota() error = %v", err)
+		}
+		if got != want {
+			t.Errorf("GetQuota() = %+v, want %+v", got, want)
+		}
+	}
+}
+
+func testAlbums(t *testing.T, r Repository) {
+	ctx := context.Background()
+	owner, other := randomTenant(), randomTenant()
+
+	albumID, err := r.CreateAlbum(ctx, owner, "holidays")
+	if err != nil {
+		t.Fatalf("CreateAlbum() error = %v", err)
+	}
+	imageID := storeImage(t, r, owner, newImage("a.jpg", 100, 0))
+	otherImageID := storeImage(t, r, other, newImage("b.jpg", 100, 0))
+
+	if err := r.AddImageToAlbum(ctx, owner, albumID, imageID); err != nil {
+		t.Fatalf("AddImageToAlbum() error = %v", err)
+	}
+	if err := r.AddImageToAlbum(ctx, owner, albumID, imageID); err != nil {
+		t.Errorf("AddImageToAlbum() twice error = %v", err)
+	}
+	if err := r.AddImageToAlbum(ctx, other, albumID, otherImageID); !errors.Is(err, repository.ErrAlbumNotFound) {
+		t.Errorf("AddImageToAlbum() to other tenant's album error = %v, want %v", err, repository.ErrAlbumNotFound)
+	}
+	if err := r.AddImageToAlbum(ctx, owner, albumID, otherImageID); !errors.Is(err, repository.ErrImageNotFound) {
+		t.Errorf("AddImageToAlbum() of other tenant's image error = %v, want %v", err, repository.ErrImageNotFound)
+	}
+}
+
+func testShareImage(t *testing.T, r Repository) {
+	ctx := context.Background()
+	owner := randomTenant()
+	grantee := permission.Grantee{Tenant: randomTenant(), Subject: "bob"}
+
+	imageID := storeImage(t, r, owner, newImage("a.jpg", 100, 0))
+
+	if _, _, err := r.GetSharedImage(ctx, grantee, imageID); !errors.Is(err, repository.ErrImageNotFound) {
+		t.Errorf("GetSharedImage() before sharing error = %v, want %v", err, repository.ErrImageNotFound)
+	}
+	if err := r.ShareImage(ctx, grantee.Tenant, imageID, permission.Grantee{Tenant: owner, Subject: "alice"}, permission.LevelRead); !errors.Is(err, repository.ErrImageNotFound) {
+		t.Errorf("ShareImage() by non-owner error = %v, want %v", err, repository.ErrImageNotFound)
+	}
+
+	for _, level := range []permission.Level{permission.LevelRead, permission.LevelWrite} {
+		if err := r.ShareImage(ctx, owner, imageID, grantee, level); err != nil {
+			t.Fatalf("ShareImage(%s) error = %v", level, err)
+		}
+
+		img, got, err := r.GetSharedImage(ctx, grantee, imageID)
+		if err != nil {
+			t.Fatalf("GetSharedImage() error = %v", err)
+		}
+		if got != level {
+			t.Errorf("GetSharedImage() level = %s, want %s", got, level)
+		}
+		if img.GetImageId() != imageID || img.GetOwnerId() != owner {
+			t.Errorf("GetSharedImage() = image %d of %q, want %d of %q", img.GetImageId(), img.GetOwnerId(), imageID, owner)
+		}
+	}
+
+	// Subjects are only unique within a tenant.
+	sameSubject := permission.Grantee{Tenant: randomTenant(), Subject: grantee.Subject}
+	if _, _, err := r.GetSharedImage(ctx, sameSubject, imageID); !errors.Is(err, repository.ErrImageNotFound) {
+		t.Errorf("GetSharedImage() by the same subject in another tenant error = %v, want %v", err, repository.ErrImageNotFound)
+	}
+	if err := r.RevokeImageShare(ctx, owner, imageID, sameSubject); !errors.Is(err, repository.ErrShareNotFound) {
+		t.Errorf("RevokeImageShare() of the same subject in another tenant error = %v, want %v", err, repository.ErrShareNotFound)
+	}
+
+	if err := r.RevokeImageShare(ctx, owner, imageID, grantee); err != nil {
+		t.Fatalf("RevokeImageShare() error = %v", err)
+	}
+	if err := r.RevokeImageShare(ctx, owner, imageID, grantee); !errors.Is(err, repository.ErrShareNotFound) {
+		t.Errorf("second RevokeImageShare() error = %v, want %v", err, repository.ErrShareNotFound)
+	}
+	if _, _, err := r.GetSharedImage(ctx, grantee, imageID); !errors.Is(err, repository.ErrImageNotFound) {
+		t.Errorf("GetSharedImage() after revoke error = %v, want %v", err, repository.ErrImageNotFound)
+	}
+}
+
+func testShareAlbum(t *testing.T, r Repository) {
+	ctx := context.Background()
+	owner := randomTenant()
+	grantee := permission.Grantee{Tenant: randomTenant(), Subject: "bob"}
+
+	imageID := storeImage(t, r, owner, newImage("a.jpg", 100, 0))
+	albumID, err := r.CreateAlbum(ctx, owner, "shared")
+	if err != nil {
+		t.Fatalf("CreateAlbum() error = %v", err)
+	}
+	if err := r.AddImageToAlbum(ctx, owner, albumID, imageID); err != nil {
+		t.Fatalf("AddImageToAlbum() error = %v", err)
+	}
+
+	if err := r.ShareAlbum(ctx, grantee.Tenant, albumID, permission.Grantee{Tenant: owner, Subject: "alice"}, permission.LevelRead); !errors.Is(err, repository.ErrAlbumNotFound) {
+		t.Errorf("ShareAlbum() by non-owner error = %v, want %v", err, repository.ErrAlbumNotFound)
+	}
+
+	if err := r.ShareImage(ctx, owner, imageID, grantee, permission.LevelRead); err != nil {
+		t.Fatalf("ShareImage() error = %v", err)
+	}
+	if err := r.ShareAlbum(ctx, owner, albumID, grantee, permission.LevelWrite); err != nil {
+		t.Fatalf("ShareAlbum() error = %v", err)
+	}
+
+	// The highest of the direct and the album grant wins.
+	if _, level, err := r.GetSharedImage(ctx, grantee, imageID); err != nil || level != permission.LevelWrite {
+		t.Errorf("GetSharedImage() = %s, %v, want %s", level, err, permission.LevelWrite)
+	}
+
+	if err := r.RevokeAlbumShare(ctx, owner, albumID, grantee); err != nil {
+		t.Fatalf("RevokeAlbumShare() error = %v", err)
+	}
+	if err := r.RevokeAlbumShare(ctx, owner, albumID, grantee); !errors.Is(err, repository.ErrShareNotFound) {
+		t.Errorf("second RevokeAlbumShare() error = %v, want %v", err, repository.ErrShareNotFound)
+	}
+	if _, level, err := r.GetSharedImage(ctx, grantee, imageID); err != nil || level != permission.LevelRead {
+		t.Errorf("GetSharedImage() after album revoke = %s, %v, want %s", level, err, permission.LevelRead)
+	}
+}
+
+func testSharesRemovedWithImage(t *testing.T, r Repository) {
+	ctx := context.Background()
+	owner := randomTenant()
+	grantee := permission.Grantee{Tenant: randomTenant(), Subject: "bob"}
+
+	imageID := storeImage(t, r, owner, newImage("a.jpg", 100, 0))
+	if err := r.ShareImage(ctx, owner, imageID, grantee, permission.LevelRead); err != nil {
+		t.Fatalf("ShareImage() error = %v", err)
+	}
+
+	if _, err := r.DeleteImageById(ctx, owner, imageID); err != nil {
+		t.Fatalf("DeleteImageById() error = %v", err)
+	}
+
+	if _, _, err := r.GetSharedImage(ctx, grantee, imageID); !errors.Is(err, repository.ErrImageNotFound) {
+		t.Errorf("GetSharedImage() after delete error = %v, want %v", err, repository.ErrImageNotFound)
+	}
+	if err := r.RevokeImageShare(ctx, owner, imageID, grantee); !errors.Is(err, repository.ErrShareNotFound) {
+		t.Errorf("RevokeImageShare() after delete error = %v, want %v", err, repository.ErrShareNotFound)
+	}
+}
+
+func testTotalUsage(t *testing.T, r Repository) {
+	ctx := context.Background()
+
+	bytesBefore, imagesBefore, err := r.GetTotalUsage(ctx)
+	if err != nil {
+		t.Fatalf("GetTotalUsage() error = %v", err)
+	}
+
+	storeImage(t, r, randomTenant(), newImage("a.jpg", 1000, 24))
+	storeImage(t, r, randomTenant(), newImage("b.jpg", 2000, 0))
+
+	bytesAfter, imagesAfter, err := r.GetTotalUsage(ctx)
+	if err != nil {
+		t.Fatalf("GetTotalUsage() error = %v", err)
+	}
+	// Other tests may store images concurrently on a shared database.
+	if bytesAfter-bytesBefore < 3024 || imagesAfter-imagesBefore < 2 {
+		t.Errorf("GetTotalUsage() grew by %d bytes, %d images, want at least 3024 bytes, 2 images",
+			bytesAfter-bytesBefore, imagesAfter-imagesBefore)
+	}
+}
+
+func testFindSimilarImages(t *testing.T, r Repository) {
+	ctx := context.Background()
+	owner := randomTenant()
+
+	source := storeImage(t, r, owner, hashedImage("source.jpg", 0))
+	near := storeImage(t, r, owner, hashedImage("near.jpg", 0b1))
+	nearer := storeImage(t, r, owner, hashedImage("nearer.jpg", 0b11))
+	// Only the top bit differs, which a signed column must keep.
+	top := storeImage(t, r, owner, hashedImage("top.jpg", 1<<63))
+	storeImage(t, r, owner, hashedImage("far.jpg", 0xffff))
+	storeImage(t, r, owner, newImage("unhashed.jpg", 100, 0))
+	storeImage(t, r, randomTenant(), hashedImage("other.jpg", 0))
+
+	matches, err := r.FindSimilarImages(ctx, owner, model.SimilarityQuery{
+		Algorithm:   model.HashPHash,
+		Hash:        0,
+		MaxDistance: 2,
+		Limit:       10,
+		ExcludeID:   source,
+	})
+	if err != nil {
+		t.Fatalf("FindSimilarImages() error = %v", err)
+	}
+
+	want := []struct {
+		id       int64
+		distance int
+	}{{near, 1}, {top, 1}, {nearer, 2}}
+	if len(matches) != len(want) {
+		t.Fatalf("FindSimilarImages() = %d matches, want %d", len(matches), len(want))
+	}
+	for i, w := range want {
+		if matches[i].Image.GetImageId() != w.id || matches[i].Distance != w.distance {
+			t.Errorf("FindSimilarImages()[%d] = image %d at %d, want image %d at %d",
+				i, matches[i].Image.GetImageId(), matches[i].Distance, w.id, w.distance)
+		}
+	}
+	if got := matches[1].Image.GetHashes().GetPhash(); got != 1<<63 {
+		t.Errorf("FindSimilarImages()[1] phash = %#x, want %#x", got, uint64(1<<63))
+	}
+
+	matches, err = r.FindSimilarImages(ctx, owner, model.SimilarityQuery{
+		Algorithm:   model.HashPHash,
+		Hash:        0,
+		MaxDistance: 2,
+		Limit:       2,
+	})
+	if err != nil {
+		t.Fatalf("FindSimilarImages(limit) error = %v", err)
+	}
+	if len(matches) != 2 || matches[0].Image.GetImageId() != source || matches[0].Distance != 0 {
+		t.Errorf("FindSimilarImages(limit) = %v, want the source first and 2 matches", matches)
+	}
+}
+
+func testFindSimilarImagesByAlgorithm(t *testing.T, r Repository) {
+	ctx := context.Background()
+	owner := randomTenant()
+
+	img := newImage("photo.jpg", 100, 0)
+	img.Hashes = &imagev1.PerceptualHash{Ahash: 0xa, Dhash: 0xd, Phash: 0xf}
+	id := storeImage(t, r, owner, img)
+
+	for algorithm, hash := range map[model.HashAlgorithm]uint64{
+		model.HashAHash: 0xa,
+		model.HashDHash: 0xd,
+		model.HashPHash: 0xf,
+	} {
+		matches, err := r.FindSimilarImages(ctx, owner, model.SimilarityQuery{
+			Algorithm: algorithm,
+			Hash:      hash,
+			Limit:     10,
+		})
+		if err != nil {
+			t.Fatalf("FindSimilarImages(%s) error = %v", algorithm, err)
+		}
+		if len(matches) != 1 || matches[0].Image.GetImageId() != id {
+			t.Errorf("FindSimilarImages(%s) = %v, want image %d", algorithm, matches, id)
+		}
+	}
+}
+
+func storeImage(t *testing.T, r Repository, owner string, metadata *imagev1.ImageMetadata) int64 {
+	t.Helper()
+
+	id, err := r.StoreImage(context.Background(), owner, metadata, model.Quota{})
+	if err != nil {
+		t.Fatalf("StoreImage() error = %v", err)
+	}
+
+	return id
+}
+
+func getUsage(t *testing.T, r Repository, owner string) model.Usage {
+	t.Helper()
+
+	usage, err := r.GetUsage(context.Background(), owner)
+	if err != nil {
+		t.Fatalf("GetUsage() error = %v", err)
+	}
+
+	return usage
+}
+
+func newImage(filename string, size, variantsSize int64) *imagev1.ImageMetadata {
+	return &imagev1.ImageMetadata{
+		Filename:      filename,
+		FileSize:      size,
+		MimeType:      "image/jpeg",
+		Width:         640,
+		Height:        480,
+		FilePath:      "uploads/images/" + filename,
+		ThumbnailPath: "uploads/thumbnails/thumb_" + filename,
+		ImageFormat:   "jpg",
+		VariantsSize:  variantsSize,
+	}
+}
+
+func hashedImage(filename string, phash uint64) *imagev1.ImageMetadata {
+	img := newImage(filename, 100, 0)
+	img.Hashes = &imagev1.PerceptualHash{Phash: phash}
+
+	return img
+}
+
+func randomTenant() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	return "conformance-" + hex.EncodeToString(b)
+}
@@ -0,0 +1,65 @@
+CREATE TABLE IF NOT EXISTS images (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    owner_id TEXT NOT NULL,
+    filename TEXT NOT NULL,
+    file_size INTEGER NOT NULL,
+    mime_type TEXT NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    uploaded_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    updated_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    file_path TEXT NOT NULL,
+    thumbnail_path TEXT,
+    image_format TEXT NOT NULL,
+    tags TEXT,
+    variants_size INTEGER NOT NULL DEFAULT 0,
+    revision INTEGER NOT NULL DEFAULT 1
+);
+CREATE INDEX IF NOT EXISTS idx_images_owner_id_uploaded_at ON images (owner_id, uploaded_at DESC);
+
+CREATE TABLE IF NOT EXISTS albums (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    owner_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE INDEX IF NOT EXISTS idx_albums_owner_id ON albums (owner_id);
+
+CREATE TABLE IF NOT EXISTS album_images (
+    album_id INTEGER NOT NULL REFERENCES albums (id) ON DELETE CASCADE,
+    image_id INTEGER NOT NULL REFERENCES images (id) ON DELETE CASCADE,
+    PRIMARY KEY (album_id, image_id)
+);
+CREATE INDEX IF NOT EXISTS idx_album_images_image_id ON album_images (image_id);
+
+CREATE TABLE IF NOT EXISTS shares (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    owner_id TEXT NOT NULL,
+    image_id INTEGER REFERENCES images (id) ON DELETE CASCADE,
+    album_id INTEGER REFERENCES albums (id) ON DELETE CASCADE,
+    grantee TEXT NOT NULL,
+    permission TEXT NOT NULL CHECK (permission IN ('read', 'write')),
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    CHECK ((image_id IS NULL) <> (album_id IS NULL))
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shares_image_grantee ON shares (image_id, grantee) WHERE image_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shares_album_grantee ON shares (album_id, grantee) WHERE album_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_shares_grantee ON shares (grantee);
+
+CREATE TABLE IF NOT EXISTS tenant_usage (
+    owner_id TEXT PRIMARY KEY,
+    total_bytes INTEGER NOT NULL DEFAULT 0,
+    image_count INTEGER NOT NULL DEFAULT 0,
+    -- First day of the month counted by monthly_upload_bytes, as YYYY-MM-DD.
+    month TEXT NOT NULL DEFAULT (strftime('%Y-%m-01', 'now')),
+    monthly_upload_bytes INTEGER NOT NULL DEFAULT 0,
+    updated_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE TABLE IF NOT EXISTS tenant_quotas (
+    owner_id TEXT PRIMARY KEY,
+    max_total_bytes INTEGER NOT NULL DEFAULT 0,
+    max_images INTEGER NOT NULL DEFAULT 0,
+    max_monthly_upload_bytes INTEGER NOT NULL DEFAULT 0,
+    updated_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
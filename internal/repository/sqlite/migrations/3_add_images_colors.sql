@@ -0,0 +1,7 @@
+-- Color summary extracted at upload, the palette as a JSON array. NULL for
+-- images stored before it was.
+ALTER TABLE images ADD COLUMN palette TEXT;
+ALTER TABLE images ADD COLUMN average_color TEXT;
+ALTER TABLE images ADD COLUMN brightness REAL;
+ALTER TABLE images ADD COLUMN colorfulness REAL;
+ALTER TABLE images ADD COLUMN grayscale INTEGER;
@@ -0,0 +1,3 @@
+-- Placeholder generated at upload. NULL for images stored before it was.
+ALTER TABLE images ADD COLUMN blurhash TEXT;
+ALTER TABLE images ADD COLUMN preview TEXT;
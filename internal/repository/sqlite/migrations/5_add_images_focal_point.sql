@@ -0,0 +1,5 @@
+-- Focal point found at upload or set by SetFocalPoint. NULL for images
+-- stored before focal points were found.
+ALTER TABLE images ADD COLUMN focal_x REAL;
+ALTER TABLE images ADD COLUMN focal_y REAL;
+ALTER TABLE images ADD COLUMN focal_manual INTEGER NOT NULL DEFAULT 0;
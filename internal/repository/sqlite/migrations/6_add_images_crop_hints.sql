@@ -0,0 +1,2 @@
+-- Crop hints set by SetCropHints, a JSON array. NULL when there are none.
+ALTER TABLE images ADD COLUMN crop_hints TEXT;
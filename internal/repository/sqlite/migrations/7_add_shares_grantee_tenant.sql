@@ -0,0 +1,11 @@
+-- Subjects are only unique within a tenant, so shares name the grantee's
+-- tenant too. Existing shares are kept for principals of the owner's tenant.
+ALTER TABLE shares ADD COLUMN grantee_tenant TEXT NOT NULL DEFAULT '';
+UPDATE shares SET grantee_tenant = owner_id;
+
+DROP INDEX IF EXISTS idx_shares_image_grantee;
+DROP INDEX IF EXISTS idx_shares_album_grantee;
+DROP INDEX IF EXISTS idx_shares_grantee;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shares_image_grantee ON shares (image_id, grantee_tenant, grantee) WHERE image_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shares_album_grantee ON shares (album_id, grantee_tenant, grantee) WHERE album_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_shares_grantee ON shares (grantee_tenant, grantee);
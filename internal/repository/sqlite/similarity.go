@@ -0,0 +1,101 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"math/bits"
+	"time"
+
+	"github.com/aidosgal/image-processing-service/internal/domain/model"
+	"github.com/aidosgal/image-processing-service/internal/lib/metrics"
+	"modernc.org/sqlite"
+)
+
+// SQLite has no bit count, so the Hamming distance between two hash columns
+// is a Go function available to every connection.
+func init() {
+	sqlite.MustRegisterDeterministicScalarFunction("hamming_distance", 2,
+		func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+			a, okA := args[0].(int64)
+			b, okB := args[1].(int64)
+			if !okA || !okB {
+				return nil, nil
+			}
+
+			return int64(bits.OnesCount64(uint64(a ^ b))), nil
+		},
+	)
+}
+
+// FindSimilarImages returns the owner's images within the query's Hamming
+// distance, closest first.
+func (r *Repository) FindSimilarImages(ctx context.Context, ownerID string, query model.SimilarityQuery) ([]model.SimilarImage, error) {
+	const op = "sqlite.FindSimilarImages"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	column, err := hashColumn(query.Algorithm)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	ctx, end := r.startQuery(ctx, op)
+	defer end()
+
+	// The column comes from hashColumn, never from the caller.
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+imageColumns+`, hamming_distance(`+column+`, ?) AS distance
+		FROM images
+		WHERE owner_id = ?
+			AND `+column+` IS NOT NULL
+			AND id <> ?
+			AND distance <= ?
+		ORDER BY distance, id
+		LIMIT ?
+	`, int64(query.Hash), ownerID, query.ExcludeID, query.MaxDistance, query.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to query images: %w", op, err)
+	}
+	defer rows.Close()
+
+	var matches []model.SimilarImage
+	for rows.Next() {
+		var match model.SimilarImage
+
+		match.Image, err = scanImage(distanceScanner{rows, &match.Distance})
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan image row: %w", op, err)
+		}
+
+		matches = append(matches, match)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: row iteration error: %w", op, err)
+	}
+
+	return matches, nil
+}
+
+func hashColumn(algorithm model.HashAlgorithm) (string, error) {
+	switch algorithm {
+	case model.HashAHash:
+		return "ahash", nil
+	case model.HashDHash:
+		return "dhash", nil
+	case model.HashPHash:
+		return "phash", nil
+	}
+
+	return "", fmt.Errorf("unknown hash algorithm %q", algorithm)
+}
+
+// distanceScanner scans the distance column that follows the image columns.
+type distanceScanner struct {
+	scanner
+	distance *int
+}
+
+func (s distanceScanner) Scan(dest ...any) error {
+	return s.scanner.Scan(append(dest, s.distance)...)
+}
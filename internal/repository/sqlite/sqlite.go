@@ -0,0 +1,952 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aidosgal/image-processing-service/internal/config"
+	"github.com/aidosgal/image-processing-service/internal/domain/model"
+	"github.com/aidosgal/image-processing-service/internal/lib/logger"
+	"github.com/aidosgal/image-processing-service/internal/lib/metrics"
+	"github.com/aidosgal/image-processing-service/internal/lib/permission"
+	"github.com/aidosgal/image-processing-service/internal/lib/tracing"
+	"github.com/aidosgal/image-processing-service/internal/repository"
+	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
+	"go.opentelemetry.io/otel/attribute"
+	_ "modernc.org/sqlite"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// Repository stores everything in a single SQLite file, for deployments
+// without Postgres. Writes are serialized by SQLite; reads run concurrently.
+type Repository struct {
+	db           *sql.DB
+	queryTimeout time.Duration
+}
+
+// NewRepository opens the database file at cfg.SQLitePath, creating it if
+// needed, and applies the embedded migrations.
+func NewRepository(ctx context.Context, cfg config.DatabaseConfig) (*Repository, error) {
+	const op = "sqlite.NewRepository"
+
+	if err := os.MkdirAll(filepath.Dir(cfg.SQLitePath), os.ModePerm); err != nil {
+		return nil, fmt.Errorf("%s: failed to create database directory: %w", op, err)
+	}
+
+	// Immediate transactions take the write lock up front, so the usage
+	// read in StoreImage cannot go stale before the update.
+	dsn := (&url.URL{
+		Scheme: "file",
+		Opaque: cfg.SQLitePath,
+		RawQuery: url.Values{
+			"_pragma": {"foreign_keys(1)", "busy_timeout(5000)", "journal_mode(WAL)"},
+			"_txlock": {"immediate"},
+		}.Encode(),
+	}).String()
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to open database: %w", op, err)
+	}
+
+	if err := migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Repository{db: db, queryTimeout: cfg.QueryTimeout}, nil
+}
+
+// migrate applies the embedded migrations newer than the recorded version,
+// each in its own transaction.
+func migrate(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			applied_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	var current int
+	err = db.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&current)
+	if err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	entries, err := fs.ReadDir(migrations, "migrations")
+	if err != nil {
+		return fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	versions := make(map[int]string, len(entries))
+	for _, entry := range entries {
+		prefix, _, _ := strings.Cut(entry.Name(), "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return fmt.Errorf("invalid migration name %q", entry.Name())
+		}
+		versions[version] = entry.Name()
+	}
+
+	ordered := make([]int, 0, len(versions))
+	for version := range versions {
+		ordered = append(ordered, version)
+	}
+	sort.Ints(ordered)
+
+	for _, version := range ordered {
+		if version <= current {
+			continue
+		}
+
+		if err := applyMigration(ctx, db, version, versions[version]); err != nil {
+			return fmt.Errorf("failed to apply migration %s: %w", versions[version], err)
+		}
+	}
+
+	return nil
+}
+
+func applyMigration(ctx context.Context, db *sql.DB, version int, name string) error {
+	script, err := migrations.ReadFile("migrations/" + name)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer rollback(ctx, tx)
+
+	if _, err := tx.ExecContext(ctx, string(script)); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version) VALUES (?)", version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Ping checks that the database file is usable.
+func (r *Repository) Ping(ctx context.Context) error {
+	const op = "sqlite.Ping"
+
+	if err := r.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Close closes the database.
+func (r *Repository) Close() error {
+	const op = "sqlite.Close"
+
+	if err := r.db.Close(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// startQuery starts the span of a repository call and bounds the call by
+// the query timeout.
+func (r *Repository) startQuery(ctx context.Context, op string) (context.Context, func()) {
+	ctx, span := tracing.Start(ctx, op, attribute.String("db.system", "sqlite"))
+	if r.queryTimeout <= 0 {
+		return ctx, func() { span.End() }
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.queryTimeout)
+	return ctx, func() {
+		cancel()
+		span.End()
+	}
+}
+
+func rollback(ctx context.Context, tx *sql.Tx) {
+	if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		logger.FromContext(ctx, slog.Default()).Warn("failed to roll back transaction", slog.String("error", err.Error()))
+	}
+}
+
+const imageColumns = `
+	id,
+	owner_id,
+	filename,
+	file_size,
+	mime_type,
+	width,
+	height,
+	file_path,
+	thumbnail_path,
+	image_format,
+	variants_size,
+	revision,
+	ahash,
+	dhash,
+	phash,
+	palette,
+	average_color,
+	brightness,
+	colorfulness,
+	grayscale,
+	blurhash,
+	preview,
+	focal_x,
+	focal_y,
+	focal_manual,
+	crop_hints
+`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanImage(row scanner) (*imagev1.ImageMetadata, error) {
+	var img imagev1.ImageMetadata
+	var ahash, dhash, phash sql.NullInt64
+	var colors repository.ColorColumns
+	var placeholder repository.PlaceholderColumns
+	var focal repository.FocalColumns
+	var cropHints repository.CropHintColumns
+
+	dest := []any{
+		&img.ImageId,
+		&img.OwnerId,
+		&img.Filename,
+		&img.FileSize,
+		&img.MimeType,
+		&img.Width,
+		&img.Height,
+		&img.FilePath,
+		&img.ThumbnailPath,
+		&img.ImageFormat,
+		&img.VariantsSize,
+		&img.Revision,
+		&ahash,
+		&dhash,
+		&phash,
+	}
+	dest = append(dest, colors.Dest()...)
+	dest = append(dest, placeholder.Dest()...)
+	dest = append(dest, focal.Dest()...)
+	if err := row.Scan(append(dest, cropHints.Dest()...)...); err != nil {
+		return nil, err
+	}
+
+	if ahash.Valid && dhash.Valid && phash.Valid {
+		img.Hashes = &imagev1.PerceptualHash{
+			Ahash: uint64(ahash.Int64),
+			Dhash: uint64(dhash.Int64),
+			Phash: uint64(phash.Int64),
+		}
+	}
+
+	summary, err := colors.Summary()
+	if err != nil {
+		return nil, err
+	}
+	img.Colors = summary
+	img.Placeholder = placeholder.Placeholder()
+	img.FocalPoint = focal.FocalPoint()
+	img.CropHints, err = cropHints.CropHints()
+	if err != nil {
+		return nil, err
+	}
+
+	return &img, nil
+}
+
+// hashColumns converts the hashes to the signed INTEGER columns, all NULL
+// when the image has none.
+func hashColumns(h *imagev1.PerceptualHash) (ahash, dhash, phash sql.NullInt64) {
+	if h == nil {
+		return
+	}
+
+	return sql.NullInt64{Int64: int64(h.GetAhash()), Valid: true},
+		sql.NullInt64{Int64: int64(h.GetDhash()), Valid: true},
+		sql.NullInt64{Int64: int64(h.GetPhash()), Valid: true}
+}
+
+// StoreImage inserts the image and charges it to the owner's usage in one
+// transaction. The transaction holds the write lock from the start, so
+// concurrent uploads cannot exceed the quota.
+func (r *Repository) StoreImage(ctx context.Context, ownerID string, metadata *imagev1.ImageMetadata, quota model.Quota) (int64, error) {
+	const op = "sqlite.StoreImage"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, end := r.startQuery(ctx, op)
+	defer end()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer rollback(ctx, tx)
+
+	month := currentMonth()
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO tenant_usage (owner_id, month) VALUES (?, ?) ON CONFLICT (owner_id) DO NOTHING",
+		ownerID, month.Format(time.DateOnly),
+	)
+	if err != nil {
+		return -1, fmt.Errorf("%s: failed to init usage: %w", op, err)
+	}
+
+	usage := model.Usage{OwnerID: ownerID, Month: month}
+	err = tx.QueryRowContext(ctx, `
+		SELECT
+			total_bytes,
+			image_count,
+			CASE WHEN month = ? THEN monthly_upload_bytes ELSE 0 END
+		FROM tenant_usage
+		WHERE owner_id = ?
+	`, month.Format(time.DateOnly), ownerID).Scan(
+		&usage.TotalBytes,
+		&usage.ImageCount,
+		&usage.MonthlyUploadBytes,
+	)
+	if err != nil {
+		return -1, fmt.Errorf("%s: failed to read usage: %w", op, err)
+	}
+
+	storedBytes := metadata.GetFileSize() + metadata.GetVariantsSize()
+	if err := quota.Check(usage, metadata.GetFileSize(), storedBytes); err != nil {
+		return -1, fmt.Errorf("%s: %w", op, err)
+	}
+
+	ahash, dhash, phash := hashColumns(metadata.GetHashes())
+	colors, err := repository.NewColorColumns(metadata.GetColors())
+	if err != nil {
+		return -1, fmt.Errorf("%s: %w", op, err)
+	}
+	placeholder := repository.NewPlaceholderColumns(metadata.GetPlaceholder())
+	focal := repository.NewFocalColumns(metadata.GetFocalPoint())
+	cropHints, err := repository.NewCropHintColumns(metadata.GetCropHints())
+	if err != nil {
+		return -1, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var imageID int64
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO images (
+			owner_id,
+			filename,
+			file_size,
+			mime_type,
+			width,
+			height,
+			file_path,
+			thumbnail_path,
+			image_format,
+			variants_size,
+			ahash,
+			dhash,
+			phash,
+			palette,
+			average_color,
+			brightness,
+			colorfulness,
+			grayscale,
+			blurhash,
+			preview,
+			focal_x,
+			focal_y,
+			focal_manual,
+			crop_hints
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`, ownerID,
+		metadata.GetFilename(),
+		metadata.GetFileSize(),
+		metadata.GetMimeType(),
+		metadata.GetWidth(),
+		metadata.GetHeight(),
+		metadata.GetFilePath(),
+		metadata.GetThumbnailPath(),
+		metadata.GetImageFormat(),
+		metadata.GetVariantsSize(),
+		ahash,
+		dhash,
+		phash,
+		colors.Palette,
+		colors.AverageColor,
+		colors.Brightness,
+		colors.Colorfulness,
+		colors.Grayscale,
+		placeholder.Blurhash,
+		placeholder.Preview,
+		focal.X,
+		focal.Y,
+		focal.Manual,
+		cropHints.Hints,
+	).Scan(&imageID)
+	if err != nil {
+		return -1, fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE tenant_usage SET
+			total_bytes = total_bytes + ?,
+			image_count = image_count + 1,
+			monthly_upload_bytes = ?,
+			month = ?,
+			updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now')
+		WHERE owner_id = ?
+	`, storedBytes, usage.MonthlyUploadBytes+metadata.GetFileSize(), month.Format(time.DateOnly), ownerID)
+	if err != nil {
+		return -1, fmt.Errorf("%s: failed to update usage: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return -1, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return imageID, nil
+}
+
+func (r *Repository) GetAllImages(ctx context.Context, ownerID string) ([]*imagev1.ImageMetadata, error) {
+	const op = "sqlite.GetAllImages"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, end := r.startQuery(ctx, op)
+	defer end()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+imageColumns+`
+		FROM images
+		WHERE owner_id = ?
+		ORDER BY uploaded_at DESC, id DESC
+	`, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to query images: %w", op, err)
+	}
+	defer rows.Close()
+
+	var images []*imagev1.ImageMetadata
+	for rows.Next() {
+		img, err := scanImage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to scan image row: %w", op, err)
+		}
+
+		images = append(images, img)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: row iteration error: %w", op, err)
+	}
+
+	return images, nil
+}
+
+func (r *Repository) GetImageById(ctx context.Context, ownerID string, imageID int64) (*imagev1.ImageMetadata, error) {
+	const op = "sqlite.GetImageById"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, end := r.startQuery(ctx, op)
+	defer end()
+
+	img, err := scanImage(r.db.QueryRowContext(ctx, `
+		SELECT `+imageColumns+`
+		FROM images
+		WHERE id = ? AND owner_id = ?
+	`, imageID, ownerID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s: %w", op, repository.ErrImageNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to retrieve image: %w", op, err)
+	}
+
+	return img, nil
+}
+
+// UpdateCropping replaces the focal point, crop hints and variants size of
+// the image and bumps its revision, returning the new one. The owner's usage
+// follows the change in variants size.
+func (r *Repository) UpdateCropping(ctx context.Context, ownerID string, imageID int64, cropping repository.Cropping) (int64, error) {
+	const op = "sqlite.UpdateCropping"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, end := r.startQuery(ctx, op)
+	defer end()
+
+	focal := repository.NewFocalColumns(cropping.FocalPoint)
+	cropHints, err := repository.NewCropHintColumns(cropping.CropHints)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer rollback(ctx, tx)
+
+	var variantsSize int64
+	err = tx.QueryRowContext(ctx,
+		"SELECT variants_size FROM images WHERE id = ? AND owner_id = ?",
+		imageID, ownerID,
+	).Scan(&variantsSize)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%s: %w", op, repository.ErrImageNotFound)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to read image: %w", op, err)
+	}
+
+	var revision int64
+	err = tx.QueryRowContext(ctx, `
+		UPDATE images SET
+			focal_x = ?,
+			focal_y = ?,
+			focal_manual = ?,
+			crop_hints = ?,
+			variants_size = ?,
+			revision = revision + 1,
+			updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now')
+		WHERE id = ?
+		RETURNING revision
+	`, focal.X, focal.Y, focal.Manual, cropHints.Hints, cropping.VariantsSize, imageID).Scan(&revision)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to update image: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE tenant_usage SET
+			total_bytes = MAX(total_bytes + ?, 0),
+			updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now')
+		WHERE owner_id = ?
+	`, cropping.VariantsSize-variantsSize, ownerID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to update usage: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return revision, nil
+}
+
+func (r *Repository) DeleteImageById(ctx context.Context, ownerID string, imageID int64) (bool, error) {
+	const op = "sqlite.DeleteImageById"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, end := r.startQuery(ctx, op)
+	defer end()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer rollback(ctx, tx)
+
+	var storedBytes int64
+	err = tx.QueryRowContext(ctx, `
+		DELETE FROM images
+		WHERE id = ? AND owner_id = ?
+		RETURNING file_size + variants_size
+	`, imageID, ownerID).Scan(&storedBytes)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, fmt.Errorf("%s: %w", op, repository.ErrImageNotFound)
+	}
+	if err != nil {
+		return false, fmt.Errorf("%s: failed to delete image record: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE tenant_usage SET
+			total_bytes = MAX(total_bytes - ?, 0),
+			image_count = MAX(image_count - 1, 0),
+			updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now')
+		WHERE owner_id = ?
+	`, storedBytes, ownerID)
+	if err != nil {
+		return false, fmt.Errorf("%s: failed to update usage: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return true, nil
+}
+
+func (r *Repository) DeleteImagesByOwner(ctx context.Context, ownerID string) (int64, error) {
+	const op = "sqlite.DeleteImagesByOwner"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, end := r.startQuery(ctx, op)
+	defer end()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer rollback(ctx, tx)
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM images WHERE owner_id = ?", ownerID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to delete images: %w", op, err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to verify deletion: %w", op, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE tenant_usage SET total_bytes = 0, image_count = 0, updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now')
+		WHERE owner_id = ?
+	`, ownerID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to update usage: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return deleted, nil
+}
+
+func (r *Repository) CreateAlbum(ctx context.Context, ownerID string, name string) (int64, error) {
+	const op = "sqlite.CreateAlbum"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, end := r.startQuery(ctx, op)
+	defer end()
+
+	var albumID int64
+	err := r.db.QueryRowContext(ctx,
+		"INSERT INTO albums (owner_id, name) VALUES (?, ?) RETURNING id",
+		ownerID, name,
+	).Scan(&albumID)
+	if err != nil {
+		return -1, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return albumID, nil
+}
+
+func (r *Repository) AddImageToAlbum(ctx context.Context, ownerID string, albumID int64, imageID int64) error {
+	const op = "sqlite.AddImageToAlbum"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, end := r.startQuery(ctx, op)
+	defer end()
+
+	if err := r.checkAlbumOwner(ctx, ownerID, albumID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := r.checkImageOwner(ctx, ownerID, imageID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO album_images (album_id, image_id) VALUES (?, ?)
+		ON CONFLICT DO NOTHING
+	`, albumID, imageID)
+	if err != nil {
+		return fmt.Errorf("%s: failed to add image to album: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *Repository) ShareImage(ctx context.Context, ownerID string, imageID int64, grantee permission.Grantee, level permission.Level) error {
+	const op = "sqlite.ShareImage"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, end := r.startQuery(ctx, op)
+	defer end()
+
+	if err := r.checkImageOwner(ctx, ownerID, imageID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO shares (owner_id, image_id, grantee_tenant, grantee, permission) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (image_id, grantee_tenant, grantee) WHERE image_id IS NOT NULL
+		DO UPDATE SET permission = excluded.permission
+	`, ownerID, imageID, grantee.Tenant, grantee.Subject, level.String())
+	if err != nil {
+		return fmt.Errorf("%s: failed to share image: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *Repository) ShareAlbum(ctx context.Context, ownerID string, albumID int64, grantee permission.Grantee, level permission.Level) error {
+	const op = "sqlite.ShareAlbum"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, end := r.startQuery(ctx, op)
+	defer end()
+
+	if err := r.checkAlbumOwner(ctx, ownerID, albumID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO shares (owner_id, album_id, grantee_tenant, grantee, permission) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (album_id, grantee_tenant, grantee) WHERE album_id IS NOT NULL
+		DO UPDATE SET permission = excluded.permission
+	`, ownerID, albumID, grantee.Tenant, grantee.Subject, level.String())
+	if err != nil {
+		return fmt.Errorf("%s: failed to share album: %w", op, err)
+	}
+
+	return nil
+}
+
+func (r *Repository) RevokeImageShare(ctx context.Context, ownerID string, imageID int64, grantee permission.Grantee) error {
+	const op = "sqlite.RevokeImageShare"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, end := r.startQuery(ctx, op)
+	defer end()
+
+	result, err := r.db.ExecContext(ctx,
+		"DELETE FROM shares WHERE owner_id = ? AND image_id = ? AND grantee_tenant = ? AND grantee = ?",
+		ownerID, imageID, grantee.Tenant, grantee.Subject,
+	)
+
+	return checkRevoked(op, result, err)
+}
+
+func (r *Repository) RevokeAlbumShare(ctx context.Context, ownerID string, albumID int64, grantee permission.Grantee) error {
+	const op = "sqlite.RevokeAlbumShare"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, end := r.startQuery(ctx, op)
+	defer end()
+
+	result, err := r.db.ExecContext(ctx,
+		"DELETE FROM shares WHERE owner_id = ? AND album_id = ? AND grantee_tenant = ? AND grantee = ?",
+		ownerID, albumID, grantee.Tenant, grantee.Subject,
+	)
+
+	return checkRevoked(op, result, err)
+}
+
+// GetSharedImage returns an image shared with grantee directly or through
+// an album, together with the highest level granted.
+func (r *Repository) GetSharedImage(ctx context.Context, grantee permission.Grantee, imageID int64) (*imagev1.ImageMetadata, permission.Level, error) {
+	const op = "sqlite.GetSharedImage"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, end := r.startQuery(ctx, op)
+	defer end()
+
+	var level permission.Level
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COALESCE(MAX(CASE permission WHEN 'write' THEN 2 WHEN 'read' THEN 1 END), 0)
+		FROM shares
+		WHERE grantee_tenant = ?1 AND grantee = ?2
+			AND (
+				image_id = ?3
+				OR album_id IN (SELECT album_id FROM album_images WHERE image_id = ?3)
+			)
+	`, grantee.Tenant, grantee.Subject, imageID).Scan(&level)
+	if err != nil {
+		return nil, permission.LevelNone, fmt.Errorf("%s: failed to check shares: %w", op, err)
+	}
+
+	if level == permission.LevelNone {
+		return nil, permission.LevelNone, fmt.Errorf("%s: %w", op, repository.ErrImageNotFound)
+	}
+
+	img, err := scanImage(r.db.QueryRowContext(ctx, `
+		SELECT `+imageColumns+`
+		FROM images
+		WHERE id = ?
+	`, imageID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, permission.LevelNone, fmt.Errorf("%s: %w", op, repository.ErrImageNotFound)
+	}
+	if err != nil {
+		return nil, permission.LevelNone, fmt.Errorf("%s: failed to retrieve image: %w", op, err)
+	}
+
+	return img, level, nil
+}
+
+func (r *Repository) GetUsage(ctx context.Context, ownerID string) (model.Usage, error) {
+	const op = "sqlite.GetUsage"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, end := r.startQuery(ctx, op)
+	defer end()
+
+	month := currentMonth()
+	usage := model.Usage{OwnerID: ownerID, Month: month}
+
+	err := r.db.QueryRowContext(ctx, `
+		SELECT
+			total_bytes,
+			image_count,
+			CASE WHEN month = ? THEN monthly_upload_bytes ELSE 0 END
+		FROM tenant_usage
+		WHERE owner_id = ?
+	`, month.Format(time.DateOnly), ownerID).Scan(
+		&usage.TotalBytes,
+		&usage.ImageCount,
+		&usage.MonthlyUploadBytes,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return usage, nil
+	}
+	if err != nil {
+		return usage, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return usage, nil
+}
+
+func (r *Repository) GetQuota(ctx context.Context, ownerID string) (model.Quota, error) {
+	const op = "sqlite.GetQuota"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, end := r.startQuery(ctx, op)
+	defer end()
+
+	var quota model.Quota
+
+	err := r.db.QueryRowContext(ctx, `
+		SELECT max_total_bytes, max_images, max_monthly_upload_bytes
+		FROM tenant_quotas
+		WHERE owner_id = ?
+	`, ownerID).Scan(
+		&quota.MaxTotalBytes,
+		&quota.MaxImages,
+		&quota.MaxMonthlyUploadBytes,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return quota, fmt.Errorf("%s: %w", op, repository.ErrQuotaNotFound)
+	}
+	if err != nil {
+		return quota, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return quota, nil
+}
+
+func (r *Repository) SetQuota(ctx context.Context, ownerID string, quota model.Quota) error {
+	const op = "sqlite.SetQuota"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, end := r.startQuery(ctx, op)
+	defer end()
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO tenant_quotas (owner_id, max_total_bytes, max_images, max_monthly_upload_bytes)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (owner_id) DO UPDATE SET
+			max_total_bytes = excluded.max_total_bytes,
+			max_images = excluded.max_images,
+			max_monthly_upload_bytes = excluded.max_monthly_upload_bytes,
+			updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now')
+	`, ownerID, quota.MaxTotalBytes, quota.MaxImages, quota.MaxMonthlyUploadBytes)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetTotalUsage sums the usage of all tenants.
+func (r *Repository) GetTotalUsage(ctx context.Context) (int64, int64, error) {
+	const op = "sqlite.GetTotalUsage"
+	defer metrics.ObserveQuery(op, time.Now())
+
+	ctx, end := r.startQuery(ctx, op)
+	defer end()
+
+	var bytes, images int64
+	err := r.db.QueryRowContext(ctx,
+		"SELECT COALESCE(SUM(total_bytes), 0), COALESCE(SUM(image_count), 0) FROM tenant_usage",
+	).Scan(&bytes, &images)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return bytes, images, nil
+}
+
+func (r *Repository) checkAlbumOwner(ctx context.Context, ownerID string, albumID int64) error {
+	var exists bool
+	err := r.db.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM albums WHERE id = ? AND owner_id = ?)", albumID, ownerID,
+	).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check album existence: %w", err)
+	}
+	if !exists {
+		return repository.ErrAlbumNotFound
+	}
+
+	return nil
+}
+
+func (r *Repository) checkImageOwner(ctx context.Context, ownerID string, imageID int64) error {
+	var exists bool
+	err := r.db.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM images WHERE id = ? AND owner_id = ?)", imageID, ownerID,
+	).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check image existence: %w", err)
+	}
+	if !exists {
+		return repository.ErrImageNotFound
+	}
+
+	return nil
+}
+
+func checkRevoked(op string, result sql.Result, err error) error {
+	if err != nil {
+		return fmt.Errorf("%s: failed to revoke share: %w", op, err)
+	}
+
+	revoked, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to verify revocation: %w", op, err)
+	}
+	if revoked == 0 {
+		return fmt.Errorf("%s: %w", op, repository.ErrShareNotFound)
+	}
+
+	return nil
+}
+
+// currentMonth is the first day of the current UTC month, which monthly
+// upload counters are kept for.
+func currentMonth() time.Time {
+	now := time.Now().UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,63 @@
+package sqlite
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aidosgal/image-processing-service/internal/config"
+	"github.com/aidosgal/image-processing-service/internal/repository/repositorytest"
+)
+
+func newTestRepository(t *testing.T, path string) *Repository {
+	t.Helper()
+
+	r, err := NewRepository(context.Background(), config.DatabaseConfig{
+		SQLitePath:   path,
+		QueryTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	t.Cleanup(func() { r.Close() })
+
+	return r
+}
+
+func TestConformance(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) repositorytest.Repository {
+		return newTestRepository(t, filepath.Join(t.TempDir(), "images.db"))
+	})
+}
+
+func TestNewRepository_ReopensMigratedDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "images.db")
+
+	r := newTestRepository(t, path)
+	id, err := r.CreateAlbum(context.Background(), "tenant", "album")
+	if err != nil {
+		t.Fatalf("CreateAlbum() error = %v", err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	r = newTestRepository(t, path)
+
+	var version int
+	if err := r.db.QueryRow("SELECT MAX(version) FROM schema_migrations").Scan(&version); err != nil {
+		t.Fatalf("failed to read schema version: %v", err)
+	}
+	entries, err := fs.ReadDir(migrations, "migrations")
+	if err != nil {
+		t.Fatalf("failed to list migrations: %v", err)
+	}
+	if version != len(entries) {
+		t.Errorf("schema version = %d, want %d", version, len(entries))
+	}
+	if err := r.checkAlbumOwner(context.Background(), "tenant", id); err != nil {
+		t.Errorf("album lost after reopening: %v", err)
+	}
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aidosgal/image-processing-service/internal/lib/metrics"
+	"github.com/aidosgal/image-processing-service/internal/lib/permission"
+	lib "github.com/aidosgal/image-processing-service/internal/lib/service"
+	"github.com/aidosgal/image-processing-service/internal/lib/smartcrop"
+	"github.com/aidosgal/image-processing-service/internal/lib/tenant"
+	"github.com/aidosgal/image-processing-service/internal/lib/tracing"
+	"github.com/aidosgal/image-processing-service/internal/repository"
+	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// SetFocalPoint overrides the focal point found at upload.
+func (i *ImageService) SetFocalPoint(ctx context.Context, imageID int64, point smartcrop.Point) (*imagev1.FocalPoint, int64, error) {
+	ctx, span := tracing.Start(ctx, "ImageService.SetFocalPoint", attribute.Int64("image.id", imageID))
+	defer span.End()
+
+	focal := &imagev1.FocalPoint{X: point.X, Y: point.Y, Manual: true}
+	revision, err := i.updateCropping(ctx, imageID, func(metadata *imagev1.ImageMetadata) {
+		metadata.FocalPoint = focal
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	i.logger(ctx).Info("Focal point set", "image_id", imageID, "x", point.X, "y", point.Y)
+
+	return focal, revision, nil
+}
+
+// SetCropHints replaces the crop hints of an image, one rectangle per aspect
+// ratio. No hints removes them all.
+func (i *ImageService) SetCropHints(ctx context.Context, imageID int64, crops map[smartcrop.Aspect]smartcrop.Rect) ([]*imagev1.CropHint, int64, error) {
+	ctx, span := tracing.Start(ctx, "ImageService.SetCropHints", attribute.Int64("image.id", imageID))
+	defer span.End()
+
+	hints := make([]*imagev1.CropHint, 0, len(crops))
+	for aspect, r := range crops {
+		hints = append(hints, &imagev1.CropHint{
+			AspectRatio: aspect.String(),
+			X:           r.X,
+			Y:           r.Y,
+			Width:       r.W,
+			Height:      r.H,
+		})
+	}
+	slices.SortFunc(hints, func(a, b *imagev1.CropHint) int {
+		return strings.Compare(a.GetAspectRatio(), b.GetAspectRatio())
+	})
+
+	revision, err := i.updateCropping(ctx, imageID, func(metadata *imagev1.ImageMetadata) {
+		metadata.CropHints = hints
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	i.logger(ctx).Info("Crop hints set", "image_id", imageID, "count", len(hints))
+
+	return hints, revision, nil
+}
+
+// updateCropping applies update to the focal point or crop hints of an image
+// the caller owns or was granted write access to, renders the stored
+// variants that crop again and stores the result under a new revision,
+// which is returned. Cached variants are dropped, so transforms are rendered
+// again too.
+func (i *ImageService) updateCropping(ctx context.Context, imageID int64, update func(metadata *imagev1.ImageMetadata)) (int64, error) {
+	log := i.logger(ctx)
+
+	ownerID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	i.cropping.Lock()
+	defer i.cropping.Unlock()
+
+	metadata, err := i.repository.GetImageById(ctx, ownerID, imageID)
+	if errors.Is(err, repository.ErrImageNotFound) {
+		metadata, err = i.sharedImage(ctx, imageID, permission.LevelWrite)
+		if err == nil {
+			ownerID = metadata.GetOwnerId()
+		}
+	}
+	if err != nil {
+		if errors.Is(err, ErrImageNotFound) || errors.Is(err, ErrPermissionDenied) {
+			return 0, err
+		}
+		log.Error("Failed to retrieve image metadata", "image_id", imageID, "error", err)
+		return 0, fmt.Errorf("failed to retrieve image metadata: %w", err)
+	}
+
+	update(metadata)
+
+	variantsSize, err := i.regenerateCropped(ctx, metadata)
+	if err != nil {
+		log.Error("Failed to regenerate variants", "image_id", imageID, "error", err)
+		return 0, fmt.Errorf("failed to regenerate variants: %w", err)
+	}
+
+	revision, err := i.repository.UpdateCropping(ctx, ownerID, imageID, repository.Cropping{
+		FocalPoint:   metadata.GetFocalPoint(),
+		CropHints:    metadata.GetCropHints(),
+		VariantsSize: variantsSize,
+	})
+	if err != nil {
+		log.Error("Failed to update cropping", "image_id", imageID, "error", err)
+		return 0, fmt.Errorf("failed to update cropping: %w", err)
+	}
+
+	i.invalidateVariants(ctx, imageID)
+
+	return revision, nil
+}
+
+// regenerateCropped renders the stored variants that crop again, with the
+// hints of metadata, and returns the new size of all stored variants. Only
+// the thumbnail can crop, when its preset does.
+func (i *ImageService) regenerateCropped(ctx context.Context, metadata *imagev1.ImageMetadata) (int64, error) {
+	pipe := i.transforms.Thumbnail
+	if pipe == nil || !pipe.Crops() || metadata.GetThumbnailPath() == "" {
+		return metadata.GetVariantsSize(), nil
+	}
+
+	ctx, cancel := withTimeout(ctx, i.timeouts.Variants)
+	defer cancel()
+
+	original, err := readFile(ctx, metadata.GetFilePath())
+	if err != nil {
+		return 0, fmt.Errorf("failed to read image file: %w", err)
+	}
+
+	if err := i.acquireDecode(ctx); err != nil {
+		return 0, err
+	}
+	defer i.decodes.Release()
+
+	decoded, err := i.decode(ctx, original)
+	if err != nil {
+		return 0, fmt.Errorf("decoding failed: %w", err)
+	}
+	decoded.Hints = hints(metadata)
+
+	var oldSize int64
+	if info, err := os.Stat(metadata.GetThumbnailPath()); err == nil {
+		oldSize = info.Size()
+	}
+
+	defer metrics.ObserveProcessing("generate_thumbnail", time.Now())
+
+	path, err := lib.GenerateVariant(ctx, decoded, metadata.GetFilePath(), i.thumbnailVariant(metadata.GetOwnerId()))
+	if err != nil {
+		return 0, fmt.Errorf("thumbnail generation failed: %w", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat thumbnail: %w", err)
+	}
+
+	return max(metadata.GetVariantsSize()-oldSize+info.Size(), 0), nil
+}
+
+// hints are the stored crop hints of an image. The focal point is nil for
+// images stored before focal points were found.
+func hints(metadata *imagev1.ImageMetadata) smartcrop.Hints {
+	var h smartcrop.Hints
+	if f := metadata.GetFocalPoint(); f != nil {
+		h.Focus = &smartcrop.Point{X: f.GetX(), Y: f.GetY()}
+	}
+
+	for _, c := range metadata.GetCropHints() {
+		aspect, err := smartcrop.ParseAspect(c.GetAspectRatio())
+		if err != nil {
+			continue
+		}
+		if h.Crops == nil {
+			h.Crops = make(map[smartcrop.Aspect]smartcrop.Rect)
+		}
+		h.Crops[aspect] = smartcrop.Rect{X: c.GetX(), Y: c.GetY(), W: c.GetWidth(), H: c.GetHeight()}
+	}
+
+	return h
+}
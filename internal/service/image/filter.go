@@ -0,0 +1,50 @@
+package service
+
+import (
+	"github.com/aidosgal/image-processing-service/internal/lib/palette"
+	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
+)
+
+// ListFilter narrows ListImages. The zero value keeps every image; images
+// stored before colors were extracted only pass the zero value.
+type ListFilter struct {
+	// Color keeps images with a palette color within ColorDistance (CIE76)
+	// of it that covers at least ColorMinFraction of the image.
+	Color            *palette.Color
+	ColorDistance    float64
+	ColorMinFraction float64
+	// GrayscaleOnly keeps images that are shades of gray.
+	GrayscaleOnly bool
+}
+
+func (f ListFilter) empty() bool {
+	return f.Color == nil && !f.GrayscaleOnly
+}
+
+func (f ListFilter) matches(img *imagev1.ImageMetadata) bool {
+	colors := img.GetColors()
+	if colors == nil {
+		return false
+	}
+
+	if f.GrayscaleOnly && !colors.GetGrayscale() {
+		return false
+	}
+
+	if f.Color == nil {
+		return true
+	}
+
+	for _, p := range colors.GetPalette() {
+		if p.GetFraction() < f.ColorMinFraction {
+			continue
+		}
+
+		c, err := palette.ParseHex(p.GetColor())
+		if err == nil && palette.Distance(*f.Color, c) <= f.ColorDistance {
+			return true
+		}
+	}
+
+	return false
+}
@@ -2,147 +2,349 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"sync"
-
+	"time"
+
+	"github.com/aidosgal/image-processing-service/internal/domain/model"
+	"github.com/aidosgal/image-processing-service/internal/lib/auth"
+	"github.com/aidosgal/image-processing-service/internal/lib/logger"
+	"github.com/aidosgal/image-processing-service/internal/lib/metrics"
+	"github.com/aidosgal/image-processing-service/internal/lib/permission"
+	"github.com/aidosgal/image-processing-service/internal/lib/ratelimit"
 	lib "github.com/aidosgal/image-processing-service/internal/lib/service"
+	"github.com/aidosgal/image-processing-service/internal/lib/tenant"
+	"github.com/aidosgal/image-processing-service/internal/lib/tracing"
+	"github.com/aidosgal/image-processing-service/internal/lib/variantcache"
+	"github.com/aidosgal/image-processing-service/internal/lib/watermark"
+	"github.com/aidosgal/image-processing-service/internal/repository"
 	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	ErrImageNotFound    = errors.New("image not found")
+	ErrAlbumNotFound    = errors.New("album not found")
+	ErrShareNotFound    = errors.New("share not found")
+	ErrPermissionDenied = permission.ErrDenied
+	ErrQuotaExceeded    = model.ErrQuotaExceeded
+	ErrBusy             = ratelimit.ErrBusy
+	ErrImageTooLarge    = lib.ErrTooLarge
 )
 
 type ImageService struct {
-	log        *slog.Logger
-	repository Repository
+	log          *slog.Logger
+	repository   Repository
+	defaultQuota model.Quota
+	decodes      *ratelimit.ConcurrencyLimiter
+	maxPixels    int64
+	timeouts     Timeouts
+	similarity   Similarity
+	cache        *variantcache.Cache
+	watermarks   map[string]*watermark.Profile
+	transforms   Transforms
+	uploads      *uploads
+	// cropping serializes changes to focal points and crop hints, so the
+	// stored variants always match the stored hints.
+	cropping sync.Mutex
+
+	imagesDir     string
+	thumbnailsDir string
+	watermarksDir string
+}
+
+// Timeouts bounds each processing step of an upload on top of the caller's
+// deadline. Zero leaves a step bounded by the caller only.
+type Timeouts struct {
+	// Decode covers reading the header and decoding the pixels.
+	Decode time.Duration
+	// Variants covers rendering and saving all variants.
+	Variants time.Duration
 }
 
 type Repository interface {
-	StoreImage(ctx context.Context, metadata *imagev1.ImageMetadata) (int64, error)
-	GetAllImages(ctx context.Context) ([]*imagev1.ImageMetadata, error)
-	GetImageById(ctx context.Context, image_id int64) (*imagev1.ImageMetadata, error)
-	DeleteImageById(ctx context.Context, image_id int64) (bool, error)
+	StoreImage(ctx context.Context, owner_id string, metadata *imagev1.ImageMetadata, quota model.Quota) (int64, error)
+	GetAllImages(ctx context.Context, owner_id string) ([]*imagev1.ImageMetadata, error)
+	GetImageById(ctx context.Context, owner_id string, image_id int64) (*imagev1.ImageMetadata, error)
+	UpdateCropping(ctx context.Context, owner_id string, image_id int64, cropping repository.Cropping) (int64, error)
+	DeleteImageById(ctx context.Context, owner_id string, image_id int64) (bool, error)
+	DeleteImagesByOwner(ctx context.Context, owner_id string) (int64, error)
+	FindSimilarImages(ctx context.Context, owner_id string, query model.SimilarityQuery) ([]model.SimilarImage, error)
+	CreateAlbum(ctx context.Context, owner_id string, name string) (int64, error)
+	AddImageToAlbum(ctx context.Context, owner_id string, album_id int64, image_id int64) error
+	ShareImage(ctx context.Context, owner_id string, image_id int64, grantee permission.Grantee, level permission.Level) error
+	ShareAlbum(ctx context.Context, owner_id string, album_id int64, grantee permission.Grantee, level permission.Level) error
+	RevokeImageShare(ctx context.Context, owner_id string, image_id int64, grantee permission.Grantee) error
+	RevokeAlbumShare(ctx context.Context, owner_id string, album_id int64, grantee permission.Grantee) error
+	GetSharedImage(ctx context.Context, grantee permission.Grantee, image_id int64) (*imagev1.ImageMetadata, permission.Level, error)
+	GetUsage(ctx context.Context, owner_id string) (model.Usage, error)
+	GetQuota(ctx context.Context, owner_id string) (model.Quota, error)
+	SetQuota(ctx context.Context, owner_id string, quota model.Quota) error
+}
+
+// Config holds the settings of an ImageService and the components it
+// shares with the rest of the app. Zero values leave a feature off: no
+// default quota, no decode limit, no cache, no watermark profiles.
+type Config struct {
+	// StorageRoot is the directory originals and variants are stored under.
+	StorageRoot string
+	// DefaultQuota applies to tenants without a quota of their own.
+	DefaultQuota model.Quota
+	// Decodes bounds the decodes running at once across all requests.
+	Decodes *ratelimit.ConcurrencyLimiter
+	// MaxPixels rejects images declaring more pixels before decoding them.
+	MaxPixels  int64
+	Timeouts   Timeouts
+	Similarity Similarity
+	Cache      *variantcache.Cache
+	Watermarks map[string]*watermark.Profile
+	Transforms Transforms
 }
 
-func NewImageService(log *slog.Logger, repository Repository) *ImageService {
+func NewImageService(log *slog.Logger, repository Repository, cfg Config) *ImageService {
 	return &ImageService{
-		log:        log,
-		repository: repository,
+		log:          log,
+		repository:   repository,
+		defaultQuota: cfg.DefaultQuota,
+		decodes:      cfg.Decodes,
+		maxPixels:    cfg.MaxPixels,
+		timeouts:     cfg.Timeouts,
+		similarity:   cfg.Similarity,
+		cache:        cfg.Cache,
+		watermarks:   cfg.Watermarks,
+		transforms:   cfg.Transforms,
+		uploads:      newUploads(),
+
+		imagesDir:     filepath.Join(cfg.StorageRoot, "images"),
+		thumbnailsDir: filepath.Join(cfg.StorageRoot, "thumbnails"),
+		watermarksDir: filepath.Join(cfg.StorageRoot, "watermarks"),
+	}
+}
+
+// logger returns the request-scoped logger from the context. Outside of a
+// request it falls back to the service logger annotated with the principal.
+func (i *ImageService) logger(ctx context.Context) *slog.Logger {
+	log := i.log
+	if p, ok := auth.PrincipalFromContext(ctx); ok {
+		log = log.With(slog.String("principal", p.Subject))
+	}
+
+	return logger.FromContext(ctx, log)
+}
+
+// acquireDecode takes a slot of the global decode limit.
+func (i *ImageService) acquireDecode(ctx context.Context) error {
+	err := i.decodes.Acquire(ctx)
+	if errors.Is(err, ratelimit.ErrBusy) {
+		metrics.DecodeLimitRejections.Inc()
+	}
+
+	return err
+}
+
+// invalidateVariants drops the cached variants of a deleted or updated image.
+func (i *ImageService) invalidateVariants(ctx context.Context, imageID int64) {
+	if err := i.cache.Invalidate(imageID); err != nil {
+		i.logger(ctx).Warn("Failed to invalidate cached variants", "image_id", imageID, "error", err)
 	}
 }
 
 func (i *ImageService) UploadImage(ctx context.Context, image []byte, filename string) (int64, error) {
-	uploadsDir := "./uploads/images"
-	if err := os.MkdirAll(uploadsDir, os.ModePerm); err != nil {
+	ctx, done, err := i.uploads.begin(ctx)
+	if err != nil {
 		return 0, err
 	}
-	uniqueFilename := lib.GenerateUniqueFilename(filename)
-	filePath := filepath.Join(uploadsDir, uniqueFilename)
+	defer done()
 
-	if err := os.WriteFile(filePath, image, 0644); err != nil {
-		return 0, fmt.Errorf("failed to save image: %w", err)
-	}
+	ctx, span := tracing.Start(ctx, "ImageService.UploadImage", attribute.Int("image.size", len(image)))
+	defer span.End()
 
-	var wg sync.WaitGroup
-	var metadataErr error
-	var thumbnailErr error
-	var metadata *imagev1.ImageMetadata
-	var thumbnailPath string
+	ownerID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
 
-	metadataChan := make(chan *imagev1.ImageMetadata, 1)
-	thumbnailChan := make(chan string, 1)
-	errChan := make(chan error, 2)
+	// Reject uploads that can't fit before spending any work on them. The
+	// repository checks the quota again when the image is stored.
+	quota, err := i.effectiveQuota(ctx, ownerID)
+	if err != nil {
+		return 0, err
+	}
+	usage, err := i.repository.GetUsage(ctx, ownerID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get usage: %w", err)
+	}
+	if err := quota.Check(usage, int64(len(image)), int64(len(image))); err != nil {
+		return 0, err
+	}
 
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		defer close(metadataChan)
+	// Storage keys are partitioned per tenant.
+	uploadsDir := filepath.Join(i.imagesDir, ownerID)
+	if err := os.MkdirAll(uploadsDir, os.ModePerm); err != nil {
+		return 0, err
+	}
+	filename = lib.DisplayName(filename)
+	filePath := filepath.Join(uploadsDir, lib.StorageName(filename, image))
 
-		extractedMetadata, err := lib.ExtractImageMetadata(filePath, uniqueFilename)
-		if err != nil {
-			metadataErr = fmt.Errorf("metadata extraction failed: %w", err)
-			errChan <- metadataErr
-			return
-		}
-		metadataChan <- extractedMetadata
-	}()
+	if err := writeFile(ctx, filePath, image); err != nil {
+		return 0, fmt.Errorf("failed to save image: %w", err)
+	}
 
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		defer close(thumbnailChan)
+	variants := append([]lib.Variant{
+		i.thumbnailVariant(ownerID),
+	}, i.watermarkVariants(ownerID, filePath)...)
 
-		generatedThumbnailPath, err := lib.GenerateThumbnail(filePath)
-		if err != nil {
-			thumbnailErr = fmt.Errorf("thumbnail generation failed: %w", err)
-			errChan <- thumbnailErr
-			return
+	metadata, err := i.process(ctx, image, filePath, filename, variants)
+	if err != nil {
+		removeFile(ctx, filePath)
+		i.removeWatermarkVariants(ctx, ownerID, filePath)
+		if errors.Is(context.Cause(ctx), ErrShuttingDown) {
+			return 0, ErrShuttingDown
 		}
-		thumbnailChan <- generatedThumbnailPath
-	}()
-
-	go func() {
-		wg.Wait()
-		close(errChan)
-	}()
+		return 0, err
+	}
+	metadata.OwnerId = ownerID
 
-	for err := range errChan {
-		if err != nil {
-			os.Remove(filePath)
-			return 0, err
+	imageID, err := i.repository.StoreImage(ctx, ownerID, metadata, quota)
+	if err != nil {
+		removeFile(ctx, filePath)
+		if metadata.GetThumbnailPath() != "" {
+			removeFile(ctx, metadata.GetThumbnailPath())
 		}
+		i.removeWatermarkVariants(ctx, ownerID, filePath)
+		return 0, err
 	}
 
-	metadata = <-metadataChan
-	thumbnailPath = <-thumbnailChan
+	i.logger(ctx).Info("Image uploaded", "image_id", imageID, "owner_id", ownerID, "size", len(image))
 
-	if thumbnailPath != "" {
-		metadata.ThumbnailPath = thumbnailPath
-	}
+	metrics.UploadSize.Observe(float64(len(image)))
+	metrics.UploadedBytes.Add(float64(len(image)))
 
-	return i.repository.StoreImage(ctx, metadata)
+	return imageID, nil
 }
 
-func (i *ImageService) ListImages(ctx context.Context) ([]*imagev1.ImageMetadata, error) {
-	i.log.Info("Listing all images")
+// ListImages returns the caller's images that pass filter, newest first.
+// The color filters are applied to the tenant's images after they are read.
+func (i *ImageService) ListImages(ctx context.Context, filter ListFilter) ([]*imagev1.ImageMetadata, error) {
+	ctx, span := tracing.Start(ctx, "ImageService.ListImages")
+	defer span.End()
 
-	images, err := i.repository.GetAllImages(ctx)
+	log := i.logger(ctx)
+
+	ownerID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("Listing all images", "owner_id", ownerID)
+
+	images, err := i.repository.GetAllImages(ctx, ownerID)
 	if err != nil {
-		i.log.Error("Failed to list images", "error", err)
+		log.Error("Failed to list images", "error", err)
 		return nil, fmt.Errorf("failed to list images: %w", err)
 	}
 
-	i.log.Info("Images retrieved successfully", "count", len(images))
+	if !filter.empty() {
+		matching := images[:0]
+		for _, img := range images {
+			if filter.matches(img) {
+				matching = append(matching, img)
+			}
+		}
+		images = matching
+	}
+
+	log.Info("Images retrieved successfully", "count", len(images))
 
 	return images, nil
 }
 
-func (i *ImageService) GetImage(ctx context.Context, imageID int64) ([]byte, *imagev1.ImageMetadata, error) {
-	i.log.Info("Retrieving image", "image_id", imageID)
+// GetImage returns the original, watermarked when the request or the
+// caller's API key names a watermark profile.
+func (i *ImageService) GetImage(ctx context.Context, imageID int64, watermarkName string) ([]byte, *imagev1.ImageMetadata, error) {
+	ctx, span := tracing.Start(ctx, "ImageService.GetImage", attribute.Int64("image.id", imageID))
+	defer span.End()
+
+	log := i.logger(ctx)
 
-	metadata, err := i.repository.GetImageById(ctx, imageID)
+	ownerID, err := tenant.FromContext(ctx)
 	if err != nil {
-		i.log.Error("Failed to retrieve image metadata", "image_id", imageID, "error", err)
+		return nil, nil, err
+	}
+
+	log.Info("Retrieving image", "image_id", imageID, "owner_id", ownerID)
+
+	profile, err := i.watermarkProfile(ctx, watermarkName)
+	if err != nil {
+		log.Warn("Watermark cannot be applied", "image_id", imageID, "watermark", watermarkName, "reason", err)
+		return nil, nil, err
+	}
+
+	metadata, err := i.repository.GetImageById(ctx, ownerID, imageID)
+	if errors.Is(err, repository.ErrImageNotFound) {
+		metadata, err = i.sharedImage(ctx, imageID, permission.LevelRead)
+	}
+	if err != nil {
+		if errors.Is(err, ErrImageNotFound) || errors.Is(err, ErrPermissionDenied) {
+			log.Warn("Image is not accessible", "image_id", imageID, "owner_id", ownerID, "reason", err)
+			return nil, nil, err
+		}
+		log.Error("Failed to retrieve image metadata", "image_id", imageID, "error", err)
 		return nil, nil, fmt.Errorf("failed to retrieve image metadata: %w", err)
 	}
 
-	imageBytes, err := os.ReadFile(metadata.GetFilePath())
+	imageBytes, err := readFile(ctx, metadata.GetFilePath())
 	if err != nil {
-		i.log.Error("Failed to read image file", "image_path", metadata.GetFilePath(), "error", err)
+		log.Error("Failed to read image file", "image_path", metadata.GetFilePath(), "error", err)
 		return nil, nil, fmt.Errorf("failed to read image file: %w", err)
 	}
 
-	i.log.Info("Image retrieved successfully", "image_id", imageID, "filename", metadata.GetFilename())
+	if profile != nil {
+		imageBytes, err = i.watermarked(ctx, metadata, imageBytes, profile)
+		if err != nil {
+			log.Error("Failed to watermark image", "image_id", imageID, "watermark", profile.Name, "error", err)
+			return nil, nil, fmt.Errorf("failed to watermark image: %w", err)
+		}
+	}
+
+	log.Info("Image retrieved successfully", "image_id", imageID, "filename", metadata.GetFilename())
+
+	metrics.ServedBytes.Add(float64(len(imageBytes)))
 
 	return imageBytes, metadata, nil
 }
 
 func (i *ImageService) DeleteImage(ctx context.Context, imageID int64) (bool, error) {
-	i.log.Info("Deleting image", "image_id", imageID)
+	ctx, span := tracing.Start(ctx, "ImageService.DeleteImage", attribute.Int64("image.id", imageID))
+	defer span.End()
+
+	log := i.logger(ctx)
 
-	metadata, err := i.repository.GetImageById(ctx, imageID)
+	ownerID, err := tenant.FromContext(ctx)
 	if err != nil {
-		i.log.Error("Failed to retrieve image metadata for deletion", "image_id", imageID, "error", err)
+		return false, err
+	}
+
+	log.Info("Deleting image", "image_id", imageID, "owner_id", ownerID)
+
+	// Shares never grant deletion, only the owner's tenant may delete.
+	metadata, err := i.repository.GetImageById(ctx, ownerID, imageID)
+	if errors.Is(err, repository.ErrImageNotFound) {
+		err = ErrImageNotFound
+		if _, shareErr := i.sharedImage(ctx, imageID, permission.LevelRead); shareErr == nil {
+			err = permission.Denied("image %d belongs to another tenant, only its owner may delete it", imageID)
+		}
+	}
+	if err != nil {
+		if errors.Is(err, ErrImageNotFound) || errors.Is(err, ErrPermissionDenied) {
+			log.Warn("Image is not accessible", "image_id", imageID, "owner_id", ownerID, "reason", err)
+			return false, err
+		}
+		log.Error("Failed to retrieve image metadata for deletion", "image_id", imageID, "error", err)
 		return false, fmt.Errorf("failed to retrieve image metadata: %w", err)
 	}
 
@@ -152,9 +354,9 @@ func (i *ImageService) DeleteImage(ctx context.Context, imageID int64) (bool, er
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		primaryFileErr = os.Remove(metadata.GetFilePath())
+		primaryFileErr = removeFile(ctx, metadata.GetFilePath())
 		if primaryFileErr != nil {
-			i.log.Error("Failed to delete primary image file",
+			log.Error("Failed to delete primary image file",
 				"image_path", metadata.GetFilePath(),
 				"error", primaryFileErr)
 		}
@@ -164,9 +366,9 @@ func (i *ImageService) DeleteImage(ctx context.Context, imageID int64) (bool, er
 	go func() {
 		defer wg.Done()
 		if metadata.GetThumbnailPath() != "" {
-			thumbnailErr = os.Remove(metadata.GetThumbnailPath())
+			thumbnailErr = removeFile(ctx, metadata.GetThumbnailPath())
 			if thumbnailErr != nil {
-				i.log.Error("Failed to delete thumbnail",
+				log.Error("Failed to delete thumbnail",
 					"thumbnail_path", metadata.GetThumbnailPath(),
 					"error", thumbnailErr)
 			}
@@ -175,23 +377,53 @@ func (i *ImageService) DeleteImage(ctx context.Context, imageID int64) (bool, er
 
 	wg.Wait()
 
+	i.removeWatermarkVariants(ctx, metadata.GetOwnerId(), metadata.GetFilePath())
+
 	if primaryFileErr != nil || thumbnailErr != nil {
-		i.log.Warn("Some files could not be deleted",
+		log.Warn("Some files could not be deleted",
 			"primary_file_error", primaryFileErr,
 			"thumbnail_error", thumbnailErr)
 	}
 
-	deleted, err := i.repository.DeleteImageById(ctx, imageID)
+	deleted, err := i.repository.DeleteImageById(ctx, metadata.GetOwnerId(), imageID)
 	if err != nil {
-		i.log.Error("Failed to delete image from database", "image_id", imageID, "error", err)
+		if errors.Is(err, repository.ErrImageNotFound) {
+			return false, ErrImageNotFound
+		}
+		log.Error("Failed to delete image from database", "image_id", imageID, "error", err)
 		return false, fmt.Errorf("failed to delete image from database: %w", err)
 	}
 
+	i.invalidateVariants(ctx, imageID)
+
 	if deleted {
-		i.log.Info("Image deleted successfully", "image_id", imageID)
+		log.Info("Image deleted successfully", "image_id", imageID)
 	} else {
-		i.log.Warn("Image not found or already deleted", "image_id", imageID)
+		log.Warn("Image not found or already deleted", "image_id", imageID)
 	}
 
 	return deleted, nil
 }
+
+// sharedImage looks up an image of another tenant that was shared with the
+// caller and checks that the granted level is at least required.
+func (i *ImageService) sharedImage(ctx context.Context, imageID int64, required permission.Level) (*imagev1.ImageMetadata, error) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return nil, ErrImageNotFound
+	}
+
+	metadata, level, err := i.repository.GetSharedImage(ctx, principalGrantee(principal), imageID)
+	if err != nil {
+		if errors.Is(err, repository.ErrImageNotFound) {
+			return nil, ErrImageNotFound
+		}
+		return nil, fmt.Errorf("failed to check image shares: %w", err)
+	}
+
+	if level < required {
+		return nil, permission.Denied("image %d is shared with %s access, %s access is required", imageID, level, required)
+	}
+
+	return metadata, nil
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aidosgal/image-processing-service/internal/lib/blurhash"
+	"github.com/aidosgal/image-processing-service/internal/lib/metrics"
+	"github.com/aidosgal/image-processing-service/internal/lib/palette"
+	"github.com/aidosgal/image-processing-service/internal/lib/phash"
+	lib "github.com/aidosgal/image-processing-service/internal/lib/service"
+	"github.com/aidosgal/image-processing-service/internal/lib/smartcrop"
+	"github.com/aidosgal/image-processing-service/internal/lib/tracing"
+	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
+	"github.com/disintegration/imaging"
+)
+
+// process decodes the upload once, from memory, and fans the decoded image
+// out to metadata extraction and every variant. On failure no variant file
+// is left behind; the original at filePath is the caller's to remove.
+func (i *ImageService) process(ctx context.Context, data []byte, filePath, filename string, variants []lib.Variant) (*imagev1.ImageMetadata, error) {
+	ctx, span := tracing.Start(ctx, "ImageService.process")
+	defer span.End()
+
+	// The slot is held until the variants are done, since that is how long
+	// the decoded pixels stay in memory.
+	if err := i.acquireDecode(ctx); err != nil {
+		return nil, err
+	}
+	defer i.decodes.Release()
+
+	decoded, err := i.decode(ctx, data)
+	if err != nil {
+		err = fmt.Errorf("decoding failed: %w", err)
+		tracing.End(span, err)
+		i.logProcessingError(ctx, "Failed to decode image", filePath, err)
+		return nil, err
+	}
+
+	metadata := lib.ExtractImageMetadata(decoded, filePath, filename)
+	metadata.Hashes = i.hash(decoded)
+	metadata.Colors = i.colors(decoded)
+	metadata.Placeholder = i.placeholder(ctx, decoded, filePath)
+	metadata.FocalPoint = i.focalPoint(decoded)
+
+	paths, err := i.generateVariants(ctx, decoded, filePath, variants)
+	if err != nil {
+		tracing.End(span, err)
+		return nil, err
+	}
+
+	metadata.ThumbnailPath = paths["thumbnail"]
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			metadata.VariantsSize += info.Size()
+		}
+	}
+
+	return metadata, nil
+}
+
+// hash computes the perceptual hashes used to find near-duplicates.
+func (i *ImageService) hash(decoded *lib.Decoded) *imagev1.PerceptualHash {
+	defer metrics.ObserveProcessing("hash", time.Now())
+
+	h := phash.Compute(decoded.Image)
+
+	return &imagev1.PerceptualHash{Ahash: h.AHash, Dhash: h.DHash, Phash: h.PHash}
+}
+
+// paletteSize is the number of dominant colors kept per image.
+const paletteSize = 5
+
+// colors extracts the color summary that ListImages filters by.
+func (i *ImageService) colors(decoded *lib.Decoded) *imagev1.ColorSummary {
+	defer metrics.ObserveProcessing("colors", time.Now())
+
+	s := palette.Extract(decoded.Image, paletteSize)
+
+	summary := &imagev1.ColorSummary{
+		Palette:      make([]*imagev1.PaletteColor, 0, len(s.Palette)),
+		AverageColor: s.Average.Hex(),
+		Brightness:   s.Brightness,
+		Colorfulness: s.Colorfulness,
+		Grayscale:    s.Grayscale,
+	}
+	for _, e := range s.Palette {
+		summary.Palette = append(summary.Palette, &imagev1.PaletteColor{Color: e.Color.Hex(), Fraction: e.Fraction})
+	}
+
+	return summary
+}
+
+// focalPoint finds the point crops keep in frame and records it on decoded
+// for the variants.
+func (i *ImageService) focalPoint(decoded *lib.Decoded) *imagev1.FocalPoint {
+	defer metrics.ObserveProcessing("focal_point", time.Now())
+
+	p := smartcrop.FocalPoint(decoded.Image)
+	decoded.Hints.Focus = &p
+
+	return &imagev1.FocalPoint{X: p.X, Y: p.Y}
+}
+
+const (
+	// previewSize is the longest side of the inline preview.
+	previewSize = 16
+	// previewQuality is low since the preview is shown blurred anyway.
+	previewQuality = 30
+)
+
+// placeholder renders the BlurHash and the inline preview clients show while
+// the image loads. Neither is essential, so a failure is logged and the
+// upload goes on without a placeholder.
+func (i *ImageService) placeholder(ctx context.Context, decoded *lib.Decoded, filePath string) *imagev1.Placeholder {
+	defer metrics.ObserveProcessing("placeholder", time.Now())
+
+	// Transparent areas would otherwise come out black.
+	small := imaging.Fit(decoded.Image, previewSize*2, previewSize*2, imaging.Box)
+	flat := imaging.New(small.Bounds().Dx(), small.Bounds().Dy(), color.White)
+	flat = imaging.Overlay(flat, small, image.Point{}, 1)
+
+	xComponents, yComponents := 4, 3
+	if flat.Bounds().Dy() > flat.Bounds().Dx() {
+		xComponents, yComponents = 3, 4
+	}
+
+	hash, err := blurhash.Encode(flat, xComponents, yComponents)
+	if err != nil {
+		i.logProcessingError(ctx, "Failed to encode blurhash", filePath, err)
+		return nil
+	}
+
+	var buf bytes.Buffer
+	preview := imaging.Fit(flat, previewSize, previewSize, imaging.Box)
+	if err := jpeg.Encode(&buf, preview, &jpeg.Options{Quality: previewQuality}); err != nil {
+		i.logProcessingError(ctx, "Failed to encode preview", filePath, err)
+		return nil
+	}
+
+	return &imagev1.Placeholder{
+		Blurhash: hash,
+		Preview:  "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()),
+	}
+}
+
+func (i *ImageService) decode(ctx context.Context, data []byte) (*lib.Decoded, error) {
+	ctx, cancel := withTimeout(ctx, i.timeouts.Decode)
+	defer cancel()
+
+	defer metrics.ObserveProcessing("decode", time.Now())
+
+	return lib.Decode(ctx, data, i.maxPixels)
+}
+
+// generateVariants renders the variants concurrently from the same decoded
+// image and returns their paths by name. The first failure cancels the
+// others, and all of them are waited for so nothing is still writing files
+// once it returns.
+func (i *ImageService) generateVariants(ctx context.Context, decoded *lib.Decoded, filePath string, variants []lib.Variant) (map[string]string, error) {
+	ctx, cancel := withTimeout(ctx, i.timeouts.Variants)
+	defer cancel()
+
+	ctx, cancelVariants := context.WithCancel(ctx)
+	defer cancelVariants()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	paths := make(map[string]string, len(variants))
+
+	errChan := make(chan error, len(variants))
+
+	for _, v := range variants {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer metrics.ObserveProcessing("generate_"+v.Name, time.Now())
+
+			path, err := lib.GenerateVariant(ctx, decoded, filePath, v)
+			if err != nil {
+				err = fmt.Errorf("%s generation failed: %w", v.Name, err)
+				i.logProcessingError(ctx, "Failed to generate "+v.Name, filePath, err)
+				errChan <- err
+				cancelVariants()
+				return
+			}
+
+			mu.Lock()
+			paths[v.Name] = path
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+	close(errChan)
+
+	if err := <-errChan; err != nil {
+		for _, path := range paths {
+			removeFile(ctx, path)
+		}
+		return nil, err
+	}
+
+	return paths, nil
+}
+
+// logProcessingError logs a failed processing step, except when it was
+// cancelled because the request or another step already failed.
+func (i *ImageService) logProcessingError(ctx context.Context, msg, filePath string, err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	i.logger(ctx).Error(msg, "path", filePath, "error", err)
+}
+
+// withTimeout bounds ctx by d. A zero d leaves ctx bounded by the caller only.
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, d)
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/aidosgal/image-processing-service/internal/lib/auth"
+	"github.com/aidosgal/image-processing-service/internal/lib/permission"
+	"github.com/aidosgal/image-processing-service/internal/lib/tenant"
+	"github.com/aidosgal/image-processing-service/internal/lib/tracing"
+	"github.com/aidosgal/image-processing-service/internal/repository"
+)
+
+func (i *ImageService) PurgeImages(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "ImageService.PurgeImages")
+	defer span.End()
+
+	log := i.logger(ctx)
+
+	ownerID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Info("Purging images", "owner_id", ownerID)
+
+	images, err := i.repository.GetAllImages(ctx, ownerID)
+	if err != nil {
+		log.Error("Failed to list images for purge", "owner_id", ownerID, "error", err)
+		return 0, fmt.Errorf("failed to list images: %w", err)
+	}
+
+	deleted, err := i.repository.DeleteImagesByOwner(ctx, ownerID)
+	if err != nil {
+		log.Error("Failed to purge images from database", "owner_id", ownerID, "error", err)
+		return 0, fmt.Errorf("failed to purge images: %w", err)
+	}
+
+	for _, img := range images {
+		i.invalidateVariants(ctx, img.GetImageId())
+
+		for _, path := range []string{img.GetFilePath(), img.GetThumbnailPath()} {
+			if path == "" {
+				continue
+			}
+			if err := removeFile(ctx, path); err != nil && !os.IsNotExist(err) {
+				log.Warn("Failed to delete image file", "path", path, "error", err)
+			}
+		}
+		i.removeWatermarkVariants(ctx, ownerID, img.GetFilePath())
+	}
+
+	log.Info("Images purged", "owner_id", ownerID, "count", deleted)
+
+	return deleted, nil
+}
+
+func (i *ImageService) CreateAlbum(ctx context.Context, name string) (int64, error) {
+	log := i.logger(ctx)
+
+	ownerID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	albumID, err := i.repository.CreateAlbum(ctx, ownerID, name)
+	if err != nil {
+		log.Error("Failed to create album", "owner_id", ownerID, "error", err)
+		return 0, fmt.Errorf("failed to create album: %w", err)
+	}
+
+	log.Info("Album created", "album_id", albumID, "owner_id", ownerID)
+
+	return albumID, nil
+}
+
+func (i *ImageService) AddImageToAlbum(ctx context.Context, albumID int64, imageID int64) error {
+	ownerID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = i.repository.AddImageToAlbum(ctx, ownerID, albumID, imageID)
+
+	return i.sharingResult(ctx, "Image added to album", err, "album_id", albumID, "image_id", imageID)
+}
+
+func (i *ImageService) ShareImage(ctx context.Context, imageID int64, grantee permission.Grantee, level permission.Level) error {
+	ownerID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	grantee = granteeOf(ownerID, grantee)
+	if err := checkGrantee(ctx, grantee); err != nil {
+		return err
+	}
+
+	err = i.repository.ShareImage(ctx, ownerID, imageID, grantee, level)
+
+	return i.sharingResult(ctx, "Image shared", err, "image_id", imageID, "grantee", grantee.String(), "level", level.String())
+}
+
+func (i *ImageService) ShareAlbum(ctx context.Context, albumID int64, grantee permission.Grantee, level permission.Level) error {
+	ownerID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+	grantee = granteeOf(ownerID, grantee)
+	if err := checkGrantee(ctx, grantee); err != nil {
+		return err
+	}
+
+	err = i.repository.ShareAlbum(ctx, ownerID, albumID, grantee, level)
+
+	return i.sharingResult(ctx, "Album shared", err, "album_id", albumID, "grantee", grantee.String(), "level", level.String())
+}
+
+func (i *ImageService) RevokeImageShare(ctx context.Context, imageID int64, grantee permission.Grantee) error {
+	ownerID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = i.repository.RevokeImageShare(ctx, ownerID, imageID, granteeOf(ownerID, grantee))
+
+	return i.sharingResult(ctx, "Image share revoked", err, "image_id", imageID, "grantee", grantee.String())
+}
+
+func (i *ImageService) RevokeAlbumShare(ctx context.Context, albumID int64, grantee permission.Grantee) error {
+	ownerID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = i.repository.RevokeAlbumShare(ctx, ownerID, albumID, granteeOf(ownerID, grantee))
+
+	return i.sharingResult(ctx, "Album share revoked", err, "album_id", albumID, "grantee", grantee.String())
+}
+
+// sharingResult logs the outcome of a sharing operation and translates
+// repository errors into service errors.
+func (i *ImageService) sharingResult(ctx context.Context, msg string, err error, args ...any) error {
+	log := i.logger(ctx)
+
+	switch {
+	case err == nil:
+		log.Info(msg, args...)
+		return nil
+	case errors.Is(err, repository.ErrImageNotFound):
+		return ErrImageNotFound
+	case errors.Is(err, repository.ErrAlbumNotFound):
+		return ErrAlbumNotFound
+	case errors.Is(err, repository.ErrShareNotFound):
+		return ErrShareNotFound
+	}
+
+	log.Error("Sharing operation failed", append(args, "error", err)...)
+
+	return fmt.Errorf("sharing operation failed: %w", err)
+}
+
+// granteeOf defaults the tenant of grantee to the owner's, so shares within
+// a tenant need only name the subject.
+func granteeOf(ownerID string, grantee permission.Grantee) permission.Grantee {
+	if grantee.Tenant == "" {
+		grantee.Tenant = ownerID
+	}
+
+	return grantee
+}
+
+func checkGrantee(ctx context.Context, grantee permission.Grantee) error {
+	if principal, ok := auth.PrincipalFromContext(ctx); ok && principalGrantee(principal) == grantee {
+		return permission.Denied("cannot share with yourself")
+	}
+
+	return nil
+}
+
+// principalGrantee is the grantee that shares to principal name.
+func principalGrantee(principal *auth.Principal) permission.Grantee {
+	return permission.Grantee{Tenant: principal.Tenant, Subject: principal.Subject}
+}
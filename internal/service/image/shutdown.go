@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var ErrShuttingDown = errors.New("service is shutting down")
+
+// uploads tracks uploads in flight so shutdown can wait for them, and
+// cancels them when it stops waiting.
+type uploads struct {
+	mu      sync.Mutex
+	closing bool
+	wg      sync.WaitGroup
+
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+}
+
+func newUploads() *uploads {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	return &uploads{ctx: ctx, cancel: cancel}
+}
+
+// begin registers an upload. The returned context is also cancelled when
+// shutdown gives up waiting; done must be called when the upload finishes.
+func (u *uploads) begin(ctx context.Context) (context.Context, func(), error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.closing {
+		return nil, nil, ErrShuttingDown
+	}
+	u.wg.Add(1)
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	stop := context.AfterFunc(u.ctx, func() { cancel(context.Cause(u.ctx)) })
+
+	return ctx, func() {
+		stop()
+		cancel(nil)
+		u.wg.Done()
+	}, nil
+}
+
+// Drain rejects new uploads and waits for the ones in flight. When ctx is
+// done first the remaining uploads are cancelled, which makes them remove
+// their partial files, and Drain waits for them to return.
+func (i *ImageService) Drain(ctx context.Context) error {
+	u := i.uploads
+
+	u.mu.Lock()
+	u.closing = true
+	u.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		u.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	u.cancel(ErrShuttingDown)
+	<-done
+
+	return fmt.Errorf("cancelled in-flight uploads: %w", ctx.Err())
+}
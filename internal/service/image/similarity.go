@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aidosgal/image-processing-service/internal/domain/model"
+	"github.com/aidosgal/image-processing-service/internal/lib/metrics"
+	"github.com/aidosgal/image-processing-service/internal/lib/permission"
+	"github.com/aidosgal/image-processing-service/internal/lib/tenant"
+	"github.com/aidosgal/image-processing-service/internal/lib/tracing"
+	"github.com/aidosgal/image-processing-service/internal/repository"
+	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	ErrNotHashed    = model.ErrNotHashed
+	ErrInvalidImage = errors.New("invalid image")
+)
+
+// Similarity configures near-duplicate search. Distances are Hamming
+// distances between 64-bit hashes.
+type Similarity struct {
+	// MaxDistance applies to queries that set none.
+	MaxDistance int
+	// DuplicateDistance is the pHash distance up to which an upload is a
+	// near-duplicate.
+	DuplicateDistance int
+	// MaxResults caps the matches of a query.
+	MaxResults int
+}
+
+// SimilarQuery compares either the stored image ImageID or, when Image is
+// set, those bytes with the caller's images.
+type SimilarQuery struct {
+	ImageID   int64
+	Image     []byte
+	Algorithm model.HashAlgorithm
+	// MaxDistance is the configured default when negative.
+	MaxDistance int
+	// Limit is the configured maximum when 0 or above it.
+	Limit int
+}
+
+// FindSimilarImages returns the caller's images that look like the query's
+// source, closest first. A stored source may also be an image shared with
+// the caller; it is never among its own matches.
+func (i *ImageService) FindSimilarImages(ctx context.Context, q SimilarQuery) ([]model.SimilarImage, error) {
+	ctx, span := tracing.Start(ctx, "ImageService.FindSimilarImages", attribute.Int64("image.id", q.ImageID))
+	defer span.End()
+
+	log := i.logger(ctx)
+
+	ownerID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := model.SimilarityQuery{
+		Algorithm:   q.Algorithm,
+		MaxDistance: q.MaxDistance,
+		Limit:       q.Limit,
+	}
+	if query.Algorithm == "" {
+		query.Algorithm = model.HashPHash
+	}
+	if query.MaxDistance < 0 {
+		query.MaxDistance = i.similarity.MaxDistance
+	}
+	if query.Limit <= 0 || query.Limit > i.similarity.MaxResults {
+		query.Limit = i.similarity.MaxResults
+	}
+
+	var hashes *imagev1.PerceptualHash
+	if q.Image != nil {
+		hashes, err = i.hashImage(ctx, q.Image)
+	} else {
+		hashes, err = i.storedHashes(ctx, ownerID, q.ImageID)
+		query.ExcludeID = q.ImageID
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	hash, ok := query.Algorithm.Of(hashes)
+	if !ok {
+		return nil, ErrNotHashed
+	}
+	query.Hash = hash
+
+	matches, err := i.repository.FindSimilarImages(ctx, ownerID, query)
+	if err != nil {
+		log.Error("Failed to find similar images", "error", err)
+		return nil, fmt.Errorf("failed to find similar images: %w", err)
+	}
+
+	log.Info("Similar images found", "image_id", q.ImageID, "algorithm", query.Algorithm, "count", len(matches))
+
+	return matches, nil
+}
+
+// NearDuplicates returns the caller's images within the duplicate distance
+// of a just uploaded image. The upload has already succeeded at that point,
+// so a failed lookup is logged and reported as no duplicates.
+func (i *ImageService) NearDuplicates(ctx context.Context, imageID int64) []model.SimilarImage {
+	matches, err := i.FindSimilarImages(ctx, SimilarQuery{
+		ImageID:     imageID,
+		Algorithm:   model.HashPHash,
+		MaxDistance: i.similarity.DuplicateDistance,
+	})
+	if err != nil {
+		i.logger(ctx).Warn("Failed to look up near-duplicates", "image_id", imageID, "error", err)
+		return nil
+	}
+
+	if len(matches) > 0 {
+		metrics.NearDuplicateUploads.Inc()
+		i.logger(ctx).Info("Upload is a near-duplicate",
+			"image_id", imageID,
+			"duplicate_of", matches[0].Image.GetImageId(),
+			"distance", matches[0].Distance)
+	}
+
+	return matches
+}
+
+// storedHashes returns the hashes of an image the caller owns or may read.
+func (i *ImageService) storedHashes(ctx context.Context, ownerID string, imageID int64) (*imagev1.PerceptualHash, error) {
+	metadata, err := i.repository.GetImageById(ctx, ownerID, imageID)
+	if errors.Is(err, repository.ErrImageNotFound) {
+		metadata, err = i.sharedImage(ctx, imageID, permission.LevelRead)
+	}
+	if err != nil {
+		if errors.Is(err, ErrImageNotFound) || errors.Is(err, ErrPermissionDenied) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to retrieve image metadata: %w", err)
+	}
+
+	return metadata.GetHashes(), nil
+}
+
+// hashImage decodes image bytes that are not stored and hashes them.
+func (i *ImageService) hashImage(ctx context.Context, image []byte) (*imagev1.PerceptualHash, error) {
+	if err := i.acquireDecode(ctx); err != nil {
+		return nil, err
+	}
+	defer i.decodes.Release()
+
+	decoded, err := i.decode(ctx, image)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+
+	return i.hash(decoded), nil
+}
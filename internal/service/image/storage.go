@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/aidosgal/image-processing-service/internal/lib/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// The helpers below wrap file storage operations in trace spans.
+
+func writeFile(ctx context.Context, path string, data []byte) error {
+	_, span := tracing.Start(ctx, "storage.write",
+		attribute.String("file.path", path),
+		attribute.Int("file.size", len(data)),
+	)
+
+	err := os.WriteFile(path, data, 0644)
+	tracing.End(span, err)
+
+	return err
+}
+
+func readFile(ctx context.Context, path string) ([]byte, error) {
+	_, span := tracing.Start(ctx, "storage.read", attribute.String("file.path", path))
+
+	data, err := os.ReadFile(path)
+	span.SetAttributes(attribute.Int("file.size", len(data)))
+	tracing.End(span, err)
+
+	return data, err
+}
+
+func removeFile(ctx context.Context, path string) error {
+	_, span := tracing.Start(ctx, "storage.remove", attribute.String("file.path", path))
+
+	err := os.Remove(path)
+	tracing.End(span, err)
+
+	return err
+}
+
+// CheckStorage verifies that the image and thumbnail directories can be
+// written to by creating and removing a probe file in each.
+func (i *ImageService) CheckStorage(ctx context.Context) error {
+	for _, dir := range []string{i.imagesDir, i.thumbnailsDir} {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+
+		f, err := os.CreateTemp(dir, ".healthcheck-*")
+		if err != nil {
+			return fmt.Errorf("%s is not writable: %w", dir, err)
+		}
+		f.Close()
+
+		if err := os.Remove(f.Name()); err != nil {
+			return fmt.Errorf("failed to remove probe file: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aidosgal/image-processing-service/internal/lib/metrics"
+	"github.com/aidosgal/image-processing-service/internal/lib/permission"
+	"github.com/aidosgal/image-processing-service/internal/lib/pipeline"
+	lib "github.com/aidosgal/image-processing-service/internal/lib/service"
+	"github.com/aidosgal/image-processing-service/internal/lib/tenant"
+	"github.com/aidosgal/image-processing-service/internal/lib/tracing"
+	"github.com/aidosgal/image-processing-service/internal/lib/variantcache"
+	"github.com/aidosgal/image-processing-service/internal/repository"
+	"github.com/disintegration/imaging"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	ErrInvalidTransform = pipeline.ErrInvalid
+	ErrUnknownPreset    = errors.New("unknown preset")
+)
+
+// Transforms configures TransformImage and the stored thumbnail.
+type Transforms struct {
+	// MaxOperations caps the operations of one request, 0 disables the cap.
+	MaxOperations int
+	Presets       map[string]*pipeline.Pipeline
+	// Thumbnail renders the thumbnail of uploads instead of the default
+	// 200px wide resize, when set.
+	Thumbnail *pipeline.Pipeline
+}
+
+// TransformRequest selects the operations of a transform: a preset or
+// explicit operations, not both.
+type TransformRequest struct {
+	ImageID int64
+	Preset  string
+	Ops     []variantcache.Op
+	// Format is the output format, empty for the format of the original.
+	Format    string
+	Watermark string
+}
+
+// Transformed is an encoded transform result.
+type Transformed struct {
+	Image    []byte
+	MimeType string
+	Width    int
+	Height   int
+}
+
+// TransformImage applies the operations to the original and encodes the
+// result, watermarked as GetImage would be. Results are served from the
+// variant cache.
+func (i *ImageService) TransformImage(ctx context.Context, req TransformRequest) (*Transformed, error) {
+	ctx, span := tracing.Start(ctx, "ImageService.TransformImage", attribute.Int64("image.id", req.ImageID))
+	defer span.End()
+
+	log := i.logger(ctx)
+
+	ownerID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pipe, err := i.transformPipeline(req)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := i.watermarkProfile(ctx, req.Watermark)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := i.repository.GetImageById(ctx, ownerID, req.ImageID)
+	if errors.Is(err, repository.ErrImageNotFound) {
+		metadata, err = i.sharedImage(ctx, req.ImageID, permission.LevelRead)
+	}
+	if err != nil {
+		if errors.Is(err, ErrImageNotFound) || errors.Is(err, ErrPermissionDenied) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to retrieve image metadata: %w", err)
+	}
+
+	formatName := req.Format
+	if formatName == "" {
+		formatName = metadata.GetImageFormat()
+	}
+	format, err := imaging.FormatFromExtension(formatName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unsupported format %q", ErrInvalidTransform, formatName)
+	}
+
+	ops := pipe.Ops()
+	if profile != nil {
+		ops = append(ops, watermarkOp(profile))
+	}
+	key := variantcache.NewKey(metadata.GetImageId(), metadata.GetRevision(), ops, strings.ToLower(format.String()))
+
+	data, err := i.cache.Get(ctx, key, func(ctx context.Context) ([]byte, error) {
+		ctx, cancel := withTimeout(ctx, i.timeouts.Variants)
+		defer cancel()
+
+		original, err := readFile(ctx, metadata.GetFilePath())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read image file: %w", err)
+		}
+
+		if err := i.acquireDecode(ctx); err != nil {
+			return nil, err
+		}
+		defer i.decodes.Release()
+
+		decoded, err := i.decode(ctx, original)
+		if err != nil {
+			return nil, fmt.Errorf("decoding failed: %w", err)
+		}
+
+		defer metrics.ObserveProcessing("transform", time.Now())
+
+		img, err := pipe.Apply(ctx, decoded.Image, hints(metadata))
+		if err != nil {
+			return nil, err
+		}
+		if profile != nil {
+			img = profile.Apply(img)
+		}
+
+		var buf bytes.Buffer
+		if err := imaging.Encode(&buf, img, format); err != nil {
+			return nil, fmt.Errorf("failed to encode transformed image: %w", err)
+		}
+
+		return buf.Bytes(), nil
+	})
+	if err != nil {
+		log.Error("Failed to transform image", "image_id", req.ImageID, "error", err)
+		return nil, err
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transformed image: %w", err)
+	}
+
+	metrics.ServedBytes.Add(float64(len(data)))
+
+	return &Transformed{
+		Image:    data,
+		MimeType: http.DetectContentType(data),
+		Width:    cfg.Width,
+		Height:   cfg.Height,
+	}, nil
+}
+
+// transformPipeline resolves the preset or parses the operations of req.
+func (i *ImageService) transformPipeline(req TransformRequest) (*pipeline.Pipeline, error) {
+	if req.Preset == "" {
+		return pipeline.Parse(req.Ops, i.transforms.MaxOperations)
+	}
+
+	if len(req.Ops) > 0 {
+		return nil, fmt.Errorf("%w: preset and operations cannot be combined", ErrInvalidTransform)
+	}
+
+	pipe, ok := i.transforms.Presets[req.Preset]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownPreset, req.Preset)
+	}
+
+	return pipe, nil
+}
+
+// thumbnailVariant is the thumbnail of uploads, rendered by the configured
+// pipeline if there is one.
+func (i *ImageService) thumbnailVariant(ownerID string) lib.Variant {
+	v := lib.Thumbnail(filepath.Join(i.thumbnailsDir, ownerID))
+	if pipe := i.transforms.Thumbnail; pipe != nil {
+		v.Render = func(ctx context.Context, d *lib.Decoded) (image.Image, error) {
+			return pipe.Apply(ctx, d.Image, d.Hints)
+		}
+	}
+
+	return v
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/jpeg"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/aidosgal/image-processing-service/internal/domain/model"
+	"github.com/aidosgal/image-processing-service/internal/lib/ratelimit"
+	"github.com/aidosgal/image-processing-service/internal/lib/tenant"
+	"github.com/aidosgal/image-processing-service/internal/lib/testutil"
+	"github.com/aidosgal/image-processing-service/internal/repository"
+	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// uploadRepository implements the calls UploadImage makes. Anything else
+// panics through the nil embedded interface.
+type uploadRepository struct {
+	Repository
+}
+
+func (uploadRepository) GetQuota(context.Context, string) (model.Quota, error) {
+	return model.Quota{}, repository.ErrQuotaNotFound
+}
+
+func (uploadRepository) GetUsage(context.Context, string) (model.Usage, error) {
+	return model.Usage{}, nil
+}
+
+func (uploadRepository) StoreImage(context.Context, string, *imagev1.ImageMetadata, model.Quota) (int64, error) {
+	return 1, nil
+}
+
+// newUploadService returns a service configured by cfg that stores files
+// under a fresh temporary storage root, which it returns too.
+func newUploadService(t *testing.T, cfg Config) (*ImageService, string) {
+	t.Helper()
+
+	cfg.StorageRoot = t.TempDir()
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	return NewImageService(log, uploadRepository{}, cfg), cfg.StorageRoot
+}
+
+func testImage(t *testing.T) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 640, 480)), nil))
+
+	return buf.Bytes()
+}
+
+// storedFiles lists every file under the storage root.
+func storedFiles(t *testing.T, root string) []string {
+	t.Helper()
+
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil
+	}
+	require.NoError(t, err)
+
+	return files
+}
+
+func TestUploadImage_StoresOriginalAndThumbnail(t *testing.T) {
+	s, root := newUploadService(t, Config{})
+	ctx := tenant.WithID(context.Background(), "tenant-a")
+
+	id, err := s.UploadImage(ctx, testImage(t), "photo.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), id)
+
+	assert.Len(t, storedFiles(t, root), 2)
+}
+
+func TestUploadImage_CancelledLeavesNothingBehind(t *testing.T) {
+	s, root := newUploadService(t, Config{})
+	base := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(tenant.WithID(context.Background(), "tenant-a"))
+	cancel()
+
+	_, err := s.UploadImage(ctx, testImage(t), "photo.jpg")
+	require.ErrorIs(t, err, context.Canceled)
+
+	assert.Empty(t, storedFiles(t, root))
+	testutil.AssertNoLeakedGoroutines(t, base)
+}
+
+func TestUploadImage_VariantTimeoutLeavesNothingBehind(t *testing.T) {
+	s, root := newUploadService(t, Config{Timeouts: Timeouts{Variants: time.Nanosecond}})
+	base := runtime.NumGoroutine()
+
+	ctx := tenant.WithID(context.Background(), "tenant-a")
+
+	_, err := s.UploadImage(ctx, testImage(t), "photo.jpg")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	assert.Empty(t, storedFiles(t, root))
+	testutil.AssertNoLeakedGoroutines(t, base)
+}
+
+func TestDrain_RejectsNewUploads(t *testing.T) {
+	s, _ := newUploadService(t, Config{})
+
+	require.NoError(t, s.Drain(context.Background()))
+
+	_, err := s.UploadImage(tenant.WithID(context.Background(), "tenant-a"), testImage(t), "photo.jpg")
+	assert.ErrorIs(t, err, ErrShuttingDown)
+}
+
+func TestUploadImage_BusyWhenDecodesAreTaken(t *testing.T) {
+	decodes := ratelimit.NewConcurrencyLimiter(1, 10*time.Millisecond)
+	s, root := newUploadService(t, Config{Decodes: decodes})
+
+	require.NoError(t, decodes.Acquire(context.Background()))
+
+	_, err := s.UploadImage(tenant.WithID(context.Background(), "tenant-a"), testImage(t), "photo.jpg")
+	assert.ErrorIs(t, err, ErrBusy)
+	var busy *ratelimit.BusyError
+	require.ErrorAs(t, err, &busy)
+	assert.Equal(t, 10*time.Millisecond, busy.RetryAfter)
+	assert.Empty(t, storedFiles(t, root))
+
+	decodes.Release()
+	_, err = s.UploadImage(tenant.WithID(context.Background(), "tenant-a"), testImage(t), "photo.jpg")
+	require.NoError(t, err)
+	assert.Zero(t, decodes.InUse(), "the slot is released after the upload")
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aidosgal/image-processing-service/internal/domain/model"
+	"github.com/aidosgal/image-processing-service/internal/lib/auth"
+	"github.com/aidosgal/image-processing-service/internal/lib/permission"
+	"github.com/aidosgal/image-processing-service/internal/lib/tenant"
+	"github.com/aidosgal/image-processing-service/internal/repository"
+)
+
+// GetUsage returns the usage and effective quota of tenantID, or of the
+// caller's tenant when tenantID is empty.
+func (i *ImageService) GetUsage(ctx context.Context, tenantID string) (model.Usage, model.Quota, error) {
+	log := i.logger(ctx)
+
+	ownerID, err := tenant.FromContext(ctx)
+	if err != nil {
+		return model.Usage{}, model.Quota{}, err
+	}
+
+	if tenantID != "" && tenantID != ownerID {
+		if err := requireOperator(ctx, "reading usage of another tenant"); err != nil {
+			return model.Usage{}, model.Quota{}, err
+		}
+		ownerID = tenantID
+	}
+
+	usage, err := i.repository.GetUsage(ctx, ownerID)
+	if err != nil {
+		log.Error("Failed to get usage", "owner_id", ownerID, "error", err)
+		return model.Usage{}, model.Quota{}, fmt.Errorf("failed to get usage: %w", err)
+	}
+
+	quota, err := i.effectiveQuota(ctx, ownerID)
+	if err != nil {
+		return model.Usage{}, model.Quota{}, err
+	}
+
+	return usage, quota, nil
+}
+
+func (i *ImageService) SetQuota(ctx context.Context, tenantID string, quota model.Quota) error {
+	log := i.logger(ctx)
+
+	if err := requireOperator(ctx, "setting quotas"); err != nil {
+		return err
+	}
+
+	if err := i.repository.SetQuota(ctx, tenantID, quota); err != nil {
+		log.Error("Failed to set quota", "owner_id", tenantID, "error", err)
+		return fmt.Errorf("failed to set quota: %w", err)
+	}
+
+	log.Info("Quota override set",
+		"owner_id", tenantID,
+		"max_total_bytes", quota.MaxTotalBytes,
+		"max_images", quota.MaxImages,
+		"max_monthly_upload_bytes", quota.MaxMonthlyUploadBytes,
+	)
+
+	return nil
+}
+
+// effectiveQuota returns the owner's quota override or the configured default.
+func (i *ImageService) effectiveQuota(ctx context.Context, ownerID string) (model.Quota, error) {
+	quota, err := i.repository.GetQuota(ctx, ownerID)
+	if errors.Is(err, repository.ErrQuotaNotFound) {
+		return i.defaultQuota, nil
+	}
+	if err != nil {
+		return model.Quota{}, fmt.Errorf("failed to get quota: %w", err)
+	}
+
+	return quota, nil
+}
+
+func requireOperator(ctx context.Context, action string) error {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok || !permission.HasRole(principal.Roles, permission.RoleOperator) {
+		return permission.Denied("%s requires the %s role", action, permission.RoleOperator)
+	}
+
+	return nil
+}
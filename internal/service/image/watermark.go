@@ -0,0 +1,157 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aidosgal/image-processing-service/internal/lib/auth"
+	"github.com/aidosgal/image-processing-service/internal/lib/metrics"
+	"github.com/aidosgal/image-processing-service/internal/lib/permission"
+	lib "github.com/aidosgal/image-processing-service/internal/lib/service"
+	"github.com/aidosgal/image-processing-service/internal/lib/tracing"
+	"github.com/aidosgal/image-processing-service/internal/lib/variantcache"
+	"github.com/aidosgal/image-processing-service/internal/lib/watermark"
+	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
+	"github.com/disintegration/imaging"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var ErrUnknownWatermark = errors.New("unknown watermark profile")
+
+// watermarkProfile resolves the profile a retrieval is watermarked with.
+// A profile set on the caller's API key always applies; a request may only
+// name that one. Without either, nil is returned.
+func (i *ImageService) watermarkProfile(ctx context.Context, requested string) (*watermark.Profile, error) {
+	name := requested
+	if p, ok := auth.PrincipalFromContext(ctx); ok && p.Watermark != "" {
+		if requested != "" && requested != p.Watermark {
+			return nil, permission.Denied("images retrieved with this API key are watermarked with %q", p.Watermark)
+		}
+		name = p.Watermark
+	}
+
+	if name == "" {
+		return nil, nil
+	}
+
+	profile, ok := i.watermarks[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownWatermark, name)
+	}
+
+	return profile, nil
+}
+
+// watermarkDir holds the watermarked variants stored for the original at
+// filePath, one per profile.
+func (i *ImageService) watermarkDir(ownerID, filePath string) string {
+	return filepath.Join(i.watermarksDir, ownerID, filepath.Base(filePath))
+}
+
+// watermarkVariants are the variants rendered at upload for the profiles
+// that ask for it, sorted by name.
+func (i *ImageService) watermarkVariants(ownerID, filePath string) []lib.Variant {
+	var variants []lib.Variant
+	for _, p := range i.watermarks {
+		if !p.OnUpload {
+			continue
+		}
+		variants = append(variants, lib.Variant{
+			Name:   "watermark_" + p.Name,
+			Dir:    i.watermarkDir(ownerID, filePath),
+			Prefix: watermarkPrefix(p),
+			Render: func(_ context.Context, d *lib.Decoded) (image.Image, error) {
+				return p.Apply(d.Image), nil
+			},
+		})
+	}
+
+	slices.SortFunc(variants, func(a, b lib.Variant) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return variants
+}
+
+// watermarkPrefix names the stored variant of a profile. It includes the
+// profile version, so variants rendered before the profile changed are
+// not served.
+func watermarkPrefix(p *watermark.Profile) string {
+	return p.Name + "-" + p.Version() + "_"
+}
+
+// watermarkOp identifies a profile in cache keys.
+func watermarkOp(p *watermark.Profile) variantcache.Op {
+	return variantcache.Op{
+		Name:   "watermark",
+		Params: map[string]string{"profile": p.Name, "version": p.Version()},
+	}
+}
+
+// watermarked returns the original watermarked with profile: the variant
+// stored at upload when there is one, otherwise rendered through the
+// variant cache.
+func (i *ImageService) watermarked(ctx context.Context, metadata *imagev1.ImageMetadata, original []byte, profile *watermark.Profile) ([]byte, error) {
+	ctx, span := tracing.Start(ctx, "ImageService.watermarked", attribute.String("watermark.profile", profile.Name))
+	defer span.End()
+
+	if profile.OnUpload {
+		path := filepath.Join(i.watermarkDir(metadata.GetOwnerId(), metadata.GetFilePath()), watermarkPrefix(profile)+filepath.Base(metadata.GetFilePath()))
+		data, err := readFile(ctx, path)
+		if err == nil {
+			return data, nil
+		}
+		if !os.IsNotExist(err) {
+			i.logger(ctx).Warn("Failed to read watermarked variant", "path", path, "error", err)
+		}
+	}
+
+	ops := []variantcache.Op{watermarkOp(profile)}
+	key := variantcache.NewKey(metadata.GetImageId(), metadata.GetRevision(), ops, metadata.GetImageFormat())
+
+	return i.cache.Get(ctx, key, func(ctx context.Context) ([]byte, error) {
+		ctx, cancel := withTimeout(ctx, i.timeouts.Variants)
+		defer cancel()
+
+		if err := i.acquireDecode(ctx); err != nil {
+			return nil, err
+		}
+		defer i.decodes.Release()
+
+		decoded, err := i.decode(ctx, original)
+		if err != nil {
+			return nil, fmt.Errorf("decoding failed: %w", err)
+		}
+
+		defer metrics.ObserveProcessing("watermark", time.Now())
+
+		format, err := imaging.FormatFromExtension(decoded.Format)
+		if err != nil {
+			format = imaging.JPEG
+		}
+
+		var buf bytes.Buffer
+		if err := imaging.Encode(&buf, profile.Apply(decoded.Image), format); err != nil {
+			return nil, fmt.Errorf("failed to encode watermarked image: %w", err)
+		}
+
+		return buf.Bytes(), nil
+	})
+}
+
+// removeWatermarkVariants deletes the watermarked variants stored for the
+// original at filePath, including those of profiles since changed.
+func (i *ImageService) removeWatermarkVariants(ctx context.Context, ownerID, filePath string) {
+	dir := i.watermarkDir(ownerID, filePath)
+	if err := os.RemoveAll(dir); err != nil {
+		i.logger(ctx).Warn("Failed to delete watermarked variants", "path", dir, "error", err)
+	}
+}
@@ -0,0 +1,2 @@
+-- Crop hints set by SetCropHints, a JSON array. NULL when there are none.
+ALTER TABLE images ADD COLUMN IF NOT EXISTS crop_hints JSONB;
@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS idx_shares_image_grantee;
+DROP INDEX IF EXISTS idx_shares_album_grantee;
+DROP INDEX IF EXISTS idx_shares_grantee;
+
+-- Without the tenant, shares to the same subject in different tenants
+-- collide; the newest is kept.
+DELETE FROM shares s USING shares newer
+WHERE newer.id > s.id
+    AND newer.grantee = s.grantee
+    AND (newer.image_id = s.image_id OR newer.album_id = s.album_id);
+
+ALTER TABLE shares DROP COLUMN IF EXISTS grantee_tenant;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shares_image_grantee ON shares (image_id, grantee) WHERE image_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shares_album_grantee ON shares (album_id, grantee) WHERE album_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_shares_grantee ON shares (grantee);
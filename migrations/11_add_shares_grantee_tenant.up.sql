@@ -0,0 +1,12 @@
+-- Subjects are only unique within a tenant, so shares name the grantee's
+-- tenant too. Existing shares are kept for principals of the owner's tenant.
+ALTER TABLE shares ADD COLUMN IF NOT EXISTS grantee_tenant VARCHAR(64);
+UPDATE shares SET grantee_tenant = owner_id WHERE grantee_tenant IS NULL;
+ALTER TABLE shares ALTER COLUMN grantee_tenant SET NOT NULL;
+
+DROP INDEX IF EXISTS idx_shares_image_grantee;
+DROP INDEX IF EXISTS idx_shares_album_grantee;
+DROP INDEX IF EXISTS idx_shares_grantee;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shares_image_grantee ON shares (image_id, grantee_tenant, grantee) WHERE image_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shares_album_grantee ON shares (album_id, grantee_tenant, grantee) WHERE album_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_shares_grantee ON shares (grantee_tenant, grantee);
@@ -0,0 +1,16 @@
+DROP INDEX IF EXISTS idx_images_ahash_band0;
+DROP INDEX IF EXISTS idx_images_ahash_band1;
+DROP INDEX IF EXISTS idx_images_ahash_band2;
+DROP INDEX IF EXISTS idx_images_ahash_band3;
+DROP INDEX IF EXISTS idx_images_dhash_band0;
+DROP INDEX IF EXISTS idx_images_dhash_band1;
+DROP INDEX IF EXISTS idx_images_dhash_band2;
+DROP INDEX IF EXISTS idx_images_dhash_band3;
+DROP INDEX IF EXISTS idx_images_phash_band0;
+DROP INDEX IF EXISTS idx_images_phash_band1;
+DROP INDEX IF EXISTS idx_images_phash_band2;
+DROP INDEX IF EXISTS idx_images_phash_band3;
+
+CREATE INDEX IF NOT EXISTS idx_images_owner_id_ahash ON images (owner_id, ahash) WHERE ahash IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_images_owner_id_dhash ON images (owner_id, dhash) WHERE dhash IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_images_owner_id_phash ON images (owner_id, phash) WHERE phash IS NOT NULL;
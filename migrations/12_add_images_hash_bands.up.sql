@@ -0,0 +1,24 @@
+-- A B-tree on the whole hash can't find hashes within a Hamming distance.
+-- Each hash is split into four 16-bit bands instead, indexed separately:
+-- two hashes at most d bits apart have a band at most d/4 bits apart, so
+-- near-duplicate search looks up the few band values that close and only
+-- measures the distance of the rows they find. The expressions must match
+-- bandExpr in internal/repository/psql/similarity.go.
+DROP INDEX IF EXISTS idx_images_owner_id_ahash;
+DROP INDEX IF EXISTS idx_images_owner_id_dhash;
+DROP INDEX IF EXISTS idx_images_owner_id_phash;
+
+CREATE INDEX IF NOT EXISTS idx_images_ahash_band0 ON images (owner_id, ((ahash >> 0) & 65535)) WHERE ahash IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_images_ahash_band1 ON images (owner_id, ((ahash >> 16) & 65535)) WHERE ahash IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_images_ahash_band2 ON images (owner_id, ((ahash >> 32) & 65535)) WHERE ahash IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_images_ahash_band3 ON images (owner_id, ((ahash >> 48) & 65535)) WHERE ahash IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_images_dhash_band0 ON images (owner_id, ((dhash >> 0) & 65535)) WHERE dhash IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_images_dhash_band1 ON images (owner_id, ((dhash >> 16) & 65535)) WHERE dhash IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_images_dhash_band2 ON images (owner_id, ((dhash >> 32) & 65535)) WHERE dhash IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_images_dhash_band3 ON images (owner_id, ((dhash >> 48) & 65535)) WHERE dhash IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_images_phash_band0 ON images (owner_id, ((phash >> 0) & 65535)) WHERE phash IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_images_phash_band1 ON images (owner_id, ((phash >> 16) & 65535)) WHERE phash IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_images_phash_band2 ON images (owner_id, ((phash >> 32) & 65535)) WHERE phash IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_images_phash_band3 ON images (owner_id, ((phash >> 48) & 65535)) WHERE phash IS NOT NULL;
@@ -1 +1 @@
-DROP TABLE IF NOT EXISTS images;
+DROP TABLE IF EXISTS images;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_images_owner_id_uploaded_at;
+ALTER TABLE images DROP COLUMN IF EXISTS owner_id;
@@ -0,0 +1,3 @@
+ALTER TABLE images ADD COLUMN IF NOT EXISTS owner_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE images ALTER COLUMN owner_id DROP DEFAULT;
+CREATE INDEX IF NOT EXISTS idx_images_owner_id_uploaded_at ON images (owner_id, uploaded_at DESC);
@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS albums (
+    id SERIAL PRIMARY KEY,
+    owner_id VARCHAR(64) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_albums_owner_id ON albums (owner_id);
+
+CREATE TABLE IF NOT EXISTS album_images (
+    album_id INTEGER NOT NULL REFERENCES albums (id) ON DELETE CASCADE,
+    image_id INTEGER NOT NULL REFERENCES images (id) ON DELETE CASCADE,
+    PRIMARY KEY (album_id, image_id)
+);
+CREATE INDEX IF NOT EXISTS idx_album_images_image_id ON album_images (image_id);
+
+CREATE TABLE IF NOT EXISTS shares (
+    id SERIAL PRIMARY KEY,
+    owner_id VARCHAR(64) NOT NULL,
+    image_id INTEGER REFERENCES images (id) ON DELETE CASCADE,
+    album_id INTEGER REFERENCES albums (id) ON DELETE CASCADE,
+    grantee VARCHAR(255) NOT NULL,
+    permission VARCHAR(16) NOT NULL CHECK (permission IN ('read', 'write')),
+    created_at TIMESTAMP DEFAULT NOW(),
+    CHECK ((image_id IS NULL) <> (album_id IS NULL))
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shares_image_grantee ON shares (image_id, grantee) WHERE image_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shares_album_grantee ON shares (album_id, grantee) WHERE album_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_shares_grantee ON shares (grantee);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS tenant_quotas;
+DROP TABLE IF EXISTS tenant_usage;
+ALTER TABLE images DROP COLUMN IF EXISTS variants_size;
@@ -0,0 +1,24 @@
+ALTER TABLE images ADD COLUMN IF NOT EXISTS variants_size BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS tenant_usage (
+    owner_id VARCHAR(64) PRIMARY KEY,
+    total_bytes BIGINT NOT NULL DEFAULT 0,
+    image_count BIGINT NOT NULL DEFAULT 0,
+    month DATE NOT NULL DEFAULT date_trunc('month', NOW())::date,
+    monthly_upload_bytes BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS tenant_quotas (
+    owner_id VARCHAR(64) PRIMARY KEY,
+    max_total_bytes BIGINT NOT NULL DEFAULT 0,
+    max_images BIGINT NOT NULL DEFAULT 0,
+    max_monthly_upload_bytes BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+INSERT INTO tenant_usage (owner_id, total_bytes, image_count)
+SELECT owner_id, SUM(file_size), COUNT(*)
+FROM images
+GROUP BY owner_id
+ON CONFLICT (owner_id) DO NOTHING;
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_images_owner_id_phash;
+DROP INDEX IF EXISTS idx_images_owner_id_dhash;
+DROP INDEX IF EXISTS idx_images_owner_id_ahash;
+
+ALTER TABLE images DROP COLUMN IF EXISTS phash;
+ALTER TABLE images DROP COLUMN IF EXISTS dhash;
+ALTER TABLE images DROP COLUMN IF EXISTS ahash;
@@ -0,0 +1,9 @@
+-- Perceptual hashes, stored as the signed bit pattern of the unsigned
+-- 64-bit hash. NULL for images stored before hashing was added.
+ALTER TABLE images ADD COLUMN IF NOT EXISTS ahash BIGINT;
+ALTER TABLE images ADD COLUMN IF NOT EXISTS dhash BIGINT;
+ALTER TABLE images ADD COLUMN IF NOT EXISTS phash BIGINT;
+
+CREATE INDEX IF NOT EXISTS idx_images_owner_id_ahash ON images (owner_id, ahash) WHERE ahash IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_images_owner_id_dhash ON images (owner_id, dhash) WHERE dhash IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_images_owner_id_phash ON images (owner_id, phash) WHERE phash IS NOT NULL;
@@ -0,0 +1,5 @@
+ALTER TABLE images DROP COLUMN IF EXISTS grayscale;
+ALTER TABLE images DROP COLUMN IF EXISTS colorfulness;
+ALTER TABLE images DROP COLUMN IF EXISTS brightness;
+ALTER TABLE images DROP COLUMN IF EXISTS average_color;
+ALTER TABLE images DROP COLUMN IF EXISTS palette;
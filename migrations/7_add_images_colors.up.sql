@@ -0,0 +1,6 @@
+-- Color summary extracted at upload. NULL for images stored before it was.
+ALTER TABLE images ADD COLUMN IF NOT EXISTS palette JSONB;
+ALTER TABLE images ADD COLUMN IF NOT EXISTS average_color VARCHAR(7);
+ALTER TABLE images ADD COLUMN IF NOT EXISTS brightness DOUBLE PRECISION;
+ALTER TABLE images ADD COLUMN IF NOT EXISTS colorfulness DOUBLE PRECISION;
+ALTER TABLE images ADD COLUMN IF NOT EXISTS grayscale BOOLEAN;
@@ -0,0 +1,3 @@
+-- Placeholder generated at upload. NULL for images stored before it was.
+ALTER TABLE images ADD COLUMN IF NOT EXISTS blurhash TEXT;
+ALTER TABLE images ADD COLUMN IF NOT EXISTS preview TEXT;
@@ -0,0 +1,3 @@
+ALTER TABLE images DROP COLUMN IF EXISTS focal_manual;
+ALTER TABLE images DROP COLUMN IF EXISTS focal_y;
+ALTER TABLE images DROP COLUMN IF EXISTS focal_x;
@@ -0,0 +1,5 @@
+-- Focal point found at upload or set by SetFocalPoint. NULL for images
+-- stored before focal points were found.
+ALTER TABLE images ADD COLUMN IF NOT EXISTS focal_x DOUBLE PRECISION;
+ALTER TABLE images ADD COLUMN IF NOT EXISTS focal_y DOUBLE PRECISION;
+ALTER TABLE images ADD COLUMN IF NOT EXISTS focal_manual BOOLEAN NOT NULL DEFAULT FALSE;
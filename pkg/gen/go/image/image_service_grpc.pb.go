@@ -19,10 +19,22 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	ImageService_UploadImage_FullMethodName = "/image.ImageService/UploadImage"
-	ImageService_ListImages_FullMethodName  = "/image.ImageService/ListImages"
-	ImageService_GetImage_FullMethodName    = "/image.ImageService/GetImage"
-	ImageService_DeleteImage_FullMethodName = "/image.ImageService/DeleteImage"
+	ImageService_UploadImage_FullMethodName       = "/image.ImageService/UploadImage"
+	ImageService_ListImages_FullMethodName        = "/image.ImageService/ListImages"
+	ImageService_GetImage_FullMethodName          = "/image.ImageService/GetImage"
+	ImageService_DeleteImage_FullMethodName       = "/image.ImageService/DeleteImage"
+	ImageService_PurgeImages_FullMethodName       = "/image.ImageService/PurgeImages"
+	ImageService_CreateAlbum_FullMethodName       = "/image.ImageService/CreateAlbum"
+	ImageService_AddImageToAlbum_FullMethodName   = "/image.ImageService/AddImageToAlbum"
+	ImageService_ShareImage_FullMethodName        = "/image.ImageService/ShareImage"
+	ImageService_ShareAlbum_FullMethodName        = "/image.ImageService/ShareAlbum"
+	ImageService_RevokeShare_FullMethodName       = "/image.ImageService/RevokeShare"
+	ImageService_GetUsage_FullMethodName          = "/image.ImageService/GetUsage"
+	ImageService_SetQuota_FullMethodName          = "/image.ImageService/SetQuota"
+	ImageService_FindSimilarImages_FullMethodName = "/image.ImageService/FindSimilarImages"
+	ImageService_TransformImage_FullMethodName    = "/image.ImageService/TransformImage"
+	ImageService_SetFocalPoint_FullMethodName     = "/image.ImageService/SetFocalPoint"
+	ImageService_SetCropHints_FullMethodName      = "/image.ImageService/SetCropHints"
 )
 
 // ImageServiceClient is the client API for ImageService service.
@@ -33,6 +45,18 @@ type ImageServiceClient interface {
 	ListImages(ctx context.Context, in *ListImagesRequest, opts ...grpc.CallOption) (*ListImagesResponse, error)
 	GetImage(ctx context.Context, in *GetImageRequest, opts ...grpc.CallOption) (*GetImageResponse, error)
 	DeleteImage(ctx context.Context, in *DeleteImageRequest, opts ...grpc.CallOption) (*DeleteImageResponse, error)
+	PurgeImages(ctx context.Context, in *PurgeImagesRequest, opts ...grpc.CallOption) (*PurgeImagesResponse, error)
+	CreateAlbum(ctx context.Context, in *CreateAlbumRequest, opts ...grpc.CallOption) (*CreateAlbumResponse, error)
+	AddImageToAlbum(ctx context.Context, in *AddImageToAlbumRequest, opts ...grpc.CallOption) (*AddImageToAlbumResponse, error)
+	ShareImage(ctx context.Context, in *ShareImageRequest, opts ...grpc.CallOption) (*ShareResponse, error)
+	ShareAlbum(ctx context.Context, in *ShareAlbumRequest, opts ...grpc.CallOption) (*ShareResponse, error)
+	RevokeShare(ctx context.Context, in *RevokeShareRequest, opts ...grpc.CallOption) (*RevokeShareResponse, error)
+	GetUsage(ctx context.Context, in *GetUsageRequest, opts ...grpc.CallOption) (*GetUsageResponse, error)
+	SetQuota(ctx context.Context, in *SetQuotaRequest, opts ...grpc.CallOption) (*SetQuotaResponse, error)
+	FindSimilarImages(ctx context.Context, in *FindSimilarImagesRequest, opts ...grpc.CallOption) (*FindSimilarImagesResponse, error)
+	TransformImage(ctx context.Context, in *TransformImageRequest, opts ...grpc.CallOption) (*TransformImageResponse, error)
+	SetFocalPoint(ctx context.Context, in *SetFocalPointRequest, opts ...grpc.CallOption) (*SetFocalPointResponse, error)
+	SetCropHints(ctx context.Context, in *SetCropHintsRequest, opts ...grpc.CallOption) (*SetCropHintsResponse, error)
 }
 
 type imageServiceClient struct {
@@ -83,6 +107,126 @@ func (c *imageServiceClient) DeleteImage(ctx context.Context, in *DeleteImageReq
 	return out, nil
 }
 
+func (c *imageServiceClient) PurgeImages(ctx context.Context, in *PurgeImagesRequest, opts ...grpc.CallOption) (*PurgeImagesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PurgeImagesResponse)
+	err := c.cc.Invoke(ctx, ImageService_PurgeImages_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *imageServiceClient) CreateAlbum(ctx context.Context, in *CreateAlbumRequest, opts ...grpc.CallOption) (*CreateAlbumResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateAlbumResponse)
+	err := c.cc.Invoke(ctx, ImageService_CreateAlbum_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *imageServiceClient) AddImageToAlbum(ctx context.Context, in *AddImageToAlbumRequest, opts ...grpc.CallOption) (*AddImageToAlbumResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AddImageToAlbumResponse)
+	err := c.cc.Invoke(ctx, ImageService_AddImageToAlbum_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *imageServiceClient) ShareImage(ctx context.Context, in *ShareImageRequest, opts ...grpc.CallOption) (*ShareResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ShareResponse)
+	err := c.cc.Invoke(ctx, ImageService_ShareImage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *imageServiceClient) ShareAlbum(ctx context.Context, in *ShareAlbumRequest, opts ...grpc.CallOption) (*ShareResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ShareResponse)
+	err := c.cc.Invoke(ctx, ImageService_ShareAlbum_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *imageServiceClient) RevokeShare(ctx context.Context, in *RevokeShareRequest, opts ...grpc.CallOption) (*RevokeShareResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RevokeShareResponse)
+	err := c.cc.Invoke(ctx, ImageService_RevokeShare_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *imageServiceClient) GetUsage(ctx context.Context, in *GetUsageRequest, opts ...grpc.CallOption) (*GetUsageResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetUsageResponse)
+	err := c.cc.Invoke(ctx, ImageService_GetUsage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *imageServiceClient) SetQuota(ctx context.Context, in *SetQuotaRequest, opts ...grpc.CallOption) (*SetQuotaResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetQuotaResponse)
+	err := c.cc.Invoke(ctx, ImageService_SetQuota_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *imageServiceClient) FindSimilarImages(ctx context.Context, in *FindSimilarImagesRequest, opts ...grpc.CallOption) (*FindSimilarImagesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FindSimilarImagesResponse)
+	err := c.cc.Invoke(ctx, ImageService_FindSimilarImages_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *imageServiceClient) TransformImage(ctx context.Context, in *TransformImageRequest, opts ...grpc.CallOption) (*TransformImageResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TransformImageResponse)
+	err := c.cc.Invoke(ctx, ImageService_TransformImage_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *imageServiceClient) SetFocalPoint(ctx context.Context, in *SetFocalPointRequest, opts ...grpc.CallOption) (*SetFocalPointResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetFocalPointResponse)
+	err := c.cc.Invoke(ctx, ImageService_SetFocalPoint_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *imageServiceClient) SetCropHints(ctx context.Context, in *SetCropHintsRequest, opts ...grpc.CallOption) (*SetCropHintsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetCropHintsResponse)
+	err := c.cc.Invoke(ctx, ImageService_SetCropHints_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ImageServiceServer is the server API for ImageService service.
 // All implementations must embed UnimplementedImageServiceServer
 // for forward compatibility.
@@ -91,6 +235,18 @@ type ImageServiceServer interface {
 	ListImages(context.Context, *ListImagesRequest) (*ListImagesResponse, error)
 	GetImage(context.Context, *GetImageRequest) (*GetImageResponse, error)
 	DeleteImage(context.Context, *DeleteImageRequest) (*DeleteImageResponse, error)
+	PurgeImages(context.Context, *PurgeImagesRequest) (*PurgeImagesResponse, error)
+	CreateAlbum(context.Context, *CreateAlbumRequest) (*CreateAlbumResponse, error)
+	AddImageToAlbum(context.Context, *AddImageToAlbumRequest) (*AddImageToAlbumResponse, error)
+	ShareImage(context.Context, *ShareImageRequest) (*ShareResponse, error)
+	ShareAlbum(context.Context, *ShareAlbumRequest) (*ShareResponse, error)
+	RevokeShare(context.Context, *RevokeShareRequest) (*RevokeShareResponse, error)
+	GetUsage(context.Context, *GetUsageRequest) (*GetUsageResponse, error)
+	SetQuota(context.Context, *SetQuotaRequest) (*SetQuotaResponse, error)
+	FindSimilarImages(context.Context, *FindSimilarImagesRequest) (*FindSimilarImagesResponse, error)
+	TransformImage(context.Context, *TransformImageRequest) (*TransformImageResponse, error)
+	SetFocalPoint(context.Context, *SetFocalPointRequest) (*SetFocalPointResponse, error)
+	SetCropHints(context.Context, *SetCropHintsRequest) (*SetCropHintsResponse, error)
 	mustEmbedUnimplementedImageServiceServer()
 }
 
@@ -113,6 +269,42 @@ func (UnimplementedImageServiceServer) GetImage(context.Context, *GetImageReques
 func (UnimplementedImageServiceServer) DeleteImage(context.Context, *DeleteImageRequest) (*DeleteImageResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteImage not implemented")
 }
+func (UnimplementedImageServiceServer) PurgeImages(context.Context, *PurgeImagesRequest) (*PurgeImagesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PurgeImages not implemented")
+}
+func (UnimplementedImageServiceServer) CreateAlbum(context.Context, *CreateAlbumRequest) (*CreateAlbumResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateAlbum not implemented")
+}
+func (UnimplementedImageServiceServer) AddImageToAlbum(context.Context, *AddImageToAlbumRequest) (*AddImageToAlbumResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddImageToAlbum not implemented")
+}
+func (UnimplementedImageServiceServer) ShareImage(context.Context, *ShareImageRequest) (*ShareResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ShareImage not implemented")
+}
+func (UnimplementedImageServiceServer) ShareAlbum(context.Context, *ShareAlbumRequest) (*ShareResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ShareAlbum not implemented")
+}
+func (UnimplementedImageServiceServer) RevokeShare(context.Context, *RevokeShareRequest) (*RevokeShareResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeShare not implemented")
+}
+func (UnimplementedImageServiceServer) GetUsage(context.Context, *GetUsageRequest) (*GetUsageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUsage not implemented")
+}
+func (UnimplementedImageServiceServer) SetQuota(context.Context, *SetQuotaRequest) (*SetQuotaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetQuota not implemented")
+}
+func (UnimplementedImageServiceServer) FindSimilarImages(context.Context, *FindSimilarImagesRequest) (*FindSimilarImagesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindSimilarImages not implemented")
+}
+func (UnimplementedImageServiceServer) TransformImage(context.Context, *TransformImageRequest) (*TransformImageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TransformImage not implemented")
+}
+func (UnimplementedImageServiceServer) SetFocalPoint(context.Context, *SetFocalPointRequest) (*SetFocalPointResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetFocalPoint not implemented")
+}
+func (UnimplementedImageServiceServer) SetCropHints(context.Context, *SetCropHintsRequest) (*SetCropHintsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetCropHints not implemented")
+}
 func (UnimplementedImageServiceServer) mustEmbedUnimplementedImageServiceServer() {}
 func (UnimplementedImageServiceServer) testEmbeddedByValue()                      {}
 
@@ -206,6 +398,222 @@ func _ImageService_DeleteImage_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ImageService_PurgeImages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PurgeImagesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImageServiceServer).PurgeImages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ImageService_PurgeImages_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImageServiceServer).PurgeImages(ctx, req.(*PurgeImagesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ImageService_CreateAlbum_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateAlbumRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImageServiceServer).CreateAlbum(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ImageService_CreateAlbum_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImageServiceServer).CreateAlbum(ctx, req.(*CreateAlbumRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ImageService_AddImageToAlbum_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddImageToAlbumRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImageServiceServer).AddImageToAlbum(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ImageService_AddImageToAlbum_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImageServiceServer).AddImageToAlbum(ctx, req.(*AddImageToAlbumRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ImageService_ShareImage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShareImageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImageServiceServer).ShareImage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ImageService_ShareImage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImageServiceServer).ShareImage(ctx, req.(*ShareImageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ImageService_ShareAlbum_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShareAlbumRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImageServiceServer).ShareAlbum(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ImageService_ShareAlbum_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImageServiceServer).ShareAlbum(ctx, req.(*ShareAlbumRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ImageService_RevokeShare_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeShareRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImageServiceServer).RevokeShare(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ImageService_RevokeShare_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImageServiceServer).RevokeShare(ctx, req.(*RevokeShareRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ImageService_GetUsage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUsageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImageServiceServer).GetUsage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ImageService_GetUsage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImageServiceServer).GetUsage(ctx, req.(*GetUsageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ImageService_SetQuota_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetQuotaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImageServiceServer).SetQuota(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ImageService_SetQuota_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImageServiceServer).SetQuota(ctx, req.(*SetQuotaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ImageService_FindSimilarImages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindSimilarImagesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImageServiceServer).FindSimilarImages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ImageService_FindSimilarImages_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImageServiceServer).FindSimilarImages(ctx, req.(*FindSimilarImagesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ImageService_TransformImage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransformImageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImageServiceServer).TransformImage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ImageService_TransformImage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImageServiceServer).TransformImage(ctx, req.(*TransformImageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ImageService_SetFocalPoint_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetFocalPointRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImageServiceServer).SetFocalPoint(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ImageService_SetFocalPoint_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImageServiceServer).SetFocalPoint(ctx, req.(*SetFocalPointRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ImageService_SetCropHints_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetCropHintsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ImageServiceServer).SetCropHints(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ImageService_SetCropHints_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ImageServiceServer).SetCropHints(ctx, req.(*SetCropHintsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ImageService_ServiceDesc is the grpc.ServiceDesc for ImageService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -229,6 +637,54 @@ var ImageService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteImage",
 			Handler:    _ImageService_DeleteImage_Handler,
 		},
+		{
+			MethodName: "PurgeImages",
+			Handler:    _ImageService_PurgeImages_Handler,
+		},
+		{
+			MethodName: "CreateAlbum",
+			Handler:    _ImageService_CreateAlbum_Handler,
+		},
+		{
+			MethodName: "AddImageToAlbum",
+			Handler:    _ImageService_AddImageToAlbum_Handler,
+		},
+		{
+			MethodName: "ShareImage",
+			Handler:    _ImageService_ShareImage_Handler,
+		},
+		{
+			MethodName: "ShareAlbum",
+			Handler:    _ImageService_ShareAlbum_Handler,
+		},
+		{
+			MethodName: "RevokeShare",
+			Handler:    _ImageService_RevokeShare_Handler,
+		},
+		{
+			MethodName: "GetUsage",
+			Handler:    _ImageService_GetUsage_Handler,
+		},
+		{
+			MethodName: "SetQuota",
+			Handler:    _ImageService_SetQuota_Handler,
+		},
+		{
+			MethodName: "FindSimilarImages",
+			Handler:    _ImageService_FindSimilarImages_Handler,
+		},
+		{
+			MethodName: "TransformImage",
+			Handler:    _ImageService_TransformImage_Handler,
+		},
+		{
+			MethodName: "SetFocalPoint",
+			Handler:    _ImageService_SetFocalPoint_Handler,
+		},
+		{
+			MethodName: "SetCropHints",
+			Handler:    _ImageService_SetCropHints_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "image/image_service.proto",
@@ -3,6 +3,7 @@ package tests
 import (
 	"testing"
 
+	"github.com/aidosgal/image-processing-service/internal/lib/permission"
 	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
 	suite "github.com/aidosgal/image-processing-service/tests/suite"
 	"github.com/stretchr/testify/assert"
@@ -10,7 +11,7 @@ import (
 )
 
 func TestDeleteImage(t *testing.T) {
-	ctx, s := suite.NewSuit(t)
+	ctx, s := suite.NewSuitWithRole(t, permission.RoleAdmin)
 
 	imageBytes, filename := generateTestImage()
 	uploadResp, err := s.ImageServiceClient.UploadImage(ctx, &imagev1.UploadImageRequest{
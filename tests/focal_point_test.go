@@ -0,0 +1,309 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"testing"
+
+	"github.com/aidosgal/image-processing-service/internal/config"
+	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
+	suite "github.com/aidosgal/image-processing-service/tests/suite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// subjectColor is the color of the subject drawn by generateSubject.
+var subjectColor = color.RGBA{R: 230, G: 40, B: 40, A: 255}
+
+// generateSubject draws a detailed, colorful square centered at (x, y),
+// relative to the image, on a flat gray background.
+func generateSubject(w, h int, x, y float64) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	cx, cy, r := int(x*float64(w)), int(y*float64(h)), min(w, h)/8
+	for py := 0; py < h; py++ {
+		for px := 0; px < w; px++ {
+			c := color.RGBA{R: 128, G: 128, B: 128, A: 255}
+			if px >= cx-r && px < cx+r && py >= cy-r && py < cy+r {
+				c = subjectColor
+				if (px/6+py/6)%2 == 0 {
+					c = color.RGBA{R: 120, G: 20, B: 20, A: 255}
+				}
+			}
+			img.Set(px, py, c)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		panic(err)
+	}
+
+	return buf.Bytes()
+}
+
+// isSubject reports whether c is either color of the subject, allowing for
+// compression.
+func isSubject(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return r>>8 > 100 && g>>8 < 80 && b>>8 < 80
+}
+
+func TestFocalPoint_FoundAtUpload(t *testing.T) {
+	ctx, s := suite.NewSuit(t)
+
+	uploadResp, err := s.ImageServiceClient.UploadImage(ctx, &imagev1.UploadImageRequest{
+		Image:    generateSubject(800, 400, 0.15, 0.5),
+		Filename: "subject.jpg",
+	})
+	require.NoError(t, err)
+
+	resp, err := s.ImageServiceClient.GetImage(ctx, &imagev1.GetImageRequest{ImageId: uploadResp.GetImageId()})
+	require.NoError(t, err)
+	focal := resp.GetMetadata().GetFocalPoint()
+	require.NotNil(t, focal)
+	assert.InDelta(t, 0.15, focal.GetX(), 0.05)
+	assert.InDelta(t, 0.5, focal.GetY(), 0.05)
+	assert.False(t, focal.GetManual())
+
+	crop, err := s.ImageServiceClient.TransformImage(ctx, &imagev1.TransformImageRequest{
+		ImageId: uploadResp.GetImageId(),
+		Operations: []*imagev1.Operation{
+			{Name: "smartcrop", Params: map[string]string{"width": "100", "height": "100"}},
+		},
+		Format: "png",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int32(100), crop.GetWidth())
+	assert.Equal(t, int32(100), crop.GetHeight())
+
+	img, _, err := image.Decode(bytes.NewReader(crop.GetImage()))
+	require.NoError(t, err)
+	assert.True(t, isSubject(img.At(30, 50)), "the subject stays in frame")
+}
+
+func TestSetFocalPoint(t *testing.T) {
+	ctx, s := suite.NewSuit(t)
+
+	uploadResp, err := s.ImageServiceClient.UploadImage(ctx, &imagev1.UploadImageRequest{
+		Image:    generateSubject(800, 400, 0.15, 0.5),
+		Filename: "subject.jpg",
+	})
+	require.NoError(t, err)
+	id := uploadResp.GetImageId()
+
+	transform := &imagev1.TransformImageRequest{
+		ImageId: id,
+		Operations: []*imagev1.Operation{
+			{Name: "smartcrop", Params: map[string]string{"width": "100", "height": "100"}},
+		},
+		Format: "png",
+	}
+	before, err := s.ImageServiceClient.TransformImage(ctx, transform)
+	require.NoError(t, err)
+
+	setResp, err := s.ImageServiceClient.SetFocalPoint(ctx, &imagev1.SetFocalPointRequest{ImageId: id, X: 0.9, Y: 0.5})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), setResp.GetRevision())
+	assert.Equal(t, 0.9, setResp.GetFocalPoint().GetX())
+	assert.True(t, setResp.GetFocalPoint().GetManual())
+
+	resp, err := s.ImageServiceClient.GetImage(ctx, &imagev1.GetImageRequest{ImageId: id})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), resp.GetMetadata().GetRevision())
+	assert.Equal(t, 0.9, resp.GetMetadata().GetFocalPoint().GetX())
+
+	after, err := s.ImageServiceClient.TransformImage(ctx, transform)
+	require.NoError(t, err)
+	assert.NotEqual(t, before.GetImage(), after.GetImage(), "crops follow the new focal point")
+
+	img, _, err := image.Decode(bytes.NewReader(after.GetImage()))
+	require.NoError(t, err)
+	assert.False(t, isSubject(img.At(30, 50)))
+
+	_, err = s.ImageServiceClient.SetFocalPoint(ctx, &imagev1.SetFocalPointRequest{ImageId: id, X: 1.5, Y: 0.5})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = s.ImageServiceClient.SetFocalPoint(ctx, &imagev1.SetFocalPointRequest{ImageId: 999999, X: 0.5, Y: 0.5})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestSetCropHints(t *testing.T) {
+	ctx, s := suite.NewSuit(t)
+
+	uploadResp, err := s.ImageServiceClient.UploadImage(ctx, &imagev1.UploadImageRequest{
+		Image:    generateSubject(800, 400, 0.15, 0.5),
+		Filename: "subject.jpg",
+	})
+	require.NoError(t, err)
+	id := uploadResp.GetImageId()
+
+	square := &imagev1.TransformImageRequest{
+		ImageId: id,
+		Operations: []*imagev1.Operation{
+			{Name: "smartcrop", Params: map[string]string{"width": "100", "height": "100"}},
+		},
+		Format: "png",
+	}
+	wide := &imagev1.TransformImageRequest{
+		ImageId: id,
+		Operations: []*imagev1.Operation{
+			{Name: "smartcrop", Params: map[string]string{"width": "100", "height": "50"}},
+		},
+		Format: "png",
+	}
+
+	// The right side of the image, away from the subject.
+	setResp, err := s.ImageServiceClient.SetCropHints(ctx, &imagev1.SetCropHintsRequest{
+		ImageId: id,
+		CropHints: []*imagev1.CropHint{
+			{AspectRatio: "2:2", X: 0.6, Y: 0, Width: 0.4, Height: 1},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), setResp.GetRevision())
+	require.Len(t, setResp.GetCropHints(), 1)
+	assert.Equal(t, "1:1", setResp.GetCropHints()[0].GetAspectRatio())
+
+	resp, err := s.ImageServiceClient.GetImage(ctx, &imagev1.GetImageRequest{ImageId: id})
+	require.NoError(t, err)
+	require.Len(t, resp.GetMetadata().GetCropHints(), 1)
+	assert.Equal(t, 0.6, resp.GetMetadata().GetCropHints()[0].GetX())
+
+	crop, err := s.ImageServiceClient.TransformImage(ctx, square)
+	require.NoError(t, err)
+	img, _, err := image.Decode(bytes.NewReader(crop.GetImage()))
+	require.NoError(t, err)
+	assert.False(t, isSubject(img.At(30, 50)), "square crops follow the hint")
+
+	crop, err = s.ImageServiceClient.TransformImage(ctx, wide)
+	require.NoError(t, err)
+	img, _, err = image.Decode(bytes.NewReader(crop.GetImage()))
+	require.NoError(t, err)
+	assert.True(t, isSubject(img.At(15, 25)), "other ratios still follow the focal point")
+
+	// No hints clears them.
+	setResp, err = s.ImageServiceClient.SetCropHints(ctx, &imagev1.SetCropHintsRequest{ImageId: id})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), setResp.GetRevision())
+	assert.Empty(t, setResp.GetCropHints())
+
+	crop, err = s.ImageServiceClient.TransformImage(ctx, square)
+	require.NoError(t, err)
+	img, _, err = image.Decode(bytes.NewReader(crop.GetImage()))
+	require.NoError(t, err)
+	assert.True(t, isSubject(img.At(30, 50)), "square crops follow the focal point again")
+}
+
+func TestSetCropHints_Invalid(t *testing.T) {
+	ctx, s := suite.NewSuit(t)
+
+	uploadResp, err := s.ImageServiceClient.UploadImage(ctx, &imagev1.UploadImageRequest{
+		Image:    generateSubject(400, 400, 0.5, 0.5),
+		Filename: "subject.jpg",
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		imageID  int64
+		hints    []*imagev1.CropHint
+		wantCode codes.Code
+	}{
+		{"no image id", 0, nil, codes.InvalidArgument},
+		{"bad aspect ratio", uploadResp.GetImageId(), []*imagev1.CropHint{{AspectRatio: "wide", Width: 1, Height: 1}}, codes.InvalidArgument},
+		{"outside the image", uploadResp.GetImageId(), []*imagev1.CropHint{{AspectRatio: "1:1", X: 0.5, Width: 0.6, Height: 0.5}}, codes.InvalidArgument},
+		{"no area", uploadResp.GetImageId(), []*imagev1.CropHint{{AspectRatio: "1:1", Width: 0.5}}, codes.InvalidArgument},
+		{"same ratio twice", uploadResp.GetImageId(), []*imagev1.CropHint{
+			{AspectRatio: "1:1", Width: 0.5, Height: 0.5},
+			{AspectRatio: "3:3", Width: 1, Height: 1},
+		}, codes.InvalidArgument},
+		{"unknown image", 999999, []*imagev1.CropHint{{AspectRatio: "1:1", Width: 1, Height: 1}}, codes.NotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.ImageServiceClient.SetCropHints(ctx, &imagev1.SetCropHintsRequest{ImageId: tt.imageID, CropHints: tt.hints})
+			assert.Equal(t, tt.wantCode, status.Code(err))
+		})
+	}
+}
+
+// TestSmartCropThumbnail runs its own server, since it needs a thumbnail
+// preset and reads the stored thumbnail.
+func TestSmartCropThumbnail(t *testing.T) {
+	t.Parallel()
+	if os.Getenv("TEST_SERVER_ADDR") != "" {
+		t.Skip("needs an in-process server to read the thumbnail")
+	}
+
+	cfg := config.MustLoadByPath("../config/local.yaml")
+	cfg.Transform.Presets = map[string][]config.OperationConfig{
+		"avatar": {{Name: "smartcrop", Params: map[string]string{"width": "64", "height": "64"}}},
+	}
+	cfg.Transform.ThumbnailPreset = "avatar"
+
+	cc, cleanup := suite.StartServer(t, cfg)
+	t.Cleanup(cleanup)
+	client := imagev1.NewImageServiceClient(cc)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.GRPC.Timeout)
+	defer cancel()
+	ctx = suite.WithTenant(ctx, suite.RandomTenant())
+
+	uploadResp, err := client.UploadImage(ctx, &imagev1.UploadImageRequest{
+		Image:    generateSubject(800, 400, 0.7, 0.5),
+		Filename: "subject.jpg",
+	})
+	require.NoError(t, err)
+
+	id := uploadResp.GetImageId()
+
+	resp, err := client.GetImage(ctx, &imagev1.GetImageRequest{ImageId: id})
+	require.NoError(t, err)
+
+	thumb := readThumbnail(t, resp.GetMetadata().GetThumbnailPath())
+	assert.Equal(t, image.Rect(0, 0, 64, 64), thumb.Bounds())
+	assert.True(t, isSubject(thumb.At(32, 32)), "the thumbnail is cropped around the subject")
+
+	_, err = client.SetFocalPoint(ctx, &imagev1.SetFocalPointRequest{ImageId: id, X: 0.1, Y: 0.5})
+	require.NoError(t, err)
+
+	thumb = readThumbnail(t, resp.GetMetadata().GetThumbnailPath())
+	assert.Equal(t, image.Rect(0, 0, 64, 64), thumb.Bounds())
+	assert.False(t, isSubject(thumb.At(32, 32)), "the thumbnail follows the new focal point")
+
+	// A square around the subject.
+	_, err = client.SetCropHints(ctx, &imagev1.SetCropHintsRequest{
+		ImageId:   id,
+		CropHints: []*imagev1.CropHint{{AspectRatio: "1:1", X: 0.6, Y: 0.3, Width: 0.2, Height: 0.4}},
+	})
+	require.NoError(t, err)
+
+	thumb = readThumbnail(t, resp.GetMetadata().GetThumbnailPath())
+	assert.True(t, isSubject(thumb.At(32, 32)), "the thumbnail follows the crop hint")
+
+	usage, err := client.GetUsage(ctx, &imagev1.GetUsageRequest{})
+	require.NoError(t, err)
+	info, err := os.Stat(resp.GetMetadata().GetThumbnailPath())
+	require.NoError(t, err)
+	assert.Equal(t, resp.GetMetadata().GetFileSize()+info.Size(), usage.GetUsage().GetTotalBytes(), "usage counts the regenerated thumbnail")
+}
+
+func readThumbnail(t *testing.T, path string) image.Image {
+	t.Helper()
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	require.NoError(t, err)
+
+	return img
+}
@@ -0,0 +1,47 @@
+package tests
+
+import (
+	"testing"
+
+	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
+	suite "github.com/aidosgal/image-processing-service/tests/suite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGetImage_ReturnsUploadedImage(t *testing.T) {
+	ctx, s := suite.NewSuit(t)
+
+	imageBytes, filename := generateTestImage()
+	uploadResp, err := s.ImageServiceClient.UploadImage(ctx, &imagev1.UploadImageRequest{
+		Image:    imageBytes,
+		Filename: filename,
+	})
+	require.NoError(t, err)
+
+	getResp, err := s.ImageServiceClient.GetImage(ctx, &imagev1.GetImageRequest{
+		ImageId: uploadResp.GetImageId(),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, imageBytes, getResp.GetImage())
+
+	metadata := getResp.GetMetadata()
+	assert.Equal(t, uploadResp.GetImageId(), metadata.GetImageId())
+	assert.Equal(t, s.Tenant, metadata.GetOwnerId())
+	assert.Equal(t, int64(len(imageBytes)), metadata.GetFileSize())
+	assert.Equal(t, int32(100), metadata.GetWidth())
+	assert.Equal(t, int32(100), metadata.GetHeight())
+	assert.Equal(t, "image/jpeg", metadata.GetMimeType())
+	assert.Equal(t, int64(1), metadata.GetRevision())
+	assert.NotEmpty(t, metadata.GetThumbnailPath())
+}
+
+func TestGetImage_NotFound(t *testing.T) {
+	ctx, s := suite.NewSuit(t)
+
+	_, err := s.ImageServiceClient.GetImage(ctx, &imagev1.GetImageRequest{ImageId: 999999})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
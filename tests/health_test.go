@@ -0,0 +1,26 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
+	suite "github.com/aidosgal/image-processing-service/tests/suite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestHealth_Serving(t *testing.T) {
+	_, s := suite.NewSuit(t)
+
+	// Health probes carry neither credentials nor a tenant.
+	ctx, cancel := context.WithTimeout(context.Background(), s.Cfg.GRPC.Timeout)
+	defer cancel()
+
+	for _, service := range []string{"", imagev1.ImageService_ServiceDesc.ServiceName} {
+		resp, err := s.HealthClient.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		require.NoError(t, err)
+		assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus(), "service %q", service)
+	}
+}
@@ -1,12 +1,22 @@
 package tests
 
 import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"strings"
 	"testing"
 
 	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
 	suite "github.com/aidosgal/image-processing-service/tests/suite"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestListImages(t *testing.T) {
@@ -34,3 +44,105 @@ func TestListImages(t *testing.T) {
 	}
 	assert.True(t, found, "Uploaded image not found in the list")
 }
+
+// generateSolidImage encodes a 64x64 PNG of a single color.
+func generateSolidImage(c color.Color) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		panic(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestListImages_ColorFilters(t *testing.T) {
+	ctx, s := suite.NewSuit(t)
+
+	upload := func(c color.Color, name string) int64 {
+		resp, err := s.ImageServiceClient.UploadImage(ctx, &imagev1.UploadImageRequest{
+			Image:    generateSolidImage(c),
+			Filename: name,
+		})
+		require.NoError(t, err)
+		return resp.GetImageId()
+	}
+
+	blue := upload(color.RGBA{R: 30, G: 60, B: 200, A: 255}, "blue.png")
+	orange := upload(color.RGBA{R: 240, G: 140, B: 20, A: 255}, "orange.png")
+	gray := upload(color.RGBA{R: 128, G: 128, B: 128, A: 255}, "gray.png")
+
+	ids := func(req *imagev1.ListImagesRequest) []int64 {
+		resp, err := s.ImageServiceClient.ListImages(ctx, req)
+		require.NoError(t, err)
+
+		var ids []int64
+		for _, img := range resp.GetImages() {
+			ids = append(ids, img.GetImageId())
+		}
+		return ids
+	}
+
+	assert.ElementsMatch(t, []int64{blue, orange, gray}, ids(&imagev1.ListImagesRequest{}))
+	assert.Equal(t, []int64{blue}, ids(&imagev1.ListImagesRequest{
+		Color: &imagev1.ColorFilter{Color: "#2040d0"},
+	}))
+	assert.Equal(t, []int64{gray}, ids(&imagev1.ListImagesRequest{GrayscaleOnly: true}))
+	assert.Empty(t, ids(&imagev1.ListImagesRequest{
+		Color: &imagev1.ColorFilter{Color: "#2040d0", MaxDistance: 1},
+	}))
+
+	resp, err := s.ImageServiceClient.ListImages(ctx, &imagev1.ListImagesRequest{
+		Color: &imagev1.ColorFilter{Color: "#f08c14"},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.GetImages(), 1)
+	colors := resp.GetImages()[0].GetColors()
+	assert.Equal(t, "#f08c14", colors.GetAverageColor())
+	require.Len(t, colors.GetPalette(), 1)
+	assert.Equal(t, 1.0, colors.GetPalette()[0].GetFraction())
+	assert.False(t, colors.GetGrayscale())
+}
+
+func TestListImages_InvalidColorFilter(t *testing.T) {
+	ctx, s := suite.NewSuit(t)
+
+	for _, f := range []*imagev1.ColorFilter{
+		{Color: "blue"},
+		{Color: "#0000ff", MaxDistance: -1},
+		{Color: "#0000ff", MinFraction: 1.5},
+	} {
+		_, err := s.ImageServiceClient.ListImages(ctx, &imagev1.ListImagesRequest{Color: f})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), f.String())
+	}
+}
+
+func TestListImages_Placeholders(t *testing.T) {
+	ctx, s := suite.NewSuit(t)
+
+	_, err := s.ImageServiceClient.UploadImage(ctx, &imagev1.UploadImageRequest{
+		Image:    generateScene(400, 300, 0.2, 0.3, 90),
+		Filename: "scene.jpg",
+	})
+	require.NoError(t, err)
+
+	resp, err := s.ImageServiceClient.ListImages(ctx, &imagev1.ListImagesRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.GetImages(), 1)
+
+	placeholder := resp.GetImages()[0].GetPlaceholder()
+	require.NotNil(t, placeholder)
+	assert.Len(t, placeholder.GetBlurhash(), 4+2*4*3, "4x3 components for a landscape image")
+
+	data, ok := strings.CutPrefix(placeholder.GetPreview(), "data:image/jpeg;base64,")
+	require.True(t, ok, placeholder.GetPreview())
+	raw, err := base64.StdEncoding.DecodeString(data)
+	require.NoError(t, err)
+	assert.Less(t, len(raw), 2048)
+
+	preview, err := jpeg.Decode(bytes.NewReader(raw))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 16, 12), preview.Bounds())
+}
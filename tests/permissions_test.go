@@ -0,0 +1,374 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/aidosgal/image-processing-service/internal/config"
+	"github.com/aidosgal/image-processing-service/internal/lib/auth"
+	"github.com/aidosgal/image-processing-service/internal/lib/permission"
+	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
+	suite "github.com/aidosgal/image-processing-service/tests/suite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// TestTransformImage_RequiresEditor runs its own server, since it needs
+// principals with different roles.
+func TestTransformImage_RequiresEditor(t *testing.T) {
+	t.Parallel()
+	if os.Getenv("TEST_SERVER_ADDR") != "" {
+		t.Skip("needs an in-process server with api key authentication")
+	}
+
+	cfg := config.MustLoadByPath("../config/local.yaml")
+	cfg.Auth = config.AuthConfig{
+		Mode: "api_key",
+		APIKeys: []config.APIKeyConfig{
+			{Name: "editor", Hash: auth.HashAPIKey("editor-key"), Tenant: "tenant-a", Roles: []string{"editor"}},
+			{Name: "viewer", Hash: auth.HashAPIKey("viewer-key"), Tenant: "tenant-a", Roles: []string{"viewer"}},
+		},
+	}
+
+	cc, cleanup := suite.StartServer(t, cfg)
+	t.Cleanup(cleanup)
+	client := imagev1.NewImageServiceClient(cc)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.GRPC.Timeout)
+	defer cancel()
+	editor := metadata.AppendToOutgoingContext(ctx, "x-api-key", "editor-key")
+	viewer := metadata.AppendToOutgoingContext(ctx, "x-api-key", "viewer-key")
+
+	imageBytes, filename := generateTestImage()
+	uploadResp, err := client.UploadImage(editor, &imagev1.UploadImageRequest{
+		Image:    imageBytes,
+		Filename: filename,
+	})
+	require.NoError(t, err)
+
+	transform := &imagev1.TransformImageRequest{
+		ImageId:    uploadResp.GetImageId(),
+		Operations: []*imagev1.Operation{{Name: "grayscale"}},
+	}
+
+	_, err = client.TransformImage(viewer, transform)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = client.GetImage(viewer, &imagev1.GetImageRequest{ImageId: uploadResp.GetImageId()})
+	require.NoError(t, err, "viewers may still get the image")
+
+	_, err = client.TransformImage(editor, transform)
+	require.NoError(t, err)
+}
+
+// TestRoleMatrix calls each method as each role of one tenant and checks that
+// exactly the roles below the method's policy are denied. Images don't exist,
+// so allowed calls that need one fail with another code.
+func TestRoleMatrix(t *testing.T) {
+	t.Parallel()
+	if os.Getenv("TEST_SERVER_ADDR") != "" {
+		t.Skip("needs an in-process server with api key authentication")
+	}
+
+	roles := []permission.Role{permission.RoleViewer, permission.RoleEditor, permission.RoleAdmin, permission.RoleOperator}
+	tenantID := suite.RandomTenant()
+
+	cfg := config.MustLoadByPath("../config/local.yaml")
+	cfg.Auth = config.AuthConfig{Mode: auth.ModeAPIKey}
+	for _, role := range roles {
+		cfg.Auth.APIKeys = append(cfg.Auth.APIKeys, config.APIKeyConfig{
+			Name:   string(role),
+			Hash:   auth.HashAPIKey(string(role) + "-key"),
+			Tenant: tenantID,
+			Roles:  []string{string(role)},
+		})
+	}
+
+	cc, cleanup := suite.StartServer(t, cfg)
+	t.Cleanup(cleanup)
+	client := imagev1.NewImageServiceClient(cc)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.GRPC.Timeout)
+	defer cancel()
+
+	const missingImage = 999_999
+	imageBytes, filename := generateTestImage()
+
+	tests := []struct {
+		name   string
+		lowest permission.Role
+		call   func(ctx context.Context) error
+	}{
+		{
+			name:   "ListImages",
+			lowest: permission.RoleViewer,
+			call: func(ctx context.Context) error {
+				_, err := client.ListImages(ctx, &imagev1.ListImagesRequest{})
+				return err
+			},
+		},
+		{
+			name:   "GetUsage",
+			lowest: permission.RoleViewer,
+			call: func(ctx context.Context) error {
+				_, err := client.GetUsage(ctx, &imagev1.GetUsageRequest{})
+				return err
+			},
+		},
+		{
+			name:   "GetUsage of another tenant",
+			lowest: permission.RoleOperator,
+			call: func(ctx context.Context) error {
+				_, err := client.GetUsage(ctx, &imagev1.GetUsageRequest{TenantId: suite.RandomTenant()})
+				return err
+			},
+		},
+		{
+			name:   "UploadImage",
+			lowest: permission.RoleEditor,
+			call: func(ctx context.Context) error {
+				_, err := client.UploadImage(ctx, &imagev1.UploadImageRequest{Image: imageBytes, Filename: filename})
+				return err
+			},
+		},
+		{
+			name:   "TransformImage",
+			lowest: permission.RoleEditor,
+			call: func(ctx context.Context) error {
+				_, err := client.TransformImage(ctx, &imagev1.TransformImageRequest{
+					ImageId:    missingImage,
+					Operations: []*imagev1.Operation{{Name: "grayscale"}},
+				})
+				return err
+			},
+		},
+		{
+			name:   "CreateAlbum",
+			lowest: permission.RoleEditor,
+			call: func(ctx context.Context) error {
+				_, err := client.CreateAlbum(ctx, &imagev1.CreateAlbumRequest{Name: "matrix"})
+				return err
+			},
+		},
+		{
+			name:   "DeleteImage",
+			lowest: permission.RoleAdmin,
+			call: func(ctx context.Context) error {
+				_, err := client.DeleteImage(ctx, &imagev1.DeleteImageRequest{ImageId: missingImage})
+				return err
+			},
+		},
+		{
+			name:   "PurgeImages",
+			lowest: permission.RoleAdmin,
+			call: func(ctx context.Context) error {
+				_, err := client.PurgeImages(ctx, &imagev1.PurgeImagesRequest{})
+				return err
+			},
+		},
+		{
+			name:   "SetQuota",
+			lowest: permission.RoleOperator,
+			call: func(ctx context.Context) error {
+				_, err := client.SetQuota(ctx, &imagev1.SetQuotaRequest{TenantId: suite.RandomTenant(), Quota: &imagev1.Quota{}})
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		allowed := false
+		for _, role := range roles {
+			allowed = allowed || role == tt.lowest
+			t.Run(tt.name+"/"+string(role), func(t *testing.T) {
+				err := tt.call(metadata.AppendToOutgoingContext(ctx, "x-api-key", string(role)+"-key"))
+				if allowed {
+					assert.NotEqual(t, codes.PermissionDenied, status.Code(err), "%s denied: %v", role, err)
+				} else {
+					assert.Equal(t, codes.PermissionDenied, status.Code(err), "%s allowed", role)
+				}
+			})
+		}
+	}
+}
+
+func TestPurgeImages(t *testing.T) {
+	ctx, s := suite.NewSuitWithRole(t, permission.RoleAdmin)
+
+	for i := 0; i < 2; i++ {
+		imageBytes, filename := generateTestImage()
+		_, err := s.ImageServiceClient.UploadImage(ctx, &imagev1.UploadImageRequest{
+			Image:    imageBytes,
+			Filename: filename,
+		})
+		require.NoError(t, err)
+	}
+
+	purgeResp, err := s.ImageServiceClient.PurgeImages(ctx, &imagev1.PurgeImagesRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), purgeResp.GetDeleted())
+
+	listResp, err := s.ImageServiceClient.ListImages(ctx, &imagev1.ListImagesRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, listResp.GetImages())
+}
+
+func TestShareImage_Validation(t *testing.T) {
+	ctx, s := suite.NewSuit(t)
+
+	imageBytes, filename := generateTestImage()
+	uploadResp, err := s.ImageServiceClient.UploadImage(ctx, &imagev1.UploadImageRequest{
+		Image:    imageBytes,
+		Filename: filename,
+	})
+	require.NoError(t, err)
+
+	_, err = s.ImageServiceClient.ShareImage(ctx, &imagev1.ShareImageRequest{
+		ImageId: uploadResp.GetImageId(),
+		Grantee: "someone",
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = s.ImageServiceClient.ShareImage(ctx, &imagev1.ShareImageRequest{
+		ImageId:    uploadResp.GetImageId() + 1_000_000,
+		Grantee:    "someone",
+		Permission: imagev1.Permission_PERMISSION_READ,
+	})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = s.ImageServiceClient.ShareImage(ctx, &imagev1.ShareImageRequest{
+		ImageId:    uploadResp.GetImageId(),
+		Grantee:    "someone",
+		Permission: imagev1.Permission_PERMISSION_READ,
+	})
+	require.NoError(t, err)
+
+	_, err = s.ImageServiceClient.RevokeShare(ctx, &imagev1.RevokeShareRequest{
+		Target:  &imagev1.RevokeShareRequest_ImageId{ImageId: uploadResp.GetImageId()},
+		Grantee: "someone",
+	})
+	require.NoError(t, err)
+}
+
+func TestAlbum(t *testing.T) {
+	ctx, s := suite.NewSuit(t)
+
+	imageBytes, filename := generateTestImage()
+	uploadResp, err := s.ImageServiceClient.UploadImage(ctx, &imagev1.UploadImageRequest{
+		Image:    imageBytes,
+		Filename: filename,
+	})
+	require.NoError(t, err)
+
+	albumResp, err := s.ImageServiceClient.CreateAlbum(ctx, &imagev1.CreateAlbumRequest{Name: "holidays"})
+	require.NoError(t, err)
+
+	_, err = s.ImageServiceClient.AddImageToAlbum(ctx, &imagev1.AddImageToAlbumRequest{
+		AlbumId: albumResp.GetAlbumId(),
+		ImageId: uploadResp.GetImageId(),
+	})
+	require.NoError(t, err)
+
+	_, err = s.ImageServiceClient.ShareAlbum(ctx, &imagev1.ShareAlbumRequest{
+		AlbumId:    albumResp.GetAlbumId(),
+		Grantee:    "someone",
+		Permission: imagev1.Permission_PERMISSION_WRITE,
+	})
+	require.NoError(t, err)
+}
+
+// TestAlbum_GranteeAccess checks what sharing an album grants on its images:
+// reading for read grantees and editing the focal point for write grantees,
+// but deletion for neither, even as admins of their own tenant.
+func TestAlbum_GranteeAccess(t *testing.T) {
+	t.Parallel()
+	if os.Getenv("TEST_SERVER_ADDR") != "" {
+		t.Skip("needs an in-process server with api key authentication")
+	}
+
+	ownerTenant, granteeTenant := suite.RandomTenant(), suite.RandomTenant()
+
+	cfg := config.MustLoadByPath("../config/local.yaml")
+	cfg.Auth = config.AuthConfig{
+		Mode: auth.ModeAPIKey,
+		APIKeys: []config.APIKeyConfig{
+			{Name: "owner", Hash: auth.HashAPIKey("owner-key"), Tenant: ownerTenant, Roles: []string{"editor"}},
+			{Name: "reader", Hash: auth.HashAPIKey("reader-key"), Tenant: granteeTenant, Roles: []string{"admin"}},
+			{Name: "writer", Hash: auth.HashAPIKey("writer-key"), Tenant: granteeTenant, Roles: []string{"admin"}},
+		},
+	}
+
+	cc, cleanup := suite.StartServer(t, cfg)
+	t.Cleanup(cleanup)
+	client := imagev1.NewImageServiceClient(cc)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.GRPC.Timeout)
+	defer cancel()
+	owner := metadata.AppendToOutgoingContext(ctx, "x-api-key", "owner-key")
+	reader := metadata.AppendToOutgoingContext(ctx, "x-api-key", "reader-key")
+	writer := metadata.AppendToOutgoingContext(ctx, "x-api-key", "writer-key")
+
+	imageBytes, filename := generateTestImage()
+	uploadResp, err := client.UploadImage(owner, &imagev1.UploadImageRequest{
+		Image:    imageBytes,
+		Filename: filename,
+	})
+	require.NoError(t, err)
+	id := uploadResp.GetImageId()
+	get := &imagev1.GetImageRequest{ImageId: id}
+
+	albumResp, err := client.CreateAlbum(owner, &imagev1.CreateAlbumRequest{Name: "holidays"})
+	require.NoError(t, err)
+	albumID := albumResp.GetAlbumId()
+
+	_, err = client.AddImageToAlbum(owner, &imagev1.AddImageToAlbumRequest{AlbumId: albumID, ImageId: id})
+	require.NoError(t, err)
+
+	_, err = client.GetImage(reader, get)
+	assert.Equal(t, codes.NotFound, status.Code(err), "image visible before sharing the album")
+
+	for grantee, level := range map[string]imagev1.Permission{
+		"reader": imagev1.Permission_PERMISSION_READ,
+		"writer": imagev1.Permission_PERMISSION_WRITE,
+	} {
+		_, err = client.ShareAlbum(owner, &imagev1.ShareAlbumRequest{
+			AlbumId:         albumID,
+			Grantee:         grantee,
+			GranteeTenantId: granteeTenant,
+			Permission:      level,
+		})
+		require.NoError(t, err)
+	}
+
+	getResp, err := client.GetImage(reader, get)
+	require.NoError(t, err, "read grantees may get images of the album")
+	assert.Equal(t, imageBytes, getResp.GetImage())
+	assert.Equal(t, ownerTenant, getResp.GetMetadata().GetOwnerId())
+
+	_, err = client.DeleteImage(reader, &imagev1.DeleteImageRequest{ImageId: id})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err), "read grantee deleted the owner's image")
+	_, err = client.DeleteImage(writer, &imagev1.DeleteImageRequest{ImageId: id})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err), "write grantee deleted the owner's image")
+
+	_, err = client.SetFocalPoint(reader, &imagev1.SetFocalPointRequest{ImageId: id, X: 0.5, Y: 0.5})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err), "read grantee set the focal point")
+	_, err = client.SetFocalPoint(writer, &imagev1.SetFocalPointRequest{ImageId: id, X: 0.25, Y: 0.75})
+	require.NoError(t, err, "write grantees may set the focal point")
+
+	_, err = client.RevokeShare(owner, &imagev1.RevokeShareRequest{
+		Target:          &imagev1.RevokeShareRequest_AlbumId{AlbumId: albumID},
+		Grantee:         "reader",
+		GranteeTenantId: granteeTenant,
+	})
+	require.NoError(t, err)
+
+	_, err = client.GetImage(reader, get)
+	assert.Equal(t, codes.NotFound, status.Code(err), "image visible after revoking the album share")
+
+	_, err = client.GetImage(owner, get)
+	require.NoError(t, err, "the image survives its grantees")
+}
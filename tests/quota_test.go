@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/aidosgal/image-processing-service/internal/lib/permission"
+	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
+	suite "github.com/aidosgal/image-processing-service/tests/suite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestQuota_ImageCount(t *testing.T) {
+	ctx, s := suite.NewSuitWithRole(t, permission.RoleOperator)
+
+	_, err := s.ImageServiceClient.SetQuota(ctx, &imagev1.SetQuotaRequest{
+		TenantId: s.Tenant,
+		Quota:    &imagev1.Quota{MaxImages: 1},
+	})
+	require.NoError(t, err)
+
+	imageBytes, filename := generateTestImage()
+	_, err = s.ImageServiceClient.UploadImage(ctx, &imagev1.UploadImageRequest{
+		Image:    imageBytes,
+		Filename: filename,
+	})
+	require.NoError(t, err)
+
+	_, err = s.ImageServiceClient.UploadImage(ctx, &imagev1.UploadImageRequest{
+		Image:    imageBytes,
+		Filename: filename,
+	})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	usageResp, err := s.ImageServiceClient.GetUsage(ctx, &imagev1.GetUsageRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), usageResp.GetUsage().GetImageCount())
+	assert.Equal(t, int64(1), usageResp.GetQuota().GetMaxImages())
+	assert.Greater(t, usageResp.GetUsage().GetTotalBytes(), int64(len(imageBytes)), "usage must include the thumbnail")
+	assert.Equal(t, int64(len(imageBytes)), usageResp.GetUsage().GetMonthlyUploadBytes())
+}
+
+func TestUsage_DeleteReleasesStorage(t *testing.T) {
+	ctx, s := suite.NewSuitWithRole(t, permission.RoleAdmin)
+
+	imageBytes, filename := generateTestImage()
+	uploadResp, err := s.ImageServiceClient.UploadImage(ctx, &imagev1.UploadImageRequest{
+		Image:    imageBytes,
+		Filename: filename,
+	})
+	require.NoError(t, err)
+
+	_, err = s.ImageServiceClient.DeleteImage(ctx, &imagev1.DeleteImageRequest{
+		ImageId: uploadResp.GetImageId(),
+	})
+	require.NoError(t, err)
+
+	usageResp, err := s.ImageServiceClient.GetUsage(ctx, &imagev1.GetUsageRequest{})
+	require.NoError(t, err)
+	assert.Zero(t, usageResp.GetUsage().GetImageCount())
+	assert.Zero(t, usageResp.GetUsage().GetTotalBytes())
+	assert.Equal(t, int64(len(imageBytes)), usageResp.GetUsage().GetMonthlyUploadBytes())
+}
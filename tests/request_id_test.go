@@ -0,0 +1,41 @@
+package tests
+
+import (
+	"testing"
+
+	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
+	suite "github.com/aidosgal/image-processing-service/tests/suite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const requestIDKey = "x-request-id"
+
+func TestRequestID_Propagated(t *testing.T) {
+	ctx, s := suite.NewSuit(t)
+
+	ctx = metadata.AppendToOutgoingContext(ctx, requestIDKey, "test-request-42")
+
+	var header metadata.MD
+	_, err := s.ImageServiceClient.ListImages(ctx, &imagev1.ListImagesRequest{}, grpc.Header(&header))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"test-request-42"}, header.Get(requestIDKey))
+}
+
+func TestRequestID_Generated(t *testing.T) {
+	ctx, s := suite.NewSuit(t)
+
+	// Ids with characters outside the allowed set are replaced. gRPC itself
+	// rejects non-printable values, so a space is used.
+	ctx = metadata.AppendToOutgoingContext(ctx, requestIDKey, "bad id")
+
+	var header metadata.MD
+	_, err := s.ImageServiceClient.ListImages(ctx, &imagev1.ListImagesRequest{}, grpc.Header(&header))
+	require.NoError(t, err)
+
+	ids := header.Get(requestIDKey)
+	require.Len(t, ids, 1)
+	assert.Len(t, ids[0], 32)
+}
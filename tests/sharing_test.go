@@ -0,0 +1,175 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/aidosgal/image-processing-service/internal/config"
+	"github.com/aidosgal/image-processing-service/internal/lib/auth"
+	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
+	suite "github.com/aidosgal/image-processing-service/tests/suite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// TestSharedImage_GranteeAccess needs principals that differ from their
+// tenant, so it runs its own server with API key authentication.
+func TestSharedImage_GranteeAccess(t *testing.T) {
+	t.Parallel()
+	if os.Getenv("TEST_SERVER_ADDR") != "" {
+		t.Skip("needs an in-process server with api key authentication")
+	}
+
+	cfg := config.MustLoadByPath("../config/local.yaml")
+	cfg.Auth = config.AuthConfig{
+		Mode: "api_key",
+		APIKeys: []config.APIKeyConfig{
+			{Name: "alice", Hash: auth.HashAPIKey("alice-key"), Tenant: "tenant-a", Roles: []string{"editor"}},
+			{Name: "bob", Hash: auth.HashAPIKey("bob-key"), Tenant: "tenant-b", Roles: []string{"viewer"}},
+			// Another bob, in another tenant.
+			{Name: "bob", Hash: auth.HashAPIKey("other-bob-key"), Tenant: "tenant-c", Roles: []string{"admin"}},
+		},
+	}
+
+	cc, cleanup := suite.StartServer(t, cfg)
+	t.Cleanup(cleanup)
+	client := imagev1.NewImageServiceClient(cc)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.GRPC.Timeout)
+	defer cancel()
+	alice := metadata.AppendToOutgoingContext(ctx, "x-api-key", "alice-key")
+	bob := metadata.AppendToOutgoingContext(ctx, "x-api-key", "bob-key")
+	otherBob := metadata.AppendToOutgoingContext(ctx, "x-api-key", "other-bob-key")
+
+	imageBytes, filename := generateTestImage()
+	uploadResp, err := client.UploadImage(alice, &imagev1.UploadImageRequest{
+		Image:    imageBytes,
+		Filename: filename,
+	})
+	require.NoError(t, err)
+	get := &imagev1.GetImageRequest{ImageId: uploadResp.GetImageId()}
+
+	_, err = client.GetImage(bob, get)
+	assert.Equal(t, codes.NotFound, status.Code(err), "image visible before sharing")
+
+	// Without a tenant, the grantee is a principal of the owner's tenant.
+	_, err = client.ShareImage(alice, &imagev1.ShareImageRequest{
+		ImageId:    uploadResp.GetImageId(),
+		Grantee:    "bob",
+		Permission: imagev1.Permission_PERMISSION_READ,
+	})
+	require.NoError(t, err)
+
+	_, err = client.GetImage(bob, get)
+	assert.Equal(t, codes.NotFound, status.Code(err), "image shared with bob of the owner's tenant is visible to bob of tenant-b")
+
+	_, err = client.ShareImage(alice, &imagev1.ShareImageRequest{
+		ImageId:         uploadResp.GetImageId(),
+		Grantee:         "bob",
+		GranteeTenantId: "tenant-b",
+		Permission:      imagev1.Permission_PERMISSION_WRITE,
+	})
+	require.NoError(t, err)
+
+	getResp, err := client.GetImage(bob, get)
+	require.NoError(t, err)
+	assert.Equal(t, imageBytes, getResp.GetImage())
+	assert.Equal(t, "tenant-a", getResp.GetMetadata().GetOwnerId())
+
+	_, err = client.GetImage(otherBob, get)
+	assert.Equal(t, codes.NotFound, status.Code(err), "image visible to the same subject in another tenant")
+	_, err = client.DeleteImage(otherBob, &imagev1.DeleteImageRequest{ImageId: uploadResp.GetImageId()})
+	assert.Equal(t, codes.NotFound, status.Code(err), "image deletable by the same subject in another tenant")
+
+	_, err = client.RevokeShare(alice, &imagev1.RevokeShareRequest{
+		Target:          &imagev1.RevokeShareRequest_ImageId{ImageId: uploadResp.GetImageId()},
+		Grantee:         "bob",
+		GranteeTenantId: "tenant-c",
+	})
+	assert.Equal(t, codes.NotFound, status.Code(err), "revoked a share that was never granted")
+
+	_, err = client.RevokeShare(alice, &imagev1.RevokeShareRequest{
+		Target:          &imagev1.RevokeShareRequest_ImageId{ImageId: uploadResp.GetImageId()},
+		Grantee:         "bob",
+		GranteeTenantId: "tenant-b",
+	})
+	require.NoError(t, err)
+
+	_, err = client.GetImage(bob, get)
+	assert.Equal(t, codes.NotFound, status.Code(err), "image visible after revoking")
+}
+
+// TestSharedImage_WriteShareCannotDelete checks that a write share lets the
+// grantee edit the focal point but not delete the image, even as an admin
+// of its own tenant.
+func TestSharedImage_WriteShareCannotDelete(t *testing.T) {
+	t.Parallel()
+	if os.Getenv("TEST_SERVER_ADDR") != "" {
+		t.Skip("needs an in-process server with api key authentication")
+	}
+
+	cfg := config.MustLoadByPath("../config/local.yaml")
+	cfg.Auth = config.AuthConfig{
+		Mode: "api_key",
+		APIKeys: []config.APIKeyConfig{
+			{Name: "owner", Hash: auth.HashAPIKey("owner-key"), Tenant: "tenant-a", Roles: []string{"admin"}},
+			{Name: "carol", Hash: auth.HashAPIKey("carol-key"), Tenant: "tenant-b", Roles: []string{"admin"}},
+			{Name: "dave", Hash: auth.HashAPIKey("dave-key"), Tenant: "tenant-b", Roles: []string{"admin"}},
+		},
+	}
+
+	cc, cleanup := suite.StartServer(t, cfg)
+	t.Cleanup(cleanup)
+	client := imagev1.NewImageServiceClient(cc)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.GRPC.Timeout)
+	defer cancel()
+	owner := metadata.AppendToOutgoingContext(ctx, "x-api-key", "owner-key")
+	carol := metadata.AppendToOutgoingContext(ctx, "x-api-key", "carol-key")
+	dave := metadata.AppendToOutgoingContext(ctx, "x-api-key", "dave-key")
+
+	imageBytes, filename := generateTestImage()
+	uploadResp, err := client.UploadImage(owner, &imagev1.UploadImageRequest{
+		Image:    imageBytes,
+		Filename: filename,
+	})
+	require.NoError(t, err)
+	id := uploadResp.GetImageId()
+
+	for grantee, level := range map[string]imagev1.Permission{
+		"carol": imagev1.Permission_PERMISSION_WRITE,
+		"dave":  imagev1.Permission_PERMISSION_READ,
+	} {
+		_, err = client.ShareImage(owner, &imagev1.ShareImageRequest{
+			ImageId:         id,
+			Grantee:         grantee,
+			GranteeTenantId: "tenant-b",
+			Permission:      level,
+		})
+		require.NoError(t, err)
+	}
+
+	_, err = client.DeleteImage(carol, &imagev1.DeleteImageRequest{ImageId: id})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err), "write grantee deleted the owner's image")
+	_, err = client.DeleteImage(dave, &imagev1.DeleteImageRequest{ImageId: id})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err), "read grantee deleted the owner's image")
+
+	focalResp, err := client.SetFocalPoint(carol, &imagev1.SetFocalPointRequest{ImageId: id, X: 0.25, Y: 0.75})
+	require.NoError(t, err, "write grantees may set the focal point")
+	assert.True(t, focalResp.GetFocalPoint().GetManual())
+
+	_, err = client.SetFocalPoint(dave, &imagev1.SetFocalPointRequest{ImageId: id, X: 0.5, Y: 0.5})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err), "read grantee set the focal point")
+
+	getResp, err := client.GetImage(owner, &imagev1.GetImageRequest{ImageId: id})
+	require.NoError(t, err)
+	assert.InDelta(t, 0.25, getResp.GetMetadata().GetFocalPoint().GetX(), 1e-9)
+
+	deleteResp, err := client.DeleteImage(owner, &imagev1.DeleteImageRequest{ImageId: id})
+	require.NoError(t, err)
+	assert.True(t, deleteResp.GetSuccess())
+}
@@ -0,0 +1,131 @@
+package tests
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+
+	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
+	suite "github.com/aidosgal/image-processing-service/tests/suite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// generateScene encodes a w x h gradient with a bright square at (x, y),
+// given as fractions of the size, so scaled copies look alike.
+func generateScene(w, h int, x, y float64, quality int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for py := 0; py < h; py++ {
+		for px := 0; px < w; px++ {
+			fx, fy := float64(px)/float64(w), float64(py)/float64(h)
+			c := color.RGBA{R: uint8(220 * fx), G: uint8(140 * fy), B: 70, A: 255}
+			if fx > x && fx < x+0.25 && fy > y && fy < y+0.25 {
+				c = color.RGBA{R: 250, G: 245, B: 230, A: 255}
+			}
+			img.Set(px, py, c)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		panic(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestFindSimilarImages_RanksNearCopies(t *testing.T) {
+	ctx, s := suite.NewSuit(t)
+
+	upload := func(data []byte, name string) int64 {
+		resp, err := s.ImageServiceClient.UploadImage(ctx, &imagev1.UploadImageRequest{
+			Image:    data,
+			Filename: name,
+		})
+		require.NoError(t, err)
+		return resp.GetImageId()
+	}
+
+	original := upload(generateScene(400, 300, 0.2, 0.3, 90), "original.jpg")
+	smaller := upload(generateScene(200, 150, 0.2, 0.3, 40), "smaller.jpg")
+	different := upload(generateScene(400, 300, 0.7, 0.6, 90), "different.jpg")
+
+	resp, err := s.ImageServiceClient.FindSimilarImages(ctx, &imagev1.FindSimilarImagesRequest{
+		Source: &imagev1.FindSimilarImagesRequest_ImageId{ImageId: original},
+	})
+	require.NoError(t, err)
+
+	var ids []int64
+	for _, m := range resp.GetMatches() {
+		ids = append(ids, m.GetImage().GetImageId())
+	}
+	assert.Contains(t, ids, smaller)
+	assert.NotContains(t, ids, original, "the source is not its own match")
+	assert.NotContains(t, ids, different)
+	assert.NotNil(t, resp.GetMatches()[0].GetImage().GetHashes())
+
+	resp, err = s.ImageServiceClient.FindSimilarImages(ctx, &imagev1.FindSimilarImagesRequest{
+		Source:    &imagev1.FindSimilarImagesRequest_Image{Image: generateScene(800, 600, 0.2, 0.3, 75)},
+		Algorithm: imagev1.HashAlgorithm_HASH_ALGORITHM_DHASH,
+	})
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(resp.GetMatches()), 2)
+	for i := 1; i < len(resp.GetMatches()); i++ {
+		assert.LessOrEqual(t, resp.GetMatches()[i-1].GetDistance(), resp.GetMatches()[i].GetDistance(), "closest first")
+	}
+}
+
+func TestUploadImage_FlagsNearDuplicates(t *testing.T) {
+	ctx, s := suite.NewSuit(t)
+
+	first, err := s.ImageServiceClient.UploadImage(ctx, &imagev1.UploadImageRequest{
+		Image:              generateScene(400, 300, 0.2, 0.3, 90),
+		Filename:           "first.jpg",
+		FlagNearDuplicates: true,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, first.GetNearDuplicates())
+
+	copied, err := s.ImageServiceClient.UploadImage(ctx, &imagev1.UploadImageRequest{
+		Image:              generateScene(300, 225, 0.2, 0.3, 50),
+		Filename:           "copy.jpg",
+		FlagNearDuplicates: true,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, copied.GetNearDuplicates())
+	assert.Equal(t, first.GetImageId(), copied.GetNearDuplicates()[0].GetImage().GetImageId())
+}
+
+func TestFindSimilarImages_InvalidRequests(t *testing.T) {
+	ctx, s := suite.NewSuit(t)
+
+	tooFar := int32(65)
+	tests := []struct {
+		name string
+		req  *imagev1.FindSimilarImagesRequest
+		code codes.Code
+	}{
+		{"no source", &imagev1.FindSimilarImagesRequest{}, codes.InvalidArgument},
+		{"distance out of range", &imagev1.FindSimilarImagesRequest{
+			Source:      &imagev1.FindSimilarImagesRequest_ImageId{ImageId: 1},
+			MaxDistance: &tooFar,
+		}, codes.InvalidArgument},
+		{"not an image", &imagev1.FindSimilarImagesRequest{
+			Source: &imagev1.FindSimilarImagesRequest_Image{Image: []byte("not an image")},
+		}, codes.InvalidArgument},
+		{"unknown image", &imagev1.FindSimilarImagesRequest{
+			Source: &imagev1.FindSimilarImagesRequest_ImageId{ImageId: 999999},
+		}, codes.NotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.ImageServiceClient.FindSimilarImages(ctx, tt.req)
+			assert.Equal(t, tt.code, status.Code(err))
+		})
+	}
+}
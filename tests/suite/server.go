@@ -0,0 +1,132 @@
+package suite
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aidosgal/image-processing-service/internal/app"
+	"github.com/aidosgal/image-processing-service/internal/config"
+	"github.com/aidosgal/image-processing-service/internal/lib/healthcheck"
+	"github.com/aidosgal/image-processing-service/internal/repository/memory"
+	"github.com/aidosgal/image-processing-service/internal/repository/sqlite"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// StartServer runs the production app, built by app.NewApp, in-process on
+// an in-memory listener, with its storage root and variant cache in a
+// temporary directory. The repository is kept in memory, or in a temporary
+// SQLite file when TEST_REPOSITORY is "sqlite". Everything else comes from
+// cfg. It returns a client connection and a cleanup that shuts the app down.
+func StartServer(t testing.TB, cfg *config.Config) (*grpc.ClientConn, func()) {
+	t.Helper()
+
+	root := t.TempDir()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if os.Getenv("TEST_SERVER_LOG") != "" {
+		log = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+
+	cfg.Storage.Root = root
+	cfg.Cache.DiskDir = filepath.Join(root, "cache")
+	// Paths in the config are relative to the repo root.
+	if cfg.GRPC.TLS.Enabled {
+		cfg.GRPC.TLS.CertFile = configPath(cfg.GRPC.TLS.CertFile)
+		cfg.GRPC.TLS.KeyFile = configPath(cfg.GRPC.TLS.KeyFile)
+		if cfg.GRPC.TLS.ClientCAFile != "" {
+			cfg.GRPC.TLS.ClientCAFile = configPath(cfg.GRPC.TLS.ClientCAFile)
+		}
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	application := app.NewApp(log, cfg,
+		app.WithRepository(newRepository(t, cfg, root)),
+		app.WithGRPCListener(lis),
+		app.WithRegisterer(prometheus.NewRegistry()),
+	)
+
+	application.Health.Start()
+	waitReady(t, application.Health)
+
+	served := make(chan struct{})
+	go func() {
+		defer close(served)
+		if err := application.GRPCSrv.Run(); err != nil {
+			t.Errorf("gRPC server failed: %v", err)
+		}
+	}()
+
+	creds, err := transportCredentials(cfg)
+	if err != nil {
+		t.Fatalf("failed to load tls credentials: %v", err)
+	}
+
+	cc, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(creds),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	cleanup := func() {
+		cc.Close()
+
+		if err := application.Shutdown(); err != nil {
+			t.Errorf("failed to shut down: %v", err)
+		}
+		<-served
+	}
+
+	return cc, cleanup
+}
+
+func newRepository(t testing.TB, cfg *config.Config, root string) app.Repository {
+	t.Helper()
+
+	switch driver := os.Getenv("TEST_REPOSITORY"); driver {
+	case "", "memory":
+		return memory.NewRepository()
+	case "sqlite":
+		dbCfg := cfg.Database
+		dbCfg.SQLitePath = filepath.Join(root, "image_service.db")
+
+		repo, err := sqlite.NewRepository(context.Background(), dbCfg)
+		if err != nil {
+			t.Fatalf("failed to open sqlite repository: %v", err)
+		}
+
+		return repo
+	default:
+		t.Fatalf("unknown TEST_REPOSITORY %q, want memory or sqlite", driver)
+		return nil
+	}
+}
+
+// waitReady waits for the first round of health checks, so health probes
+// see the server as serving from the start.
+func waitReady(t testing.TB, checker *healthcheck.Checker) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		ready, failed := checker.Ready()
+		if ready {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("server not ready: %v", failed)
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -2,22 +2,39 @@ package suite
 
 import (
 	"context"
-	"net"
-	"strconv"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/aidosgal/image-processing-service/internal/config"
+	"github.com/aidosgal/image-processing-service/internal/lib/auth"
+	"github.com/aidosgal/image-processing-service/internal/lib/permission"
+	"github.com/aidosgal/image-processing-service/internal/lib/tenant"
 	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
 )
 
+const apiKeyMetadataKey = "x-api-key"
+
 type Suite struct {
 	*testing.T
 	Cfg                *config.Config
+	Tenant             string
 	ImageServiceClient imagev1.ImageServiceClient
+	HealthClient       healthpb.HealthClient
 }
 
+// NewSuit starts an in-process server for the test, see StartServer. When
+// TEST_SERVER_ADDR is set it connects to the server at that address instead.
 func NewSuit(t *testing.T) (context.Context, *Suite) {
 	t.Helper()
 	t.Parallel()
@@ -26,23 +43,156 @@ func NewSuit(t *testing.T) (context.Context, *Suite) {
 
 	ctx, cancelCtx := context.WithTimeout(context.Background(), cfg.GRPC.Timeout)
 
+	tenantID := RandomTenant()
+	ctx = WithTenant(ctx, tenantID)
+
 	t.Cleanup(func() {
 		t.Helper()
 		cancelCtx()
 	})
 
-	cc, err := grpc.DialContext(context.Background(), grpcAddress(cfg), grpc.WithTransportCredentials(insecure.NewCredentials()))
-	if err != nil {
-		t.Fatalf("grpc server connection failed: %v", err)
+	var cc *grpc.ClientConn
+	if addr := os.Getenv("TEST_SERVER_ADDR"); addr != "" {
+		cc = dialServer(t, cfg, addr)
+	} else {
+		var cleanup func()
+		cc, cleanup = StartServer(t, cfg)
+		t.Cleanup(cleanup)
 	}
 
 	return ctx, &Suite{
 		T:                  t,
 		Cfg:                cfg,
+		Tenant:             tenantID,
 		ImageServiceClient: imagev1.NewImageServiceClient(cc),
+		HealthClient:       healthpb.NewHealthClient(cc),
+	}
+}
+
+// NewSuitWithRole is NewSuit for tests that call methods above the editor
+// role, which is all a caller gets without authentication. The in-process
+// server runs in api_key mode with a single key of the suite's tenant holding
+// role, and the returned context sends that key. The test is skipped when
+// TEST_SERVER_ADDR is set, since that server doesn't know the key.
+func NewSuitWithRole(t *testing.T, role permission.Role) (context.Context, *Suite) {
+	t.Helper()
+	t.Parallel()
+
+	if os.Getenv("TEST_SERVER_ADDR") != "" {
+		t.Skip("needs an in-process server with api key authentication")
+	}
+
+	cfg := config.MustLoadByPath("../config/local.yaml")
+
+	tenantID := RandomTenant()
+	key := RandomTenant()
+	cfg.Auth = config.AuthConfig{
+		Mode: auth.ModeAPIKey,
+		APIKeys: []config.APIKeyConfig{
+			{Name: string(role), Hash: auth.HashAPIKey(key), Tenant: tenantID, Roles: []string{string(role)}},
+		},
+	}
+
+	ctx, cancelCtx := context.WithTimeout(context.Background(), cfg.GRPC.Timeout)
+	ctx = metadata.AppendToOutgoingContext(ctx, apiKeyMetadataKey, key)
+	t.Cleanup(cancelCtx)
+
+	cc, cleanup := StartServer(t, cfg)
+	t.Cleanup(cleanup)
+
+	return ctx, &Suite{
+		T:                  t,
+		Cfg:                cfg,
+		Tenant:             tenantID,
+		ImageServiceClient: imagev1.NewImageServiceClient(cc),
+		HealthClient:       healthpb.NewHealthClient(cc),
+	}
+}
+
+// dialServer connects to an image service started outside the tests, such
+// as the one from docker-compose.
+func dialServer(t *testing.T, cfg *config.Config, addr string) *grpc.ClientConn {
+	t.Helper()
+
+	creds, err := transportCredentials(cfg)
+	if err != nil {
+		t.Fatalf("failed to load tls credentials: %v", err)
 	}
+
+	cc, err := grpc.NewClient(addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		t.Fatalf("grpc server connection failed: %v", err)
+	}
+	t.Cleanup(func() { cc.Close() })
+
+	return cc
+}
+
+// WithTenant returns a context whose outgoing requests act on behalf of the given tenant.
+// A tenant already set on ctx is replaced.
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	md.Set(tenant.MetadataKey, tenantID)
+
+	return metadata.NewOutgoingContext(ctx, md)
 }
 
-func grpcAddress(cfg *config.Config) string {
-	return net.JoinHostPort("localhost", strconv.Itoa(cfg.GRPC.Port))
+func RandomTenant() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	return "test-" + hex.EncodeToString(b)
+}
+
+// transportCredentials dials with TLS when the server has it enabled. The
+// server certificate is trusted directly unless TEST_TLS_CA_FILE is set.
+// With mutual TLS the client key pair is read from TEST_TLS_CLIENT_CERT and
+// TEST_TLS_CLIENT_KEY.
+func transportCredentials(cfg *config.Config) (credentials.TransportCredentials, error) {
+	if !cfg.GRPC.TLS.Enabled {
+		return insecure.NewCredentials(), nil
+	}
+
+	caFile := os.Getenv("TEST_TLS_CA_FILE")
+	if caFile == "" {
+		caFile = configPath(cfg.GRPC.TLS.CertFile)
+	}
+
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", caFile)
+	}
+
+	tlsCfg := &tls.Config{
+		RootCAs:    roots,
+		ServerName: "localhost",
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if cfg.GRPC.TLS.ClientCAFile != "" {
+		cert, err := tls.LoadX509KeyPair(os.Getenv("TEST_TLS_CLIENT_CERT"), os.Getenv("TEST_TLS_CLIENT_KEY"))
+		if err != nil {
+			return nil, fmt.Errorf("mutual tls requires a client key pair: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return credentials.NewTLS(tlsCfg), nil
+}
+
+// configPath resolves paths from the config, which are relative to the repo root.
+func configPath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join("..", path)
 }
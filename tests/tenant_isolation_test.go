@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
+	suite "github.com/aidosgal/image-processing-service/tests/suite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestTenantIsolation(t *testing.T) {
+	ctx, s := suite.NewSuit(t)
+
+	imageBytes, filename := generateTestImage()
+	uploadResp, err := s.ImageServiceClient.UploadImage(ctx, &imagev1.UploadImageRequest{
+		Image:    imageBytes,
+		Filename: filename,
+	})
+	require.NoError(t, err)
+
+	otherCtx := suite.WithTenant(context.WithoutCancel(ctx), suite.RandomTenant())
+	otherCtx, cancel := context.WithTimeout(otherCtx, s.Cfg.GRPC.Timeout)
+	defer cancel()
+
+	listResp, err := s.ImageServiceClient.ListImages(otherCtx, &imagev1.ListImagesRequest{})
+	require.NoError(t, err)
+	for _, img := range listResp.GetImages() {
+		assert.NotEqual(t, uploadResp.GetImageId(), img.GetImageId(), "image of another tenant is listed")
+	}
+
+	_, err = s.ImageServiceClient.GetImage(otherCtx, &imagev1.GetImageRequest{
+		ImageId: uploadResp.GetImageId(),
+	})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = s.ImageServiceClient.TransformImage(otherCtx, &imagev1.TransformImageRequest{
+		ImageId:    uploadResp.GetImageId(),
+		Operations: []*imagev1.Operation{{Name: "grayscale"}},
+	})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	// Without authentication nobody may delete; the cross-tenant delete is
+	// covered with API keys in TestSharedImage_GranteeAccess.
+	_, err = s.ImageServiceClient.DeleteImage(otherCtx, &imagev1.DeleteImageRequest{
+		ImageId: uploadResp.GetImageId(),
+	})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	getResp, err := s.ImageServiceClient.GetImage(ctx, &imagev1.GetImageRequest{
+		ImageId: uploadResp.GetImageId(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, s.Tenant, getResp.GetMetadata().GetOwnerId())
+}
+
+func TestTenantRequired(t *testing.T) {
+	_, s := suite.NewSuit(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.Cfg.GRPC.Timeout)
+	defer cancel()
+
+	_, err := s.ImageServiceClient.ListImages(ctx, &imagev1.ListImagesRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestUploadFilenameTraversal(t *testing.T) {
+	ctx, s := suite.NewSuit(t)
+
+	imageBytes, _ := generateTestImage()
+	other := suite.RandomTenant()
+	filename := "../" + other + "/test_image.jpg"
+
+	var paths []string
+	for range 2 {
+		uploadResp, err := s.ImageServiceClient.UploadImage(ctx, &imagev1.UploadImageRequest{
+			Image:    imageBytes,
+			Filename: filename,
+		})
+		require.NoError(t, err)
+
+		getResp, err := s.ImageServiceClient.GetImage(ctx, &imagev1.GetImageRequest{ImageId: uploadResp.GetImageId()})
+		require.NoError(t, err)
+		assert.Equal(t, "test_image.jpg", getResp.GetMetadata().GetFilename())
+
+		path := getResp.GetMetadata().GetFilePath()
+		assert.Equal(t, s.Tenant, filepath.Base(filepath.Dir(path)), "stored outside the tenant's directory")
+		assert.NotContains(t, path, other)
+		paths = append(paths, path)
+	}
+
+	assert.NotEqual(t, paths[0], paths[1], "uploads with the same name share a file")
+}
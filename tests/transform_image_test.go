@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"bytes"
+	"image"
+	"testing"
+
+	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
+	suite "github.com/aidosgal/image-processing-service/tests/suite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestTransformImage(t *testing.T) {
+	ctx, s := suite.NewSuit(t)
+
+	uploadResp, err := s.ImageServiceClient.UploadImage(ctx, &imagev1.UploadImageRequest{
+		Image:    generateScene(400, 300, 0.2, 0.3, 90),
+		Filename: "scene.jpg",
+	})
+	require.NoError(t, err)
+
+	req := &imagev1.TransformImageRequest{
+		ImageId: uploadResp.GetImageId(),
+		Operations: []*imagev1.Operation{
+			{Name: "brightness", Params: map[string]string{"percent": "10"}},
+			{Name: "grayscale"},
+			{Name: "resize", Params: map[string]string{"width": "100"}},
+		},
+		Format: "png",
+	}
+	resp, err := s.ImageServiceClient.TransformImage(ctx, req)
+	require.NoError(t, err)
+
+	assert.Equal(t, "image/png", resp.GetMimeType())
+	assert.Equal(t, int32(100), resp.GetWidth())
+	assert.Equal(t, int32(75), resp.GetHeight())
+
+	img, format, err := image.Decode(bytes.NewReader(resp.GetImage()))
+	require.NoError(t, err)
+	assert.Equal(t, "png", format)
+	r, g, b, _ := img.At(60, 40).RGBA()
+	assert.Equal(t, r, g, "grayscale")
+	assert.Equal(t, g, b, "grayscale")
+
+	again, err := s.ImageServiceClient.TransformImage(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, resp.GetImage(), again.GetImage())
+
+	preset, err := s.ImageServiceClient.TransformImage(ctx, &imagev1.TransformImageRequest{
+		ImageId: uploadResp.GetImageId(),
+		Preset:  "noir",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "image/jpeg", preset.GetMimeType(), "the original's format is kept")
+	assert.Equal(t, int32(400), preset.GetWidth())
+}
+
+func TestTransformImage_InvalidRequests(t *testing.T) {
+	ctx, s := suite.NewSuit(t)
+
+	uploadResp, err := s.ImageServiceClient.UploadImage(ctx, &imagev1.UploadImageRequest{
+		Image:    generateScene(200, 150, 0.2, 0.3, 90),
+		Filename: "scene.jpg",
+	})
+	require.NoError(t, err)
+	id := uploadResp.GetImageId()
+
+	tests := []struct {
+		name string
+		req  *imagev1.TransformImageRequest
+		code codes.Code
+	}{
+		{"no image", &imagev1.TransformImageRequest{}, codes.InvalidArgument},
+		{"unknown operation", &imagev1.TransformImageRequest{
+			ImageId:    id,
+			Operations: []*imagev1.Operation{{Name: "emboss"}},
+		}, codes.InvalidArgument},
+		{"parameter out of range", &imagev1.TransformImageRequest{
+			ImageId:    id,
+			Operations: []*imagev1.Operation{{Name: "blur", Params: map[string]string{"sigma": "500"}}},
+		}, codes.InvalidArgument},
+		{"resize beyond the size limit", &imagev1.TransformImageRequest{
+			ImageId:    id,
+			Operations: []*imagev1.Operation{{Name: "resize", Params: map[string]string{"height": "8192"}}},
+		}, codes.InvalidArgument},
+		{"unknown preset", &imagev1.TransformImageRequest{ImageId: id, Preset: "sepia"}, codes.InvalidArgument},
+		{"preset and operations", &imagev1.TransformImageRequest{
+			ImageId:    id,
+			Preset:     "noir",
+			Operations: []*imagev1.Operation{{Name: "invert"}},
+		}, codes.InvalidArgument},
+		{"unknown format", &imagev1.TransformImageRequest{ImageId: id, Format: "webp"}, codes.InvalidArgument},
+		{"unknown image", &imagev1.TransformImageRequest{
+			ImageId:    999999,
+			Operations: []*imagev1.Operation{{Name: "invert"}},
+		}, codes.NotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.ImageServiceClient.TransformImage(ctx, tt.req)
+			assert.Equal(t, tt.code, status.Code(err), status.Convert(err).Message())
+		})
+	}
+}
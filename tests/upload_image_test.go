@@ -3,14 +3,19 @@ package tests
 import (
 	"bytes"
 	"crypto/rand"
+	"encoding/binary"
+	"hash/crc32"
 	"image"
 	"image/jpeg"
+	"image/png"
 	"testing"
 
 	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
 	suite "github.com/aidosgal/image-processing-service/tests/suite"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func generateTestImage() ([]byte, string) {
@@ -132,3 +137,30 @@ func TestUploadMultipleImages(t *testing.T) {
 		assert.Greater(t, result.ImageID, int64(0))
 	}
 }
+
+// TestUploadImage_DecompressionBomb uploads a tiny PNG whose header claims
+// 100000x100000 pixels, which must be rejected before it is decoded.
+func TestUploadImage_DecompressionBomb(t *testing.T) {
+	ctx, s := suite.NewSuit(t)
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1))))
+	data := buf.Bytes()
+
+	// The IHDR chunk follows the 8 byte signature: length, type, width and
+	// height, then the rest of its data and its CRC over type and data.
+	ihdr := data[8+4 : 8+4+4+13]
+	binary.BigEndian.PutUint32(ihdr[4:], 100_000)
+	binary.BigEndian.PutUint32(ihdr[8:], 100_000)
+	binary.BigEndian.PutUint32(data[8+4+4+13:], crc32.ChecksumIEEE(ihdr))
+
+	_, err := s.ImageServiceClient.UploadImage(ctx, &imagev1.UploadImageRequest{
+		Image:    data,
+		Filename: "bomb.png",
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), status.Convert(err).Message())
+
+	usageResp, err := s.ImageServiceClient.GetUsage(ctx, &imagev1.GetUsageRequest{})
+	require.NoError(t, err)
+	assert.Zero(t, usageResp.GetUsage().GetImageCount())
+}
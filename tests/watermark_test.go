@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"os"
+	"testing"
+
+	"github.com/aidosgal/image-processing-service/internal/config"
+	"github.com/aidosgal/image-processing-service/internal/lib/auth"
+	imagev1 "github.com/aidosgal/image-processing-service/pkg/gen/go/image"
+	suite "github.com/aidosgal/image-processing-service/tests/suite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestGetImage_Watermark(t *testing.T) {
+	ctx, s := suite.NewSuit(t)
+
+	original := generateScene(400, 300, 0.2, 0.3, 90)
+	uploadResp, err := s.ImageServiceClient.UploadImage(ctx, &imagev1.UploadImageRequest{
+		Image:    original,
+		Filename: "scene.jpg",
+	})
+	require.NoError(t, err)
+
+	plain, err := s.ImageServiceClient.GetImage(ctx, &imagev1.GetImageRequest{ImageId: uploadResp.GetImageId()})
+	require.NoError(t, err)
+	assert.Equal(t, original, plain.GetImage())
+
+	marked, err := s.ImageServiceClient.GetImage(ctx, &imagev1.GetImageRequest{
+		ImageId:   uploadResp.GetImageId(),
+		Watermark: "sample",
+	})
+	require.NoError(t, err)
+	assert.NotEqual(t, original, marked.GetImage())
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(marked.GetImage()))
+	require.NoError(t, err)
+	assert.Equal(t, "jpeg", format)
+	assert.Equal(t, 400, cfg.Width)
+	assert.Equal(t, 300, cfg.Height)
+
+	again, err := s.ImageServiceClient.GetImage(ctx, &imagev1.GetImageRequest{
+		ImageId:   uploadResp.GetImageId(),
+		Watermark: "sample",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, marked.GetImage(), again.GetImage())
+
+	_, err = s.ImageServiceClient.GetImage(ctx, &imagev1.GetImageRequest{
+		ImageId:   uploadResp.GetImageId(),
+		Watermark: "missing",
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// TestGetImage_WatermarkPerAPIKey runs its own server, since it needs API
+// keys with watermark profiles and a profile rendered at upload.
+func TestGetImage_WatermarkPerAPIKey(t *testing.T) {
+	t.Parallel()
+	if os.Getenv("TEST_SERVER_ADDR") != "" {
+		t.Skip("needs an in-process server with api key authentication")
+	}
+
+	cfg := config.MustLoadByPath("../config/local.yaml")
+	cfg.Watermark.Profiles = map[string]config.WatermarkProfile{
+		"partner": {Text: "PARTNER", Tile: true, OnUpload: true},
+		"sample":  {Text: "SAMPLE"},
+	}
+	cfg.Auth = config.AuthConfig{
+		Mode: "api_key",
+		APIKeys: []config.APIKeyConfig{
+			{Name: "owner", Hash: auth.HashAPIKey("owner-key"), Tenant: "tenant-a", Roles: []string{"editor"}},
+			{Name: "partner", Hash: auth.HashAPIKey("partner-key"), Tenant: "tenant-a", Roles: []string{"viewer"}, Watermark: "partner"},
+		},
+	}
+
+	cc, cleanup := suite.StartServer(t, cfg)
+	t.Cleanup(cleanup)
+	client := imagev1.NewImageServiceClient(cc)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.GRPC.Timeout)
+	defer cancel()
+	owner := metadata.AppendToOutgoingContext(ctx, "x-api-key", "owner-key")
+	partner := metadata.AppendToOutgoingContext(ctx, "x-api-key", "partner-key")
+
+	original := generateScene(400, 300, 0.2, 0.3, 90)
+	uploadResp, err := client.UploadImage(owner, &imagev1.UploadImageRequest{
+		Image:    original,
+		Filename: "scene.jpg",
+	})
+	require.NoError(t, err)
+	get := &imagev1.GetImageRequest{ImageId: uploadResp.GetImageId()}
+
+	ownerResp, err := client.GetImage(owner, get)
+	require.NoError(t, err)
+	assert.Equal(t, original, ownerResp.GetImage())
+
+	partnerResp, err := client.GetImage(partner, get)
+	require.NoError(t, err)
+	assert.NotEqual(t, original, partnerResp.GetImage(), "the key's profile applies without asking")
+
+	stored, err := client.GetImage(owner, &imagev1.GetImageRequest{ImageId: uploadResp.GetImageId(), Watermark: "partner"})
+	require.NoError(t, err)
+	assert.Equal(t, partnerResp.GetImage(), stored.GetImage())
+
+	_, err = client.GetImage(partner, &imagev1.GetImageRequest{ImageId: uploadResp.GetImageId(), Watermark: "sample"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err), "the key's profile cannot be swapped")
+}